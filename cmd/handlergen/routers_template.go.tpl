@@ -1,14 +1,15 @@
 package {{.PackageName}}
 
 import (
+	"gin-example/internal/pkg/cache"
 	"gin-example/internal/pkg/core"
 	"gin-example/internal/repository/mysql"
 
 	"go.uber.org/zap"
 )
 
-func RegisterGenerated{{.StructName}}Routes(logger *zap.Logger, db mysql.Repo, r core.RouterGroup) {
-	h := New(logger, db)
+func RegisterGenerated{{.StructName}}Routes(logger *zap.Logger, db mysql.Repo, r core.RouterGroup, cache cache.Cache) {
+	h := New(logger, db, cache)
 
 	// 新增数据
 	r.POST("/{{.PackageName}}", h.Create())
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"io"
+	"time"
 
 	"gin-example/internal/pkg/env"
 
@@ -33,11 +34,94 @@ type Config struct {
 	} `toml:"mysql"`
 
 	Redis struct {
-		Addr string `toml:"addr"`
-		Pass string `toml:"pass"`
-		Db   int    `toml:"db"`
+		Mode         string   `toml:"mode"` // standalone / sentinel / cluster，默认 standalone
+		Addr         string   `toml:"addr"`
+		Addrs        []string `toml:"addrs"` // sentinel 为哨兵地址，cluster 为节点地址；为空时使用 addr
+		MasterName   string   `toml:"masterName"`
+		SentinelPass string   `toml:"sentinelPass"`
+		Pass         string   `toml:"pass"`
+		Db           int      `toml:"db"`
 	} `toml:"redis"`
 
+	Cache struct {
+		Codec             string `toml:"codec"` // json / msgpack / gob
+		CompressThreshold int    `toml:"compressThreshold"`
+
+		Bloom struct {
+			Enable            bool          `toml:"enable"`
+			Backend           string        `toml:"backend"` // redis（默认）/ local，local 只适用于单实例部署
+			ExpectedItems     uint64        `toml:"expectedItems"`
+			FalsePositiveRate float64       `toml:"falsePositiveRate"`
+			RebuildInterval   time.Duration `toml:"rebuildInterval"`
+		} `toml:"bloom"`
+
+		Warmup struct {
+			Timeout time.Duration `toml:"timeout"`
+		} `toml:"warmup"`
+
+		Snapshot struct {
+			Enable bool          `toml:"enable"`
+			Path   string        `toml:"path"`
+			MaxAge time.Duration `toml:"maxAge"`
+		} `toml:"snapshot"`
+	} `toml:"cache"`
+
+	RateLimit struct {
+		RulesFile string          `toml:"rulesFile"` // 外部规则文件，修改后无需重启即可生效，设置后忽略 rules
+		MaxKeys   int             `toml:"maxKeys"`   // 每条规则最多保留的限流器数量，超出时淘汰最久未使用的
+		IdleTTL   time.Duration   `toml:"idleTTL"`   // 限流器闲置超过该时间后回收
+		Rules     []RateLimitRule `toml:"rules"`
+
+		// TrustedProxies 可信代理的 IP 或 CIDR，只有来自这些地址的请求才使用 X-Forwarded-For / X-Real-IP，默认按直连地址限流
+		TrustedProxies []string `toml:"trustedProxies"`
+	} `toml:"rateLimit"`
+
+	Concurrency struct {
+		Enable       bool          `toml:"enable"`
+		Algorithm    string        `toml:"algorithm"`    // aimd / vegas / gradient，默认 gradient
+		InitialLimit int           `toml:"initialLimit"` // 初始并发上限
+		MinLimit     int           `toml:"minLimit"`
+		MaxLimit     int           `toml:"maxLimit"`
+		Timeout      time.Duration `toml:"timeout"`  // aimd 算法中耗时超过该值视为过载
+		MaxQueue     int           `toml:"maxQueue"` // 并发数达到上限时最多排队的请求数
+		MaxWait      time.Duration `toml:"maxWait"`  // 排队请求最多等待的时间
+	} `toml:"concurrency"`
+
+	Shedding struct {
+		Enable             bool           `toml:"enable"`
+		CPUThreshold       float64        `toml:"cpuThreshold"`       // CPU 使用率达到该值（0-100）时视为满载
+		SheddableThreshold float64        `toml:"sheddableThreshold"` // 压力达到该值时拒绝 sheddable 请求
+		DefaultThreshold   float64        `toml:"defaultThreshold"`   // 压力达到该值时拒绝 default 请求
+		HighThreshold      float64        `toml:"highThreshold"`      // 压力达到该值时拒绝 high 请求
+		TrustHeader        bool           `toml:"trustHeader"`        // 信任请求头 X-Criticality，只应在内部服务之间启用
+		Rules              []SheddingRule `toml:"rules"`
+	} `toml:"shedding"`
+
+	CircuitBreaker struct {
+		Default   CircuitBreakerPolicy    `toml:"default"`
+		Services  []CircuitBreakerService `toml:"services"`
+		Propagate bool                    `toml:"propagate"` // 通过 Redis 将强制状态同步到所有实例
+		Channel   string                  `toml:"channel"`   // 同步使用的频道和 Hash，默认 circuitbreaker:commands
+		Routes    []CircuitBreakerRoute   `toml:"routes"`    // 服务端路由组熔断
+	} `toml:"circuitBreaker"`
+
+	Bulkhead struct {
+		Default  BulkheadPolicy    `toml:"default"`
+		Services []BulkheadService `toml:"services"`
+	} `toml:"bulkhead"`
+
+	Resilience struct {
+		Pipelines []ResiliencePipeline `toml:"pipelines"`
+	} `toml:"resilience"`
+
+	Quota struct {
+		Enable      bool           `toml:"enable"`
+		DefaultPlan string         `toml:"defaultPlan"` // 未绑定套餐的调用方使用的套餐，为空时不计量
+		Retention   time.Duration  `toml:"retention"`   // 窗口结束后计数保留的时长，供计费导出
+		Plans       []QuotaPlan    `toml:"plans"`
+		Bindings    []QuotaBinding `toml:"bindings"`
+	} `toml:"quota"`
+
 	Mongo struct {
 		URI        string `toml:"uri"`
 		UserName   string `toml:"username"`
@@ -73,6 +157,121 @@ type Config struct {
 	} `toml:"server"`
 }
 
+// RateLimitRule 限流规则，请求匹配的所有规则都会生效
+type RateLimitRule struct {
+	Name    string   `toml:"name"`
+	Path    string   `toml:"path"`    // 路由模式，:param 和 * 匹配一段路径，末尾的 ** 匹配剩余部分
+	Methods []string `toml:"methods"` // 为空时匹配全部方法
+	Roles   []string `toml:"roles"`   // 为空时不限制用户角色
+	APIKeys []string `toml:"apiKeys"` // 为空时不限制 API Key
+	RPS     float64  `toml:"rps"`
+	Burst   int      `toml:"burst"`
+	Key     string   `toml:"key"`     // 限流维度：global / ip / user / apikey / header:<name>，默认 ip
+	Backend string   `toml:"backend"` // 限流状态保存位置：local / redis，默认 local，多实例部署时使用 redis 共享配额
+}
+
+// SheddingRule 按路由指定请求优先级
+type SheddingRule struct {
+	Path        string   `toml:"path"`        // 路由模式，* 匹配一段路径，末尾的 /** 匹配剩余部分
+	Methods     []string `toml:"methods"`     // 为空时匹配全部方法
+	Criticality string   `toml:"criticality"` // sheddable / default / high / critical
+}
+
+// CircuitBreakerPolicy 熔断策略，服务未设置的字段使用默认策略
+type CircuitBreakerPolicy struct {
+	FailureThreshold         int           `toml:"failureThreshold"`         // 不使用滑动窗口时，连续失败次数阈值
+	ResetTimeout             time.Duration `toml:"resetTimeout"`             // 熔断后进入半开启状态的等待时间
+	WindowType               string        `toml:"windowType"`               // count / time，为空时使用连续失败次数
+	WindowSize               int           `toml:"windowSize"`               // 计数窗口为调用次数，时间窗口为秒数
+	MinimumCalls             int           `toml:"minimumCalls"`             // 窗口内调用数达到该值后才判断是否熔断
+	FailureRateThreshold     float64       `toml:"failureRateThreshold"`     // 失败率阈值（百分比）
+	SlowCallDuration         time.Duration `toml:"slowCallDuration"`         // 耗时超过该值视为慢调用
+	SlowCallRateThreshold    float64       `toml:"slowCallRateThreshold"`    // 慢调用率阈值（百分比）
+	PermittedCallsInHalfOpen int           `toml:"permittedCallsInHalfOpen"` // 半开启状态下允许的试探请求数
+}
+
+// CircuitBreakerService 按服务单独配置的熔断策略
+type CircuitBreakerService struct {
+	Name   string               `toml:"name"`
+	Hosts  []string             `toml:"hosts"` // 属于该服务的 host，共用一个熔断器
+	Policy CircuitBreakerPolicy `toml:"policy"`
+}
+
+// CircuitBreakerRoute 服务端路由组熔断，处理器返回 5xx 或超时计为失败
+type CircuitBreakerRoute struct {
+	Name     string               `toml:"name"`     // 路由组名称，熔断器名称为 http-server:<name>
+	Methods  []string             `toml:"methods"`  // 受保护的请求方法，默认 GET / HEAD
+	Timeout  time.Duration        `toml:"timeout"`  // 处理耗时超过该值计为失败，0 表示只按请求 context 判断
+	Fallback string               `toml:"fallback"` // unavailable / static / cache，默认 unavailable
+	Payload  string               `toml:"payload"`  // static 降级返回的 JSON，cache 降级没有缓存时同样返回该数据
+	CacheTTL time.Duration        `toml:"cacheTTL"` // cache 降级时正常响应的保存时长
+	Policy   CircuitBreakerPolicy `toml:"policy"`
+}
+
+// BulkheadPolicy 隔离舱策略，服务未设置的字段使用默认策略
+type BulkheadPolicy struct {
+	Type          string        `toml:"type"`          // semaphore / pool
+	MaxConcurrent int           `toml:"maxConcurrent"` // 最大并发调用数
+	MaxWait       time.Duration `toml:"maxWait"`       // 已满时最多等待的时间
+	QueueSize     int           `toml:"queueSize"`     // pool 类型的队列长度
+}
+
+// BulkheadService 按下游依赖单独配置的隔离舱
+type BulkheadService struct {
+	Name   string         `toml:"name"`
+	Hosts  []string       `toml:"hosts"` // 属于该依赖的 host，共用一个隔离舱
+	Policy BulkheadPolicy `toml:"policy"`
+}
+
+// ResiliencePipeline 下游依赖的容错策略组合，未设置的策略不生效
+type ResiliencePipeline struct {
+	Name      string              `toml:"name"`
+	Hosts     []string            `toml:"hosts"`    // 属于该依赖的 host
+	Timeout   time.Duration       `toml:"timeout"`  // 每次尝试的超时时间
+	Breaker   bool                `toml:"breaker"`  // 使用 circuitBreaker 中同名的熔断器
+	Bulkhead  bool                `toml:"bulkhead"` // 使用 bulkhead 中同名的隔离舱
+	Retry     ResilienceRetry     `toml:"retry"`
+	RateLimit ResilienceRateLimit `toml:"rateLimit"`
+	Hedge     ResilienceHedge     `toml:"hedge"`
+}
+
+// ResilienceRetry 重试策略，maxRetries 为 0 时不重试
+type ResilienceRetry struct {
+	MaxRetries         int           `toml:"maxRetries"`
+	MinInterval        time.Duration `toml:"minInterval"`
+	MaxInterval        time.Duration `toml:"maxInterval"`
+	MaxJitter          time.Duration `toml:"maxJitter"`
+	GRPCCodes          []string      `toml:"grpcCodes"`          // 重试的 gRPC 状态码，如 UNAVAILABLE，默认只重试 UNAVAILABLE
+	BudgetRatio        float64       `toml:"budgetRatio"`        // 重试预算：重试占请求的比例，0 表示不限制
+	BudgetMinPerSecond int           `toml:"budgetMinPerSecond"` // 重试预算：每秒固定允许的重试次数
+}
+
+// ResilienceRateLimit 调用下游的限流策略，rps 为 0 时不限流
+type ResilienceRateLimit struct {
+	RPS   float64 `toml:"rps"`
+	Burst int     `toml:"burst"`
+}
+
+// ResilienceHedge 对冲请求策略，等待 delay 后仍未返回时再发出一个请求，maxAttempts 小于 2 时不对冲
+type ResilienceHedge struct {
+	Delay       time.Duration `toml:"delay"`
+	MaxAttempts int           `toml:"maxAttempts"`
+	Percentile  float64       `toml:"percentile"` // 按最近成功调用耗时的分位数对冲，delay 为最小延迟，0 表示固定延迟
+}
+
+// QuotaPlan 套餐，配额为 0 表示该窗口不限制
+type QuotaPlan struct {
+	Name    string `toml:"name"`
+	Daily   int64  `toml:"daily"`   // 每个自然日的调用次数
+	Monthly int64  `toml:"monthly"` // 每个自然月的调用次数
+}
+
+// QuotaBinding 调用方与套餐的绑定关系
+type QuotaBinding struct {
+	Subject string `toml:"subject"` // 调用方：user:<id> / apikey:<key>
+	Plan    string `toml:"plan"`
+}
+
 var (
 	//go:embed dev_configs.toml
 	devConfigs []byte
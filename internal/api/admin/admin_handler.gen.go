@@ -1,27 +1,45 @@
 package admin
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"strconv"
 	"time"
 
+	"gin-example/configs"
 	"gin-example/internal/code"
 	"gin-example/internal/pkg/cache"
 	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/shutdown"
 	"gin-example/internal/repository/mysql"
 	"gin-example/internal/repository/mysql/dao"
 	"gin-example/internal/repository/mysql/model"
 
 	"go.uber.org/zap"
+	"gorm.io/gen"
 	"gorm.io/gorm"
 )
 
+const (
+	// cacheTag 缓存标签，写操作后通过该标签失效所有相关缓存
+	cacheTag = "admin"
+)
+
+var (
+	// listKeys 列表缓存键空间
+	listKeys = cache.NewKeySpace(cacheTag+":list", cache.WithKeyTTL(5*time.Minute), cache.WithKeyTags(cacheTag))
+
+	// itemKeys 详情缓存键空间，与列表共用同一个标签
+	itemKeys = cache.NewKeySpace(cacheTag+":{id:int}", cache.WithKeyTTL(10*time.Minute), cache.WithKeyTags(cacheTag))
+)
+
 type handler struct {
 	logger  *zap.Logger
 	writeDB *dao.Query
 	readDB  *dao.Query
 	cache   cache.Cache
+	loader  *cache.Refresher
+	filter  cache.BloomFilter
 }
 
 type genResultInfo struct {
@@ -29,13 +47,98 @@ type genResultInfo struct {
 	Error        error `json:"error"`
 }
 
-func New(logger *zap.Logger, db mysql.Repo, cache cache.Cache) *handler {
-	return &handler{
+func New(logger *zap.Logger, db mysql.Repo, c cache.Cache) *handler {
+	h := &handler{
 		logger:  logger,
 		writeDB: dao.Use(db.GetDbW()),
 		readDB:  dao.Use(db.GetDbR()),
-		cache:   cache,
+		cache:   c,
+	}
+
+	// 软过期后返回旧值并后台刷新，避免热点键过期时集中回源
+	h.loader = cache.NewRefresher(c, cache.WithRefreshName(cacheTag), cache.WithRefreshLogger(logger))
+	h.loader.RegisterKeySpace(listKeys, h.loadList, cache.RefreshPolicy{
+		SoftTTL: 4 * time.Minute,
+		Beta:    1,
+	})
+
+	byIDPolicy := cache.RefreshPolicy{
+		SoftTTL: 8 * time.Minute,
+		Beta:    1,
+	}
+
+	// 布隆过滤器拦截一定不存在的 ID，启动时从数据库构建并定期重建
+	if bloomConfig := configs.Get().Cache.Bloom; bloomConfig.Enable {
+		h.filter = cache.NewBloomFilter(c, cache.Namespace()+":"+cacheTag, cache.BloomConfig{
+			Backend:           bloomConfig.Backend,
+			ExpectedItems:     bloomConfig.ExpectedItems,
+			FalsePositiveRate: bloomConfig.FalsePositiveRate,
+			RebuildInterval:   bloomConfig.RebuildInterval,
+		})
+
+		// 定期重建在服务关闭时停止
+		rebuildCtx, cancel := context.WithCancel(context.Background())
+		shutdown.RegisterHook(cancel)
+		go cache.RebuildPeriodically(rebuildCtx, h.filter, bloomConfig.RebuildInterval, h.seedIDs, logger)
+		byIDPolicy.Filter = h.filter
+	}
+	h.loader.RegisterKeySpace(itemKeys, h.loadByID, byIDPolicy)
+
+	// 实例对外提供服务前预热列表和详情缓存
+	cache.RegisterWarmup(cacheTag, h.warmup)
+
+	return h
+}
+
+// warmup 从数据库加载列表，写入列表缓存和每条数据的详情缓存
+func (h *handler) warmup(ctx context.Context) error {
+	list, err := h.readDB.Admin.WithContext(ctx).Find()
+	if err != nil {
+		return err
+	}
+
+	items := make(map[string]interface{}, len(list)+1)
+	items[listKeys.Key()] = list
+	for _, item := range list {
+		items[itemKeys.Key(item.ID)] = item
+	}
+	return h.loader.Prime(items)
+}
+
+// seedIDs 遍历全部 ID，用于构建布隆过滤器
+func (h *handler) seedIDs(ctx context.Context, add func(items ...string) error) error {
+	var batch []*model.Admin
+	return h.readDB.Admin.WithContext(ctx).Select(h.readDB.Admin.ID).FindInBatches(&batch, 1000, func(tx gen.Dao, _ int) error {
+		ids := make([]string, len(batch))
+		for i, item := range batch {
+			ids[i] = strconv.Itoa(int(item.ID))
+		}
+		return add(ids...)
+	})
+}
+
+// loadList 列表数据回源
+func (h *handler) loadList(ctx context.Context, key string) (interface{}, error) {
+	return h.readDB.Admin.WithContext(ctx).Find()
+}
+
+// loadByID 详情数据回源
+func (h *handler) loadByID(ctx context.Context, key string) (interface{}, error) {
+	args, ok := itemKeys.Parse(key)
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.readDB.Admin.WithContext(ctx).Where(h.readDB.Admin.ID.Eq(int32(id))).First()
+	if err == gorm.ErrRecordNotFound {
+		return nil, cache.ErrKeyNotFound
 	}
+	return data, err
 }
 
 // Create 新增数据
@@ -69,6 +172,12 @@ func (h *handler) Create() core.HandlerFunc {
 			return
 		}
 
+		// 删除相关缓存，并将新 ID 加入布隆过滤器
+		_ = h.loader.InvalidateTag(itemKeys.Tags()...)
+		if h.filter != nil {
+			_ = h.filter.Add(ctx.RequestContext(), strconv.Itoa(int(createData.ID)))
+		}
+
 		ctx.Payload(createData)
 	}
 }
@@ -81,35 +190,21 @@ func (h *handler) Create() core.HandlerFunc {
 // @Produce json
 // @Success 200 {object} []model.Admin
 // @Failure 400 {object} code.Failure
+// @Failure 500 {object} code.Failure
 // @Router /api/admins [get]
 func (h *handler) List() core.HandlerFunc {
 	return func(ctx core.Context) {
-		// 尝试从缓存获取
+		// 优先读缓存，未命中时回源
 		var list []*model.Admin
-		cacheKey := "admin:list"
-		
-		if exists, _ := h.cache.Exists(cacheKey); exists {
-			if err := h.cache.Get(cacheKey, &list); err == nil {
-				ctx.Payload(list)
-				return
-			}
-		}
-
-		// 缓存未命中，从数据库获取
-		var err error
-		list, err = h.readDB.Admin.WithContext(ctx.RequestContext()).Find()
-		if err != nil {
+		if err := h.loader.GetOrLoad(ctx.RequestContext(), listKeys.Key(), &list); err != nil && err != cache.ErrKeyNotFound {
 			ctx.AbortWithError(core.Error(
-				http.StatusBadRequest,
+				http.StatusInternalServerError,
 				code.ServerError,
 				err.Error()),
 			)
 			return
 		}
 
-		// 存储到缓存，过期时间5分钟
-		_ = h.cache.Set(cacheKey, list, 5*time.Minute)
-
 		ctx.Payload(list)
 	}
 }
@@ -123,6 +218,7 @@ func (h *handler) List() core.HandlerFunc {
 // @Param id path string true "id"
 // @Success 200 {object} model.Admin
 // @Failure 400 {object} code.Failure
+// @Failure 500 {object} code.Failure
 // @Router /api/admin/{id} [get]
 func (h *handler) GetByID() core.HandlerFunc {
 	return func(ctx core.Context) {
@@ -136,21 +232,10 @@ func (h *handler) GetByID() core.HandlerFunc {
 			return
 		}
 
-		// 尝试从缓存获取
+		// 优先读缓存，未命中时回源
 		var data *model.Admin
-		cacheKey := fmt.Sprintf("admin:%d", id)
-		
-		if exists, _ := h.cache.Exists(cacheKey); exists {
-			if err := h.cache.Get(cacheKey, &data); err == nil {
-				ctx.Payload(data)
-				return
-			}
-		}
-
-		// 缓存未命中，从数据库获取
-		data, err = h.readDB.Admin.WithContext(ctx.RequestContext()).Where(h.readDB.Admin.ID.Eq(int32(id))).First()
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
+		if err := h.loader.GetOrLoad(ctx.RequestContext(), itemKeys.Key(id), &data); err != nil {
+			if err == cache.ErrKeyNotFound {
 				ctx.AbortWithError(core.Error(
 					http.StatusBadRequest,
 					code.ServerError,
@@ -160,16 +245,13 @@ func (h *handler) GetByID() core.HandlerFunc {
 			}
 
 			ctx.AbortWithError(core.Error(
-				http.StatusBadRequest,
+				http.StatusInternalServerError,
 				code.ServerError,
 				err.Error()),
 			)
 			return
 		}
 
-		// 存储到缓存，过期时间10分钟
-		_ = h.cache.Set(cacheKey, data, 10*time.Minute)
-
 		ctx.Payload(data)
 	}
 }
@@ -225,9 +307,7 @@ func (h *handler) UpdateByID() core.HandlerFunc {
 		}
 
 		// 删除相关缓存
-		cacheKey := fmt.Sprintf("admin:%d", id)
-		_ = h.cache.Delete(cacheKey)
-		_ = h.cache.Delete("admin:list")
+		_ = h.loader.InvalidateTag(itemKeys.Tags()...)
 
 		ctx.Payload(resultInfo)
 	}
@@ -273,9 +353,7 @@ func (h *handler) DeleteByID() core.HandlerFunc {
 		}
 
 		// 删除相关缓存
-		cacheKey := fmt.Sprintf("admin:%d", id)
-		_ = h.cache.Delete(cacheKey)
-		_ = h.cache.Delete("admin:list")
+		_ = h.loader.InvalidateTag(itemKeys.Tags()...)
 
 		ctx.Payload(resultInfo)
 	}
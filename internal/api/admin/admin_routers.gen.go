@@ -3,7 +3,6 @@ package admin
 import (
 	"gin-example/internal/pkg/cache"
 	"gin-example/internal/pkg/core"
-	"gin-example/internal/pkg/ratelimit"
 	"gin-example/internal/repository/mysql"
 
 	"go.uber.org/zap"
@@ -12,22 +11,18 @@ import (
 func RegisterGeneratedAdminRoutes(logger *zap.Logger, db mysql.Repo, r core.RouterGroup, cache cache.Cache) {
 	h := New(logger, db, cache)
 
-	// 创建限流中间件
-	rateLimitConfig := ratelimit.DefaultRateLimitConfig()
-	rateLimitMiddleware := ratelimit.NewRateLimitMiddleware(rateLimitConfig)
-	
 	// 新增数据
-	r.POST("/admin", rateLimitMiddleware.Middleware(), h.Create())
+	r.POST("/admin", h.Create())
 
 	// 获取列表数据
-	r.GET("/admins", rateLimitMiddleware.Middleware(), h.List())
+	r.GET("/admins", h.List())
 
 	// 根据 ID 获取数据
-	r.GET("/admin/:id", rateLimitMiddleware.Middleware(), h.GetByID())
+	r.GET("/admin/:id", h.GetByID())
 
 	// 根据 ID 更新数据
-	r.PUT("/admin/:id", rateLimitMiddleware.Middleware(), h.UpdateByID())
+	r.PUT("/admin/:id", h.UpdateByID())
 
 	// 根据 ID 删除数据
-	r.DELETE("/admin/:id", rateLimitMiddleware.Middleware(), h.DeleteByID())
+	r.DELETE("/admin/:id", h.DeleteByID())
 }
\ No newline at end of file
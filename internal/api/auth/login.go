@@ -78,6 +78,7 @@ func (h *handler) Login() core.HandlerFunc {
 			Id:       1, // 示例用户ID
 			UserName: req.Username,
 			NickName: req.Username,
+			Roles:    []string{"admin"}, // 示例角色
 		}
 		
 		// 签发Token（24小时过期）
@@ -0,0 +1,160 @@
+package quota
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/core"
+	quotaPkg "gin-example/internal/pkg/quota"
+	"gin-example/internal/pkg/timeutil"
+
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger *zap.Logger
+	quota  *quotaPkg.Manager
+}
+
+func New(logger *zap.Logger, quota *quotaPkg.Manager) *handler {
+	return &handler{
+		logger: logger,
+		quota:  quota,
+	}
+}
+
+// Usage 查询调用方的配额使用情况
+// @Summary 配额使用情况
+// @Description 查询当前调用方（API Key 或登录用户）在当日、当月的配额使用情况，不消耗配额
+// @Tags Quota
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string false "API Key"
+// @Success 200 {object} quotaPkg.Usage
+// @Failure 401 {object} code.Failure
+// @Router /quota/usage [get]
+func (h *handler) Usage() core.HandlerFunc {
+	return func(ctx core.Context) {
+		subject := quotaPkg.SubjectOf(ctx)
+		if subject == "" {
+			ctx.AbortWithError(core.Error(
+				http.StatusUnauthorized,
+				code.JWTAuthVerifyError,
+				code.Text(code.JWTAuthVerifyError)),
+			)
+			return
+		}
+
+		usage, err := h.quota.Get(ctx.RequestContext(), subject)
+		if err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ServerError,
+				err.Error()),
+			)
+			return
+		}
+
+		ctx.Payload(usage)
+	}
+}
+
+type exportRequest struct {
+	Window string `form:"window" binding:"required,oneof=day month"` // 配额窗口
+	Date   string `form:"date"`                                      // 窗口内任意一天，格式 2006-01-02，默认当天
+	Format string `form:"format" binding:"omitempty,oneof=json csv"` // 导出格式，默认 json
+}
+
+// ExportResponse 计费导出响应结构
+type ExportResponse struct {
+	Window  string            `json:"window"`
+	Records []quotaPkg.Record `json:"records"`
+}
+
+// Export 导出计费用量
+// @Summary 导出计费用量
+// @Description 导出指定窗口内全部调用方的用量，供计费使用，需要管理员权限。API Key 调用方以 Key 的摘要代替原始的 Key
+// @Tags Quota
+// @Accept json
+// @Produce json,text/csv
+// @Param window query string true "配额窗口" Enums(day, month)
+// @Param date query string false "窗口内任意一天，格式 2006-01-02"
+// @Param format query string false "导出格式" Enums(json, csv)
+// @Success 200 {object} ExportResponse
+// @Failure 400 {object} code.Failure
+// @Failure 401 {object} code.Failure
+// @Failure 403 {object} code.Failure
+// @Router /system/quota/export [get]
+func (h *handler) Export() core.HandlerFunc {
+	return func(ctx core.Context) {
+		req := new(exportRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+
+		window, err := quotaPkg.ParseWindow(req.Window)
+		if err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+
+		at := time.Now()
+		if req.Date != "" {
+			if at, err = timeutil.ParseInCST("2006-01-02", req.Date); err != nil {
+				ctx.AbortWithError(core.Error(
+					http.StatusBadRequest,
+					code.ParamBindError,
+					err.Error()),
+				)
+				return
+			}
+		}
+
+		records, err := h.quota.Export(ctx.RequestContext(), window, at)
+		if err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ServerError,
+				err.Error()),
+			)
+			return
+		}
+
+		if req.Format == "csv" {
+			writeCSV(ctx, records)
+			return
+		}
+
+		ctx.Payload(&ExportResponse{
+			Window:  req.Window,
+			Records: records,
+		})
+	}
+}
+
+// writeCSV 以 CSV 格式输出导出记录
+func writeCSV(ctx core.Context, records []quotaPkg.Record) {
+	writer := ctx.ResponseWriter()
+	writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	writer.Header().Set("Content-Disposition", "attachment; filename=quota.csv")
+	writer.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(writer)
+	_ = w.Write([]string{"subject", "plan", "window", "period", "used"})
+	for _, r := range records {
+		_ = w.Write([]string{r.Subject, r.Plan, string(r.Window), r.Period, strconv.FormatInt(r.Used, 10)})
+	}
+	w.Flush()
+}
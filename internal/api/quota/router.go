@@ -0,0 +1,19 @@
+package quota
+
+import (
+	"gin-example/internal/pkg/core"
+	quotaPkg "gin-example/internal/pkg/quota"
+
+	"go.uber.org/zap"
+)
+
+// RegisterQuotaRoutes 注册配额路由，auth 为调用方鉴权中间件，与 /api 使用相同的校验，adminAuth 为运维接口的鉴权中间件
+func RegisterQuotaRoutes(logger *zap.Logger, quota *quotaPkg.Manager, r core.Mux, auth core.HandlerFunc, adminAuth ...core.HandlerFunc) {
+	h := New(logger, quota)
+
+	// 调用方查询自己的配额使用情况，不消耗配额
+	r.Group("", auth).GET("/quota/usage", h.Usage())
+
+	// 计费导出，供运维使用
+	r.Group("", adminAuth...).GET("/system/quota/export", h.Export())
+}
@@ -0,0 +1,179 @@
+package system
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/timeutil"
+
+	"go.uber.org/zap"
+)
+
+type breakerHandler struct {
+	logger     *zap.Logger
+	propagator *circuitbreaker.Propagator
+}
+
+func NewBreakerHandler(logger *zap.Logger, propagator *circuitbreaker.Propagator) *breakerHandler {
+	// 预先创建配置的熔断器，管理接口只能操作已存在的熔断器
+	circuitbreaker.Default()
+
+	return &breakerHandler{
+		logger:     logger,
+		propagator: propagator,
+	}
+}
+
+// BreakerInfo 熔断器信息
+type BreakerInfo struct {
+	Name    string                 `json:"name"`
+	Metrics map[string]interface{} `json:"metrics"`
+}
+
+// BreakersResponse 熔断器列表响应结构
+type BreakersResponse struct {
+	Breakers []BreakerInfo `json:"breakers"`
+}
+
+// List 列出全部熔断器及统计信息
+// @Summary 熔断器列表
+// @Description 列出已创建的熔断器（包括下游依赖和服务端熔断器）及其统计信息
+// @Tags System
+// @Accept json
+// @Produce json
+// @Success 200 {object} BreakersResponse
+// @Failure 401 {object} code.Failure
+// @Failure 403 {object} code.Failure
+// @Router /system/breakers [get]
+func (h *breakerHandler) List() core.HandlerFunc {
+	return func(ctx core.Context) {
+		breakers := circuitbreaker.All()
+
+		res := BreakersResponse{Breakers: make([]BreakerInfo, 0, len(breakers))}
+		for _, cb := range breakers {
+			res.Breakers = append(res.Breakers, BreakerInfo{
+				Name:    cb.Name(),
+				Metrics: cb.Metrics(),
+			})
+		}
+
+		ctx.Payload(res)
+	}
+}
+
+type operateURI struct {
+	Name string `uri:"name" binding:"required"` // 熔断器名称
+}
+
+type operateRequest struct {
+	Action string `json:"action" binding:"required,oneof=force_open force_closed reset"` // 操作
+	Reason string `json:"reason" binding:"required"`                                     // 操作原因，记录到审计日志
+}
+
+// OperateResponse 熔断器操作响应结构
+type OperateResponse struct {
+	Name       string                 `json:"name"`
+	Action     string                 `json:"action"`
+	Found      bool                   `json:"found"`      // 当前实例是否存在该熔断器
+	Propagated bool                   `json:"propagated"` // 是否已同步到其他实例
+	Metrics    map[string]interface{} `json:"metrics,omitempty"`
+}
+
+// Operate 强制开启、强制关闭或重置熔断器
+// @Summary 操作熔断器
+// @Description 强制开启（force_open）、强制关闭（force_closed）或重置（reset）熔断器，强制状态在重置前不会因调用结果改变。
+// @Description 只能操作当前实例已存在的熔断器，需要管理员权限。开启同步时操作会同步到所有实例，尚未创建该熔断器的实例在创建时生效。
+// @Description 每次操作都会以登录用户为操作人记录审计日志
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param name path string true "熔断器名称"
+// @Param Request body operateRequest true "请求信息"
+// @Success 200 {object} OperateResponse
+// @Failure 400 {object} code.Failure
+// @Failure 401 {object} code.Failure
+// @Failure 403 {object} code.Failure
+// @Failure 404 {object} code.Failure
+// @Router /system/breakers/{name} [post]
+func (h *breakerHandler) Operate() core.HandlerFunc {
+	return func(ctx core.Context) {
+		uri := new(operateURI)
+		req := new(operateRequest)
+		if err := ctx.ShouldBindURI(uri); err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+
+		// 只能操作已存在的熔断器，避免任意名称写入待生效列表和 Redis
+		if _, ok := circuitbreaker.Lookup(uri.Name); !ok {
+			ctx.AbortWithError(core.Error(
+				http.StatusNotFound,
+				code.BreakerNotFound,
+				code.Text(code.BreakerNotFound)),
+			)
+			return
+		}
+
+		found, err := circuitbreaker.Apply(uri.Name, req.Action)
+		if err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+
+		cmd := &circuitbreaker.Command{
+			Name:     uri.Name,
+			Action:   req.Action,
+			Operator: ctx.SessionUserInfo().UserName,
+			Reason:   req.Reason,
+			Time:     timeutil.CSTLayoutString(),
+		}
+
+		res := OperateResponse{Name: uri.Name, Action: req.Action, Found: found}
+		if h.propagator != nil {
+			publishCtx, cancel := context.WithTimeout(ctx.RequestContext(), 3*time.Second)
+			err := h.propagator.Publish(publishCtx, cmd)
+			cancel()
+			if err != nil {
+				h.logger.Warn("propagate circuit breaker command failed", zap.String("name", uri.Name), zap.Error(err))
+			}
+			res.Propagated = err == nil
+		}
+
+		// 审计日志
+		h.logger.Info("circuit breaker audit",
+			zap.String("name", cmd.Name),
+			zap.String("action", cmd.Action),
+			zap.String("operator", cmd.Operator),
+			zap.String("reason", cmd.Reason),
+			zap.String("client_ip", ctx.RemoteAddr()),
+			zap.Bool("found", found),
+			zap.Bool("propagated", res.Propagated),
+			zap.String("trace_id", ctx.Trace().ID()),
+		)
+
+		if cb, ok := circuitbreaker.Lookup(uri.Name); ok {
+			res.Metrics = cb.Metrics()
+		}
+		ctx.Payload(res)
+	}
+}
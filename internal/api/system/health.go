@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"gin-example/internal/pkg/cache"
 	"gin-example/internal/pkg/core"
 	"gin-example/internal/repository/mysql"
 	redisRepo "gin-example/internal/repository/redis"
@@ -15,13 +16,15 @@ type handler struct {
 	logger *zap.Logger
 	db     mysql.Repo
 	redis  *redisRepo.Repo
+	cache  cache.Cache
 }
 
-func New(logger *zap.Logger, db mysql.Repo, redisRepo *redisRepo.Repo) *handler {
+func New(logger *zap.Logger, db mysql.Repo, redisRepo *redisRepo.Repo, cache cache.Cache) *handler {
 	return &handler{
 		logger: logger,
 		db:     db,
 		redis:  redisRepo,
+		cache:  cache,
 	}
 }
 
@@ -55,8 +58,11 @@ func (h *handler) Health() core.HandlerFunc {
 			Message: redisStatus.Message,
 		}
 
+		// 检查缓存运行模式
+		resp.Components.Cache = h.checkCache()
+
 		// 设置整体状态
-		if dbStatus.Status != "up" || redisStatus.Status != "up" {
+		if dbStatus.Status != "up" || redisStatus.Status != "up" || resp.Components.Cache.Status != "up" {
 			resp.Status = "degraded"
 		}
 
@@ -120,6 +126,36 @@ func (h *handler) checkRedis() ComponentStatus {
 	return status
 }
 
+// checkCache 检查缓存运行模式
+func (h *handler) checkCache() CacheStatus {
+	status := CacheStatus{
+		ComponentStatus: ComponentStatus{Status: "up"},
+		Mode:            cache.ModeL1Only,
+	}
+
+	reporter, ok := h.cache.(cache.HealthReporter)
+	if !ok {
+		// 未接入Redis，仅使用本地缓存
+		status.Status = "degraded"
+		status.Message = "Using local cache only"
+		return status
+	}
+
+	health := reporter.Health()
+	status.Mode = health.Mode
+	status.ConsecutiveErrors = health.ConsecutiveErrors
+	status.LastTransition = health.LastTransition
+
+	if health.Degraded {
+		status.Status = "degraded"
+		status.Message = "Redis unavailable, cache degraded to l1-only mode: " + health.LastError
+		return status
+	}
+
+	status.Message = "Multi-level cache is healthy"
+	return status
+}
+
 // HealthResponse 健康检查响应结构
 type HealthResponse struct {
 	Status      string         `json:"status"`
@@ -132,10 +168,19 @@ type HealthResponse struct {
 type ComponentsInfo struct {
 	Database ComponentStatus `json:"database"`
 	Redis    ComponentStatus `json:"redis"`
+	Cache    CacheStatus     `json:"cache"`
 }
 
 // ComponentStatus 组件状态
 type ComponentStatus struct {
 	Status  string `json:"status"`  // up, down, degraded
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
+
+// CacheStatus 缓存状态
+type CacheStatus struct {
+	ComponentStatus
+	Mode              string    `json:"mode"`               // multi-level, l1-only
+	ConsecutiveErrors int       `json:"consecutive_errors"` // Redis连续错误次数
+	LastTransition    time.Time `json:"last_transition"`    // 最近一次模式切换时间
+}
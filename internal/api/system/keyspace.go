@@ -0,0 +1,79 @@
+package system
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/cache"
+	"gin-example/internal/pkg/core"
+)
+
+const (
+	// defaultMaxScan 每个节点默认最多遍历的键数量
+	defaultMaxScan = 1000
+	// maxMaxScan 每个节点最多遍历的键数量上限，避免长时间占用 Redis
+	maxMaxScan = 10000
+)
+
+type keySpacesRequest struct {
+	MaxScan int `form:"max_scan"` // 每个节点最多遍历的键数量
+}
+
+// KeySpacesResponse 键空间列表响应结构
+type KeySpacesResponse struct {
+	Namespace string                `json:"namespace"`
+	KeySpaces []cache.KeySpaceStats `json:"key_spaces"`
+}
+
+// KeySpaces 列出已声明的缓存键空间及估算的键数量
+// @Summary 缓存键空间
+// @Description 列出已声明的缓存键空间，通过 SCAN 估算每个键空间的键数量
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param max_scan query int false "每个节点最多遍历的键数量"
+// @Success 200 {object} KeySpacesResponse
+// @Failure 400 {object} code.Failure
+// @Failure 401 {object} code.Failure
+// @Failure 403 {object} code.Failure
+// @Router /system/cache/keyspaces [get]
+func (h *handler) KeySpaces() core.HandlerFunc {
+	return func(ctx core.Context) {
+		req := new(keySpacesRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ParamBindError,
+				err.Error()),
+			)
+			return
+		}
+
+		if req.MaxScan <= 0 {
+			req.MaxScan = defaultMaxScan
+		}
+		if req.MaxScan > maxMaxScan {
+			req.MaxScan = maxMaxScan
+		}
+
+		scanCtx, cancel := context.WithTimeout(ctx.RequestContext(), 10*time.Second)
+		defer cancel()
+
+		stats, err := cache.StatKeySpaces(scanCtx, h.cache, req.MaxScan)
+		if err != nil {
+			ctx.AbortWithError(core.Error(
+				http.StatusBadRequest,
+				code.ServerError,
+				err.Error()),
+			)
+			return
+		}
+
+		ctx.Payload(&KeySpacesResponse{
+			Namespace: cache.Namespace(),
+			KeySpaces: stats,
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package system
 
 import (
+	"gin-example/internal/pkg/cache"
+	"gin-example/internal/pkg/circuitbreaker"
 	"gin-example/internal/pkg/core"
 	"gin-example/internal/repository/mysql"
 	"gin-example/internal/repository/redis"
@@ -9,9 +11,26 @@ import (
 )
 
 // RegisterHealthRoutes 注册健康检查路由
-func RegisterHealthRoutes(logger *zap.Logger, db mysql.Repo, redisRepo *redis.Repo, r core.Mux) {
-	h := New(logger, db, redisRepo)
+// auth 为运维接口的鉴权中间件
+func RegisterHealthRoutes(logger *zap.Logger, db mysql.Repo, redisRepo *redis.Repo, cache cache.Cache, r core.Mux, auth ...core.HandlerFunc) {
+	h := New(logger, db, redisRepo, cache)
 	
 	// 注册健康检查路由
 	r.Group("").GET("/system/health", h.Health())
+
+	// 缓存键空间，供运维排查使用
+	r.Group("", auth...).GET("/system/cache/keyspaces", h.KeySpaces())
+}
+
+// RegisterBreakerRoutes 注册熔断器管理路由，propagator 不为空时强制状态同步到所有实例
+// auth 为运维接口的鉴权中间件
+func RegisterBreakerRoutes(logger *zap.Logger, propagator *circuitbreaker.Propagator, r core.Mux, auth ...core.HandlerFunc) {
+	h := NewBreakerHandler(logger, propagator)
+	group := r.Group("/system/breakers", auth...)
+
+	// 熔断器列表及统计信息
+	group.GET("", h.List())
+
+	// 强制开启、强制关闭、重置熔断器
+	group.POST("/:name", h.Operate())
 }
\ No newline at end of file
@@ -19,6 +19,12 @@ const (
 	ServerError        = 10101
 	ParamBindError     = 10102
 	JWTAuthVerifyError = 10103
+	TooManyRequests    = 10105
+	QuotaExceeded      = 10106
+	ServiceUnavailable = 10107
+	BreakerNotFound    = 10108
+	PermissionDenied   = 10109
+	APIKeyInvalid      = 10110
 )
 
 func Text(code int) string {
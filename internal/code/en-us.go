@@ -4,4 +4,10 @@ var enUSText = map[int]string{
 	ServerError:        "Internal server error",
 	ParamBindError:     "Parameter error",
 	JWTAuthVerifyError: "JWT auth verify error",
+	TooManyRequests:    "Too many requests",
+	QuotaExceeded:      "Usage quota exceeded",
+	ServiceUnavailable: "Service is busy, please try again later",
+	BreakerNotFound:    "Circuit breaker not found",
+	PermissionDenied:   "Permission denied",
+	APIKeyInvalid:      "Invalid API key",
 }
@@ -4,4 +4,10 @@ var zhCNText = map[int]string{
 	ServerError:        "内部服务器错误",
 	ParamBindError:     "参数信息错误",
 	JWTAuthVerifyError: "JWT 授权验证错误",
+	TooManyRequests:    "请求过于频繁，请稍后再试",
+	QuotaExceeded:      "调用量已超出套餐配额",
+	ServiceUnavailable: "服务繁忙，请稍后再试",
+	BreakerNotFound:    "熔断器不存在",
+	PermissionDenied:   "权限不足",
+	APIKeyInvalid:      "API Key 无效",
 }
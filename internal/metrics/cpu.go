@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clockTicks Linux 下 /proc 中 CPU 时间的单位（USER_HZ）
+const clockTicks = 100
+
+var (
+	// cpuPercent 最近一次采样的进程 CPU 使用率（math.Float64bits）
+	cpuPercent uint64
+
+	lastCPUSeconds float64
+	lastCPUSample  time.Time
+
+	cpuLimitOnce  sync.Once
+	cpuLimitCores float64
+)
+
+// CPUUsage 返回最近一次采样的进程 CPU 使用率，按进程可用的核心数（GOMAXPROCS 和 cgroup 配额中较小者）折算，范围 0-100
+// 非 Linux 系统无法采样，始终返回 0
+func CPUUsage() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&cpuPercent))
+}
+
+// sampleCPU 根据两次采样间的进程 CPU 时间计算使用率
+func sampleCPU() {
+	seconds, ok := processCPUSeconds()
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if !lastCPUSample.IsZero() {
+		elapsed := now.Sub(lastCPUSample).Seconds() * cpuLimit()
+		if elapsed > 0 {
+			percent := math.Min(100, (seconds-lastCPUSeconds)/elapsed*100)
+			atomic.StoreUint64(&cpuPercent, math.Float64bits(percent))
+			cpuUsage.Set(percent)
+		}
+	}
+
+	lastCPUSeconds = seconds
+	lastCPUSample = now
+}
+
+// cpuLimit 进程可用的核心数，容器中按 cgroup 的 CPU 配额计算，否则为 GOMAXPROCS
+func cpuLimit() float64 {
+	cpuLimitOnce.Do(func() {
+		cpuLimitCores = float64(runtime.GOMAXPROCS(0))
+		if quota, ok := cgroupCPUQuota(); ok && quota < cpuLimitCores {
+			cpuLimitCores = quota
+		}
+	})
+	return cpuLimitCores
+}
+
+// cgroupCPUQuota 读取 cgroup 的 CPU 配额（核心数），依次尝试 cgroup v2 的 cpu.max 和 v1 的 cfs 配额
+func cgroupCPUQuota() (float64, bool) {
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		// 格式为 "$MAX $PERIOD"，不限制时 $MAX 为 max
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 {
+			return parseCPUQuota(fields[0], fields[1])
+		}
+		return 0, false
+	}
+
+	quota, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0, false
+	}
+	period, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err != nil {
+		return 0, false
+	}
+	return parseCPUQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// parseCPUQuota 将周期内可用的 CPU 时间换算为核心数，不限制（max 或 -1）时返回 false
+func parseCPUQuota(quota, period string) (float64, bool) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
+
+// processCPUSeconds 读取 /proc/self/stat 中进程的用户态和内核态 CPU 时间
+func processCPUSeconds() (float64, bool) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, false
+	}
+
+	// 第二个字段是括号包裹的进程名，可能含空格，从右括号之后开始解析
+	stat := string(data)
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, false
+	}
+
+	// 右括号之后依次为 state(3) ... utime(14) stime(15)
+	fields := strings.Fields(stat[i+1:])
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseFloat(fields[11], 64)
+	stime, err2 := strconv.ParseFloat(fields[12], 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return (utime + stime) / clockTicks, true
+}
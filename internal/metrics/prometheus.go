@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -131,6 +132,66 @@ var (
 		Help:      "Cache hit ratio",
 	})
 
+	cacheDegraded = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_degraded",
+			Help:      "Whether the cache is running in l1-only degraded mode (0=multi-level, 1=l1-only)",
+		},
+		[]string{"cache"},
+	)
+
+	cacheStateTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_state_transitions_total",
+			Help:      "Total number of cache mode transitions",
+		},
+		[]string{"cache", "mode"},
+	)
+
+	cacheStaleServed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_stale_served_total",
+			Help:      "Total number of stale values served while a background refresh runs",
+		},
+		[]string{"cache"},
+	)
+
+	cacheEarlyRefresh = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_early_refresh_total",
+			Help:      "Total number of probabilistic early refreshes triggered before soft expiration",
+		},
+		[]string{"cache"},
+	)
+
+	cacheRefresh = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_refresh_total",
+			Help:      "Total number of cache loader executions",
+		},
+		[]string{"cache", "success"},
+	)
+
+	cacheFilterRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cache_filter_rejected_total",
+			Help:      "Total number of lookups short-circuited by the bloom filter",
+		},
+		[]string{"cache"},
+	)
+
 	// 限流相关指标
 	rateLimitAllowed = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: namespace,
@@ -149,6 +210,140 @@ var (
 		[]string{"limit_type"},
 	)
 
+	rateLimitFallback = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rate_limit_fallback_total",
+			Help:      "Total number of distributed rate limit decisions made without Redis",
+		},
+		[]string{"mode"},
+	)
+
+	quotaExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "quota_exceeded_total",
+			Help:      "Total number of requests rejected by usage quota",
+		},
+		[]string{"plan", "window"},
+	)
+
+	// 自适应并发限制相关指标
+	concurrencyLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "concurrency_limit",
+			Help:      "Current adaptive concurrency limit",
+		},
+		[]string{"name"},
+	)
+
+	concurrencyInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "concurrency_in_flight",
+			Help:      "Number of requests currently holding a concurrency permit",
+		},
+		[]string{"name"},
+	)
+
+	concurrencyQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "concurrency_queue_depth",
+			Help:      "Number of requests waiting for a concurrency permit",
+		},
+		[]string{"name"},
+	)
+
+	concurrencyShed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "concurrency_shed_total",
+			Help:      "Total number of requests shed by the adaptive concurrency limiter",
+		},
+		[]string{"name"},
+	)
+
+	// 负载保护相关指标
+	loadShedDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "load_shed_decisions_total",
+			Help:      "Total number of load shedding decisions by criticality",
+		},
+		[]string{"criticality", "decision"},
+	)
+
+	loadPressure = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "load_pressure",
+			Help:      "Current load pressure reported by each overload signal, 1 means at capacity",
+		},
+		[]string{"signal"},
+	)
+
+	// 隔离舱相关指标
+	bulkheadActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "bulkhead_active_calls",
+			Help:      "Number of calls currently executing inside each bulkhead",
+		},
+		[]string{"name"},
+	)
+
+	bulkheadRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "bulkhead_rejected_total",
+			Help:      "Total number of calls rejected because the bulkhead was full",
+		},
+		[]string{"name"},
+	)
+
+	// 容错策略相关指标
+	resilienceDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "resilience_decisions_total",
+			Help:      "Total number of decisions made by outbound resilience pipelines",
+		},
+		[]string{"pipeline", "stage", "decision"},
+	)
+
+	retryBudgetExhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "retry_budget_exhausted_total",
+			Help:      "Total number of retries or hedged requests skipped because the retry budget was exhausted",
+		},
+		[]string{"name"},
+	)
+
+	hedgedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "hedged_requests_total",
+			Help:      "Total number of hedged requests by outcome (fired, completed)",
+		},
+		[]string{"name", "outcome"},
+	)
+
 	// 熔断器相关指标
 	circuitBreakerState = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -278,8 +473,27 @@ func init() {
 		cacheHits,
 		cacheMisses,
 		cacheHitRatio,
+		cacheDegraded,
+		cacheStateTransitions,
+		cacheStaleServed,
+		cacheEarlyRefresh,
+		cacheRefresh,
+		cacheFilterRejected,
 		rateLimitAllowed,
 		rateLimitExceeded,
+		rateLimitFallback,
+		quotaExceeded,
+		concurrencyLimit,
+		concurrencyInFlight,
+		concurrencyQueueDepth,
+		concurrencyShed,
+		loadShedDecisions,
+		loadPressure,
+		bulkheadActive,
+		bulkheadRejected,
+		resilienceDecisions,
+		retryBudgetExhausted,
+		hedgedRequests,
 		circuitBreakerState,
 		circuitBreakerTripped,
 		loadBalancerRequests,
@@ -347,6 +561,55 @@ func updateCacheHitRatio() {
 	// cacheHitRatio.Set(hitRatio)
 }
 
+// SetCacheDegraded 设置缓存降级状态
+func SetCacheDegraded(cache string, degraded bool) {
+	value := 0.0
+	if degraded {
+		value = 1
+	}
+
+	cacheDegraded.With(prometheus.Labels{
+		"cache": cache,
+	}).Set(value)
+}
+
+// RecordCacheStateTransition 记录缓存模式切换
+func RecordCacheStateTransition(cache, mode string) {
+	cacheStateTransitions.With(prometheus.Labels{
+		"cache": cache,
+		"mode":  mode,
+	}).Inc()
+}
+
+// RecordCacheStaleServed 记录返回旧值的次数
+func RecordCacheStaleServed(cache string) {
+	cacheStaleServed.With(prometheus.Labels{
+		"cache": cache,
+	}).Inc()
+}
+
+// RecordCacheEarlyRefresh 记录提前刷新的次数
+func RecordCacheEarlyRefresh(cache string) {
+	cacheEarlyRefresh.With(prometheus.Labels{
+		"cache": cache,
+	}).Inc()
+}
+
+// RecordCacheRefresh 记录加载器执行结果
+func RecordCacheRefresh(cache string, success bool) {
+	cacheRefresh.With(prometheus.Labels{
+		"cache":   cache,
+		"success": strconv.FormatBool(success),
+	}).Inc()
+}
+
+// RecordCacheFilterRejected 记录被布隆过滤器拦截的次数
+func RecordCacheFilterRejected(cache string) {
+	cacheFilterRejected.With(prometheus.Labels{
+		"cache": cache,
+	}).Inc()
+}
+
 // SetActiveUsers 设置活跃用户数
 func SetActiveUsers(count float64) {
 	activeUsers.Set(count)
@@ -364,6 +627,107 @@ func RecordRateLimitExceeded(limitType string) {
 	}).Inc()
 }
 
+// RecordRateLimitFallback 记录 Redis 不可用时分布式限流的降级处理次数
+func RecordRateLimitFallback(mode string) {
+	rateLimitFallback.With(prometheus.Labels{
+		"mode": mode,
+	}).Inc()
+}
+
+// RecordQuotaExceeded 记录超出调用配额被拒绝的请求
+func RecordQuotaExceeded(plan, window string) {
+	quotaExceeded.With(prometheus.Labels{
+		"plan":   plan,
+		"window": window,
+	}).Inc()
+}
+
+// SetConcurrencyLimit 设置自适应并发上限
+func SetConcurrencyLimit(name string, limit int) {
+	concurrencyLimit.With(prometheus.Labels{
+		"name": name,
+	}).Set(float64(limit))
+}
+
+// SetConcurrencyInFlight 设置正在执行的请求数
+func SetConcurrencyInFlight(name string, inFlight int) {
+	concurrencyInFlight.With(prometheus.Labels{
+		"name": name,
+	}).Set(float64(inFlight))
+}
+
+// SetConcurrencyQueueDepth 设置排队等待的请求数
+func SetConcurrencyQueueDepth(name string, depth int) {
+	concurrencyQueueDepth.With(prometheus.Labels{
+		"name": name,
+	}).Set(float64(depth))
+}
+
+// RecordConcurrencyShed 记录被并发限制拒绝的请求
+func RecordConcurrencyShed(name string) {
+	concurrencyShed.With(prometheus.Labels{
+		"name": name,
+	}).Inc()
+}
+
+// RecordLoadShedDecision 记录负载保护的判断结果
+func RecordLoadShedDecision(criticality string, shed bool) {
+	decision := "admitted"
+	if shed {
+		decision = "shed"
+	}
+
+	loadShedDecisions.With(prometheus.Labels{
+		"criticality": criticality,
+		"decision":    decision,
+	}).Inc()
+}
+
+// SetLoadPressure 设置过载信号的当前压力
+func SetLoadPressure(signal string, pressure float64) {
+	loadPressure.With(prometheus.Labels{
+		"signal": signal,
+	}).Set(pressure)
+}
+
+// SetBulkheadActive 设置隔离舱内正在执行的调用数
+func SetBulkheadActive(name string, active int) {
+	bulkheadActive.With(prometheus.Labels{
+		"name": name,
+	}).Set(float64(active))
+}
+
+// RecordBulkheadRejected 记录隔离舱拒绝的调用
+func RecordBulkheadRejected(name string) {
+	bulkheadRejected.With(prometheus.Labels{
+		"name": name,
+	}).Inc()
+}
+
+// RecordResilienceDecision 记录容错策略做出的决策
+func RecordResilienceDecision(pipeline, stage, decision string) {
+	resilienceDecisions.With(prometheus.Labels{
+		"pipeline": pipeline,
+		"stage":    stage,
+		"decision": decision,
+	}).Inc()
+}
+
+// RecordRetryBudgetExhausted 记录因重试预算用尽而放弃的重试
+func RecordRetryBudgetExhausted(name string) {
+	retryBudgetExhausted.With(prometheus.Labels{
+		"name": name,
+	}).Inc()
+}
+
+// RecordHedge 记录对冲请求，outcome 为 fired（发出对冲请求）或 completed（发出对冲请求后调用成功）
+func RecordHedge(name, outcome string) {
+	hedgedRequests.With(prometheus.Labels{
+		"name":    name,
+		"outcome": outcome,
+	}).Inc()
+}
+
 // SetCircuitBreakerState 设置熔断器状态
 func SetCircuitBreakerState(service string, state float64) {
 	circuitBreakerState.With(prometheus.Labels{
@@ -449,8 +813,15 @@ func collectSystemMetrics() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	// CPU 使用率用于负载保护，需要更高的采样频率
+	cpuTicker := time.NewTicker(time.Second)
+	defer cpuTicker.Stop()
+
 	for {
 		select {
+		case <-cpuTicker.C:
+			sampleCPU()
+
 		case <-ticker.C:
 			// 收集goroutines数量
 			goroutines.Set(float64(runtime.NumGoroutine()))
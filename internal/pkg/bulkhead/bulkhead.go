@@ -0,0 +1,240 @@
+package bulkhead
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"gin-example/internal/metrics"
+)
+
+const (
+	// TypeSemaphore 信号量隔离，在调用方的 goroutine 中执行
+	TypeSemaphore = "semaphore"
+	// TypePool 工作池隔离，在固定数量的工作 goroutine 中执行，超出的调用进入有界队列
+	TypePool = "pool"
+)
+
+// ErrFull 隔离舱已满，调用被拒绝
+var ErrFull error = &RejectedError{}
+
+// RejectedError 隔离舱拒绝调用的错误
+// HTTPCode 返回 429，熔断器按默认分类不会把隔离舱的拒绝计为下游失败
+type RejectedError struct{}
+
+func (e *RejectedError) Error() string {
+	return "bulkhead is full"
+}
+
+// HTTPCode 返回 HTTP 状态码
+func (e *RejectedError) HTTPCode() int {
+	return http.StatusTooManyRequests
+}
+
+// Bulkhead 隔离舱，限制对单个下游依赖的并发调用数
+type Bulkhead interface {
+	// Name 隔离舱名称
+	Name() string
+
+	// Execute 在隔离舱内执行 fn，已满且等待超时时返回 ErrFull
+	Execute(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Active 当前正在执行的调用数
+	Active() int
+}
+
+// Config 隔离舱配置
+type Config struct {
+	Type          string        // TypeSemaphore / TypePool，默认 TypeSemaphore
+	MaxConcurrent int           // 最大并发调用数，默认 25
+	MaxWait       time.Duration // 已满时最多等待的时间，0 表示立即拒绝
+	QueueSize     int           // TypePool 的队列长度
+}
+
+// New 按配置创建隔离舱
+func New(name string, config *Config) Bulkhead {
+	if config == nil {
+		config = &Config{}
+	}
+
+	maxConcurrent := config.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 25
+	}
+
+	if config.Type == TypePool {
+		return NewPool(name, maxConcurrent, config.QueueSize, config.MaxWait)
+	}
+	return NewSemaphore(name, maxConcurrent, config.MaxWait)
+}
+
+// Semaphore 信号量隔离舱
+type Semaphore struct {
+	name    string
+	slots   chan struct{}
+	maxWait time.Duration
+	active  int64
+}
+
+// NewSemaphore 创建信号量隔离舱
+func NewSemaphore(name string, maxConcurrent int, maxWait time.Duration) *Semaphore {
+	return &Semaphore{
+		name:    name,
+		slots:   make(chan struct{}, maxConcurrent),
+		maxWait: maxWait,
+	}
+}
+
+// Name 隔离舱名称
+func (s *Semaphore) Name() string {
+	return s.name
+}
+
+// Execute 在隔离舱内执行 fn
+func (s *Semaphore) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+
+	return fn(ctx)
+}
+
+func (s *Semaphore) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		if s.maxWait <= 0 {
+			return reject(s.name)
+		}
+
+		timer := time.NewTimer(s.maxWait)
+		defer timer.Stop()
+
+		select {
+		case s.slots <- struct{}{}:
+		case <-timer.C:
+			return reject(s.name)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	metrics.SetBulkheadActive(s.name, int(atomic.AddInt64(&s.active, 1)))
+	return nil
+}
+
+func (s *Semaphore) release() {
+	metrics.SetBulkheadActive(s.name, int(atomic.AddInt64(&s.active, -1)))
+	<-s.slots
+}
+
+// Active 当前正在执行的调用数
+func (s *Semaphore) Active() int {
+	return int(atomic.LoadInt64(&s.active))
+}
+
+// Pool 工作池隔离舱，调用方等待工作 goroutine 执行完成
+// 慢调用只会占满自己的工作池，不会让调用方的 goroutine 无限增长
+type Pool struct {
+	name    string
+	tasks   chan *task
+	maxWait time.Duration
+	active  int64
+	closed  chan struct{}
+}
+
+type task struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	done chan error
+}
+
+// NewPool 创建工作池隔离舱，启动 workers 个工作 goroutine，最多 queueSize 个调用排队
+func NewPool(name string, workers, queueSize int, maxWait time.Duration) *Pool {
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool{
+		name:    name,
+		tasks:   make(chan *task, queueSize),
+		maxWait: maxWait,
+		closed:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Name 隔离舱名称
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Execute 提交 fn 到工作池并等待执行完成，ctx 取消时不再等待
+func (p *Pool) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	t := &task{ctx: ctx, fn: fn, done: make(chan error, 1)}
+
+	select {
+	case p.tasks <- t:
+	default:
+		if p.maxWait <= 0 {
+			return reject(p.name)
+		}
+
+		timer := time.NewTimer(p.maxWait)
+		defer timer.Stop()
+
+		select {
+		case p.tasks <- t:
+		case <-timer.C:
+			return reject(p.name)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case err := <-t.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	for {
+		select {
+		case t := <-p.tasks:
+			// 排队期间调用方已经放弃，跳过
+			if err := t.ctx.Err(); err != nil {
+				t.done <- err
+				continue
+			}
+
+			metrics.SetBulkheadActive(p.name, int(atomic.AddInt64(&p.active, 1)))
+			t.done <- t.fn(t.ctx)
+			metrics.SetBulkheadActive(p.name, int(atomic.AddInt64(&p.active, -1)))
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+// Active 当前正在执行的调用数
+func (p *Pool) Active() int {
+	return int(atomic.LoadInt64(&p.active))
+}
+
+// Close 停止工作 goroutine，队列中未执行的调用不再执行
+func (p *Pool) Close() {
+	close(p.closed)
+}
+
+func reject(name string) error {
+	metrics.RecordBulkheadRejected(name)
+	return ErrFull
+}
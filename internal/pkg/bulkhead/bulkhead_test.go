@@ -0,0 +1,145 @@
+package bulkhead
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreRejectsWhenFull(t *testing.T) {
+	s := NewSemaphore("test-semaphore", 1, 0)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	go s.Execute(context.Background(), func(context.Context) error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	if s.Active() != 1 {
+		t.Fatalf("expected 1 active call, got %d", s.Active())
+	}
+
+	err := s.Execute(context.Background(), func(context.Context) error { return nil })
+	if !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+
+	close(finish)
+}
+
+func TestSemaphoreWaitsForSlot(t *testing.T) {
+	s := NewSemaphore("test-semaphore-wait", 1, time.Second)
+
+	started := make(chan struct{})
+	go s.Execute(context.Background(), func(context.Context) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	<-started
+
+	called := false
+	if err := s.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	}); err != nil || !called {
+		t.Fatalf("expected call to run after waiting, err=%v called=%v", err, called)
+	}
+}
+
+func TestPoolQueueing(t *testing.T) {
+	p := NewPool("test-pool", 1, 1, 0)
+	defer p.Close()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	go p.Execute(context.Background(), func(context.Context) error {
+		close(started)
+		<-finish
+		return nil
+	})
+	<-started
+
+	// 工作 goroutine 忙，第二个调用进入队列
+	queued := make(chan error, 1)
+	go func() {
+		queued <- p.Execute(context.Background(), func(context.Context) error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 队列已满
+	if err := p.Execute(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+
+	close(finish)
+	if err := <-queued; err != nil {
+		t.Fatalf("expected queued call to succeed, got %v", err)
+	}
+}
+
+func TestRegistryForHost(t *testing.T) {
+	r := NewRegistry(&Config{MaxConcurrent: 5})
+	r.Configure("payment", &Config{MaxConcurrent: 1}, "pay.example.com")
+
+	if name := r.ServiceName("pay.example.com:443"); name != "payment" {
+		t.Fatalf("expected payment, got %s", name)
+	}
+	if r.ForHost("pay.example.com") != r.Get("payment") {
+		t.Fatal("expected hosts of the same service to share a bulkhead")
+	}
+	if r.ForHost("other.example.com") == r.Get("payment") {
+		t.Fatal("expected other hosts to get an isolated bulkhead")
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type closeNotifier struct {
+	io.Reader
+	closed chan struct{}
+}
+
+func (c *closeNotifier) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestTransportClosesLateResponse(t *testing.T) {
+	registry := NewRegistry(&Config{Type: TypePool, MaxConcurrent: 1, QueueSize: 1})
+	release := make(chan struct{})
+	body := &closeNotifier{Reader: strings.NewReader("late"), closed: make(chan struct{})}
+
+	transport := NewTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: body}, nil
+	}), registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://late.example.com/", nil)
+
+	resp, err := transport.RoundTrip(req)
+	if resp != nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error without response, got %v %v", resp, err)
+	}
+
+	// 调用方返回后才收到的响应需要被关闭
+	close(release)
+	select {
+	case <-body.closed:
+	case <-time.After(time.Second):
+		t.Fatal("late response body was not closed")
+	}
+}
@@ -0,0 +1,137 @@
+package bulkhead
+
+import (
+	"sort"
+	"sync"
+
+	"gin-example/configs"
+	"gin-example/internal/pkg/hostmap"
+)
+
+// Registry 隔离舱注册表，每个下游依赖使用独立的并发配额
+type Registry struct {
+	mu        sync.RWMutex
+	defaults  Config
+	configs   map[string]Config // 服务名称 -> 配置
+	hosts     hostmap.Map
+	bulkheads map[string]Bulkhead
+}
+
+// NewRegistry 创建隔离舱注册表，未单独配置的依赖使用 defaults
+func NewRegistry(defaults *Config) *Registry {
+	if defaults == nil {
+		defaults = &Config{}
+	}
+
+	return &Registry{
+		defaults:  *defaults,
+		configs:   make(map[string]Config),
+		bulkheads: make(map[string]Bulkhead),
+	}
+}
+
+// Configure 设置依赖的隔离舱配置，hosts 中的 host 共用该依赖的隔离舱
+func (r *Registry) Configure(name string, config *Config, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if config != nil {
+		r.configs[name] = *config
+	}
+	r.hosts.Set(name, hosts...)
+}
+
+// ServiceName 返回 host 所属的依赖名称，未配置时为去掉默认端口的 host
+func (r *Registry) ServiceName(host string) string {
+	return r.hosts.ServiceName(host)
+}
+
+// Configured 依赖是否单独配置了隔离舱
+func (r *Registry) Configured(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.configs[name]
+	return ok
+}
+
+// ForHost 返回 host 所属依赖的隔离舱
+func (r *Registry) ForHost(host string) Bulkhead {
+	return r.Get(r.ServiceName(host))
+}
+
+// Get 返回依赖的隔离舱，不存在时按配置创建
+func (r *Registry) Get(name string) Bulkhead {
+	r.mu.RLock()
+	b, ok := r.bulkheads[name]
+	r.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.bulkheads[name]; ok {
+		return b
+	}
+
+	config, ok := r.configs[name]
+	if !ok {
+		config = r.defaults
+	}
+
+	b = New(name, &config)
+	r.bulkheads[name] = b
+	return b
+}
+
+// Names 返回已创建的隔离舱名称，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.bulkheads))
+	for name := range r.bulkheads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// Default 返回按配置文件创建的全局隔离舱注册表
+func Default() *Registry {
+	defaultRegistryOnce.Do(func() {
+		cfg := configs.Get().Bulkhead
+
+		defaultRegistry = NewRegistry(policyConfig(cfg.Default, &Config{}))
+		for _, service := range cfg.Services {
+			defaultRegistry.Configure(service.Name, policyConfig(service.Policy, &defaultRegistry.defaults), service.Hosts...)
+		}
+	})
+	return defaultRegistry
+}
+
+// policyConfig 将配置文件中的策略转换为隔离舱配置，未设置的字段使用 base 中的值
+func policyConfig(policy configs.BulkheadPolicy, base *Config) *Config {
+	config := *base
+
+	if policy.Type != "" {
+		config.Type = policy.Type
+	}
+	if policy.MaxConcurrent > 0 {
+		config.MaxConcurrent = policy.MaxConcurrent
+	}
+	if policy.MaxWait > 0 {
+		config.MaxWait = policy.MaxWait
+	}
+	if policy.QueueSize > 0 {
+		config.QueueSize = policy.QueueSize
+	}
+	return &config
+}
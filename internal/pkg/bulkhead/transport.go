@@ -0,0 +1,84 @@
+package bulkhead
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Transport 按请求的 host 使用隔离舱的 http.RoundTripper
+type Transport struct {
+	base     http.RoundTripper
+	registry *Registry
+}
+
+// NewTransport 创建带隔离舱的 Transport，base 为空时使用 http.DefaultTransport，registry 为空时使用 Default()
+func NewTransport(base http.RoundTripper, registry *Registry) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if registry == nil {
+		registry = Default()
+	}
+
+	return &Transport{
+		base:     base,
+		registry: registry,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+// 隔离舱只限制到收到响应头为止的并发，读取响应体不占用配额
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := new(handoff)
+
+	err := t.registry.ForHost(req.URL.Host).Execute(req.Context(), func(ctx context.Context) error {
+		resp, err := t.base.RoundTrip(req)
+		h.put(resp)
+		return err
+	})
+	resp := h.take()
+
+	if err != nil {
+		// 工作池在调用方 ctx 结束时不再等待，此时已经收到的响应不会返回给调用方
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if err == ErrFull && req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// handoff 将工作 goroutine 收到的响应交给调用方
+// 工作池隔离舱在调用方 ctx 结束后直接返回，工作 goroutine 之后收到的响应由它自己关闭，避免连接泄漏
+type handoff struct {
+	mu        sync.Mutex
+	resp      *http.Response
+	abandoned bool // 调用方已经返回
+}
+
+// put 工作 goroutine 交付响应，调用方已经返回时关闭响应
+func (h *handoff) put(resp *http.Response) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.abandoned {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		return
+	}
+	h.resp = resp
+}
+
+// take 调用方取走响应，之后交付的响应会被关闭
+func (h *handoff) take() *http.Response {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.abandoned = true
+	return h.resp
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotInteger 计数操作的键中存储的不是整数
+var ErrNotInteger = errors.New("cache value is not an integer")
+
+// mapDecoder 将批量获取的结果写入 *map[string]T
+type mapDecoder struct {
+	m        reflect.Value
+	elemType reflect.Type
+	codec    Codec
+}
+
+// newMapDecoder 校验 dest 为 *map[string]T，必要时初始化 map
+func newMapDecoder(dest interface{}, codec Codec) (*mapDecoder, error) {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Map || rv.Elem().Type().Key().Kind() != reflect.String {
+		return nil, errors.Errorf("cache: MGet dest must be *map[string]T, got %T", dest)
+	}
+
+	m := rv.Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+
+	return &mapDecoder{m: m, elemType: m.Type().Elem(), codec: codec}, nil
+}
+
+// decode 反序列化 data 并写入 key
+func (d *mapDecoder) decode(key string, data []byte) error {
+	v := reflect.New(d.elemType)
+	if err := d.codec.Unmarshal(data, v.Interface()); err != nil {
+		return errors.Wrapf(err, "cache: decode key %s", key)
+	}
+	d.m.SetMapIndex(reflect.ValueOf(key).Convert(d.m.Type().Key()), v.Elem())
+	return nil
+}
+
+// parseCounter 解析计数值，空数据视为0
+func parseCounter(data []byte) (int64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return n, nil
+}
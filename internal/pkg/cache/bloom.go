@@ -0,0 +1,410 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"gin-example/internal/pkg/lock"
+	redisRepo "gin-example/internal/repository/redis"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// BloomBackendLocal 过滤器存放在进程内存中，只适用于单实例部署：
+	// 其他实例新增的元素在下次重建前不在本实例的过滤器中，会被误判为不存在
+	BloomBackendLocal = "local"
+	// BloomBackendRedis 过滤器存放在 Redis bitmap 中，多实例共享
+	BloomBackendRedis = "redis"
+
+	// bloomKeyPrefix Redis 中过滤器 bitmap 的键前缀
+	bloomKeyPrefix = "cache:bloom:"
+
+	// bloomBatchSize 重建时每批写入的元素数量
+	bloomBatchSize = 1000
+
+	// bloomRebuildKeyTTL 重建键的过期时间，每批写入时延长，重建中断后自动清理
+	bloomRebuildKeyTTL = 10 * time.Minute
+)
+
+// bloomAddScript 设置元素对应的位，正在重建时同时写入重建键
+var bloomAddScript = redisRepo.GetScript("cache_bloom_add")
+
+// BloomFilter 布隆过滤器，用于拦截一定不存在的键（防止缓存穿透）
+//
+// 重建完成前 MightContain 始终返回 true，避免误拦截
+type BloomFilter interface {
+	// Add 添加元素
+	Add(ctx context.Context, items ...string) error
+	// MightContain 返回 false 表示元素一定不存在，true 表示可能存在
+	MightContain(ctx context.Context, item string) (bool, error)
+	// Rebuild 通过 seed 重新构建过滤器，期间新增的元素不会丢失
+	Rebuild(ctx context.Context, seed SeedFunc) error
+}
+
+// SeedFunc 数据源遍历函数，通过 add 写入全部已存在的元素
+type SeedFunc func(ctx context.Context, add func(items ...string) error) error
+
+// BloomConfig 布隆过滤器配置
+type BloomConfig struct {
+	Backend           string        // local / redis，默认 redis
+	ExpectedItems     uint64        // 预计元素数量
+	FalsePositiveRate float64       // 期望误判率，默认 0.01
+	RebuildInterval   time.Duration // 重建间隔，Redis 过滤器在其他实例半个间隔内完成过重建时跳过本次重建
+}
+
+// redisClientProvider 能提供 Redis 客户端的缓存实现
+type redisClientProvider interface {
+	redisClient() redis.UniversalClient
+}
+
+// NewBloomFilter 为指定键空间创建布隆过滤器
+// 缓存实现基于 Redis 时使用多实例共享的 Redis bitmap，backend 为 local 或缓存实现不基于 Redis 时使用本地内存
+func NewBloomFilter(c Cache, name string, config BloomConfig) BloomFilter {
+	params := newBloomParams(config.ExpectedItems, config.FalsePositiveRate)
+
+	if config.Backend != BloomBackendLocal {
+		if p, ok := c.(redisClientProvider); ok && p.redisClient() != nil {
+			// 使用 hash tag 保证集群模式下重建键与正式键位于同一 slot，RENAME 和脚本才能执行
+			return newRedisBloomFilter(p.redisClient(), bloomKeyPrefix+"{"+name+"}", params, config.RebuildInterval)
+		}
+	}
+
+	return newLocalBloomFilter(params)
+}
+
+// RebuildPeriodically 立即重建一次，之后按间隔定期重建，直到 ctx 结束
+func RebuildPeriodically(ctx context.Context, filter BloomFilter, interval time.Duration, seed SeedFunc, logger *zap.Logger) {
+	rebuild := func() {
+		if err := filter.Rebuild(ctx, seed); err != nil && logger != nil {
+			logger.Warn("bloom filter rebuild failed", zap.Error(err))
+		}
+	}
+
+	rebuild()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rebuild()
+		}
+	}
+}
+
+// bloomParams 位数组大小 m 和哈希函数个数 k
+type bloomParams struct {
+	m uint64
+	k uint64
+}
+
+// newBloomParams 根据预计元素数量 n 和误判率 p 计算参数
+//
+//	m = -n * ln(p) / (ln2)^2
+//	k = m / n * ln2
+func newBloomParams(n uint64, p float64) bloomParams {
+	if n == 0 {
+		n = 10000
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return bloomParams{m: m, k: k}
+}
+
+// locations 通过双重哈希计算元素对应的 k 个位置，各实例计算结果一致
+func (p bloomParams) locations(item string) []uint64 {
+	h1 := fnv.New64a()
+	_, _ = h1.Write([]byte(item))
+	a := h1.Sum64()
+
+	h2 := fnv.New64()
+	_, _ = h2.Write([]byte(item))
+	b := h2.Sum64() | 1
+
+	locs := make([]uint64, p.k)
+	for i := uint64(0); i < p.k; i++ {
+		locs[i] = (a + i*b) % p.m
+	}
+	return locs
+}
+
+// localBloomFilter 本地内存布隆过滤器
+type localBloomFilter struct {
+	params bloomParams
+
+	mu     sync.RWMutex
+	bits   []uint64
+	next   []uint64 // 重建中的位数组，期间新增元素同时写入
+	seeded bool
+}
+
+func newLocalBloomFilter(params bloomParams) *localBloomFilter {
+	return &localBloomFilter{
+		params: params,
+		bits:   make([]uint64, (params.m+63)/64),
+	}
+}
+
+func (f *localBloomFilter) Add(_ context.Context, items ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, item := range items {
+		for _, loc := range f.params.locations(item) {
+			f.bits[loc/64] |= 1 << (loc % 64)
+			if f.next != nil {
+				f.next[loc/64] |= 1 << (loc % 64)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *localBloomFilter) MightContain(_ context.Context, item string) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if !f.seeded {
+		return true, nil
+	}
+
+	for _, loc := range f.params.locations(item) {
+		if f.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *localBloomFilter) Rebuild(ctx context.Context, seed SeedFunc) error {
+	f.mu.Lock()
+	if f.next != nil {
+		f.mu.Unlock()
+		return nil // 已有重建任务在执行
+	}
+	f.next = make([]uint64, len(f.bits))
+	f.mu.Unlock()
+
+	err := seed(ctx, func(items ...string) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		for _, item := range items {
+			for _, loc := range f.params.locations(item) {
+				f.next[loc/64] |= 1 << (loc % 64)
+			}
+		}
+		return nil
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		f.bits = f.next
+		f.seeded = true
+	}
+	f.next = nil
+	return err
+}
+
+// redisBloomFilter 基于 Redis bitmap 的布隆过滤器，多实例共享
+//
+// 重建通过分布式锁只在一个实例上执行，写入固定的重建键后 RENAME 为正式键。
+// 重建键存在期间，任意实例的 Add 都会同时写入重建键，重建完成后不会丢失期间新增的元素
+type redisBloomFilter struct {
+	client   redis.UniversalClient
+	key      string
+	params   bloomParams
+	locker   *lock.Locker
+	interval time.Duration
+
+	mu     sync.RWMutex
+	seeded bool
+}
+
+func newRedisBloomFilter(client redis.UniversalClient, key string, params bloomParams, interval time.Duration) *redisBloomFilter {
+	return &redisBloomFilter{
+		client:   client,
+		key:      key,
+		params:   params,
+		locker:   lock.New(client, nil),
+		interval: interval,
+	}
+}
+
+// rebuildKey 重建中的位数组，存在即表示有实例正在重建
+func (f *redisBloomFilter) rebuildKey() string {
+	return f.key + ":rebuild"
+}
+
+// builtKey 最近一次重建完成的时间（Unix 毫秒），存在即表示过滤器已经构建
+func (f *redisBloomFilter) builtKey() string {
+	return f.key + ":built"
+}
+
+func (f *redisBloomFilter) Add(ctx context.Context, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	offsets := make([]interface{}, 0, len(items)*int(f.params.k))
+	for _, item := range items {
+		for _, loc := range f.params.locations(item) {
+			offsets = append(offsets, loc)
+		}
+	}
+	return bloomAddScript.Run(ctx, f.client, []string{f.key, f.rebuildKey()}, offsets...).Err()
+}
+
+func (f *redisBloomFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	f.mu.RLock()
+	seeded := f.seeded
+	f.mu.RUnlock()
+
+	if !seeded {
+		return true, nil
+	}
+
+	pipe := f.client.Pipeline()
+	locs := f.params.locations(item)
+	cmds := make([]*redis.IntCmd, len(locs))
+	for i, loc := range locs {
+		cmds[i] = pipe.GetBit(ctx, f.key, int64(loc))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return true, err
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Val() == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Rebuild 获得重建锁的实例执行重建，其他实例只检查过滤器是否已经由其他实例构建
+func (f *redisBloomFilter) Rebuild(ctx context.Context, seed SeedFunc) error {
+	lk, err := f.locker.Acquire(ctx, f.rebuildKey())
+	if err == lock.ErrNotAcquired {
+		return f.checkBuilt(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = lk.Release(context.Background())
+	}()
+
+	// 其他实例刚完成重建，不再重复遍历数据源
+	if built, err := f.client.Get(ctx, f.builtKey()).Int64(); err == nil && f.interval > 0 &&
+		time.Since(time.UnixMilli(built)) < f.interval/2 {
+		f.markSeeded()
+		return nil
+	}
+
+	// 租约丢失时停止重建，避免与新的持有者同时写入重建键
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lk.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	rebuildKey := f.rebuildKey()
+
+	// 清理上次中断的重建，并预先分配 bitmap，保证数据源为空时 RENAME 也能成功
+	pipe := f.client.TxPipeline()
+	pipe.Del(ctx, rebuildKey)
+	pipe.SetBit(ctx, rebuildKey, int64(f.params.m-1), 0)
+	pipe.PExpire(ctx, rebuildKey, bloomRebuildKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	err = seed(ctx, func(items ...string) error {
+		for start := 0; start < len(items); start += bloomBatchSize {
+			end := start + bloomBatchSize
+			if end > len(items) {
+				end = len(items)
+			}
+			if err := f.setBits(ctx, rebuildKey, items[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = f.client.Del(context.Background(), rebuildKey).Err()
+		return err
+	}
+
+	pipe = f.client.TxPipeline()
+	pipe.Rename(ctx, rebuildKey, f.key)
+	pipe.Persist(ctx, f.key)
+	pipe.Set(ctx, f.builtKey(), time.Now().UnixMilli(), 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		_ = f.client.Del(context.Background(), rebuildKey).Err()
+		return err
+	}
+
+	f.markSeeded()
+	return nil
+}
+
+// checkBuilt 其他实例已经完成过重建时开始使用过滤器
+func (f *redisBloomFilter) checkBuilt(ctx context.Context) error {
+	n, err := f.client.Exists(ctx, f.builtKey()).Result()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		f.markSeeded()
+	}
+	return nil
+}
+
+func (f *redisBloomFilter) markSeeded() {
+	f.mu.Lock()
+	f.seeded = true
+	f.mu.Unlock()
+}
+
+// setBits 通过 pipeline 批量设置重建键中元素对应的位，并延长重建键的过期时间
+func (f *redisBloomFilter) setBits(ctx context.Context, key string, items []string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	pipe := f.client.Pipeline()
+	for _, item := range items {
+		for _, loc := range f.params.locations(item) {
+			pipe.SetBit(ctx, key, int64(loc), 1)
+		}
+	}
+	pipe.PExpire(ctx, key, bloomRebuildKeyTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLocalBloomFilter(t *testing.T) {
+	f := NewBloomFilter(nil, "item", BloomConfig{ExpectedItems: 1000, FalsePositiveRate: 0.01})
+	ctx := context.Background()
+
+	// 构建完成前不拦截任何元素
+	if ok, _ := f.MightContain(ctx, "1"); !ok {
+		t.Fatal("filter should not reject before seeded")
+	}
+
+	err := f.Rebuild(ctx, func(ctx context.Context, add func(items ...string) error) error {
+		for i := 0; i < 1000; i++ {
+			if err := add(strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if ok, _ := f.MightContain(ctx, strconv.Itoa(i)); !ok {
+			t.Fatalf("%d should be contained", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if ok, _ := f.MightContain(ctx, strconv.Itoa(i)); ok {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 10000; rate > 0.03 {
+		t.Fatalf("false positive rate = %.4f; want about 0.01", rate)
+	}
+
+	_ = f.Add(ctx, "new")
+	if ok, _ := f.MightContain(ctx, "new"); !ok {
+		t.Fatal("added item should be contained")
+	}
+}
+
+func TestRefresherBloomFilter(t *testing.T) {
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	filter := NewBloomFilter(l, "item", BloomConfig{ExpectedItems: 100})
+	_ = filter.Rebuild(context.Background(), func(ctx context.Context, add func(items ...string) error) error {
+		return add("1")
+	})
+
+	var calls int32
+	r := NewRefresher(l)
+	r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return key, nil
+	}, RefreshPolicy{HardTTL: time.Minute, Filter: filter})
+
+	var got string
+	if err := r.GetOrLoad(context.Background(), "item:404", &got); err != ErrKeyNotFound {
+		t.Fatalf("GetOrLoad() = %v; want ErrKeyNotFound", err)
+	}
+	if calls != 0 {
+		t.Fatal("loader should not run for ids rejected by the filter")
+	}
+
+	if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil || got != "item:1" {
+		t.Fatalf("GetOrLoad() = %q, %v", got, err)
+	}
+}
+
+func TestRedisBloomFilterRebuild(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	params := newBloomParams(100, 0.01)
+	a := newRedisBloomFilter(client, "bloom:{item}", params, time.Hour)
+	b := newRedisBloomFilter(client, "bloom:{item}", params, time.Hour)
+
+	var seeds int32
+	err := a.Rebuild(ctx, func(ctx context.Context, add func(items ...string) error) error {
+		atomic.AddInt32(&seeds, 1)
+
+		// 重建期间其他实例不能同时重建，其他实例新增的元素在重建完成后仍然存在
+		if err := b.Rebuild(ctx, func(context.Context, func(items ...string) error) error {
+			t.Fatal("only one instance should rebuild at a time")
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := b.Add(ctx, "new"); err != nil {
+			return err
+		}
+		return add("1", "2")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []string{"1", "2", "new"} {
+		if ok, err := a.MightContain(ctx, item); err != nil || !ok {
+			t.Fatalf("MightContain(%q) = %v, %v; want true", item, ok, err)
+		}
+	}
+	if ok, _ := a.MightContain(ctx, "404"); ok {
+		t.Fatal("item never added should be rejected")
+	}
+
+	// 其他实例刚完成重建，不再重复遍历数据源
+	if err := b.Rebuild(ctx, func(context.Context, func(items ...string) error) error {
+		atomic.AddInt32(&seeds, 1)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if seeds != 1 {
+		t.Fatalf("seeds = %d; want 1", seeds)
+	}
+	if ok, _ := b.MightContain(ctx, "404"); ok {
+		t.Fatal("filter built by another instance should be used")
+	}
+}
@@ -7,12 +7,36 @@ type Cache interface {
 	// Get 从缓存获取数据
 	Get(key string, dest interface{}) error
 	
-	// Set 设置缓存数据
-	Set(key string, value interface{}, expiration time.Duration) error
+	// Set 设置缓存数据，可关联若干标签用于批量失效
+	Set(key string, value interface{}, expiration time.Duration, tags ...string) error
 	
 	// Delete 删除缓存数据
 	Delete(key string) error
 	
 	// Exists 检查键是否存在
 	Exists(key string) (bool, error)
+
+	// InvalidateTag 删除关联了指定标签的所有缓存数据
+	InvalidateTag(tags ...string) error
+
+	// DeletePrefix 删除指定前缀的所有缓存数据
+	DeletePrefix(prefix string) error
+
+	// MGet 批量获取数据，dest 必须为 *map[string]T，不存在的键不会出现在结果中
+	MGet(keys []string, dest interface{}) error
+
+	// MSet 批量设置缓存数据
+	MSet(items map[string]interface{}, expiration time.Duration, tags ...string) error
+
+	// Incr 原子增加计数并返回新值，键不存在时从0开始并设置过期时间
+	Incr(key string, delta int64, expiration time.Duration) (int64, error)
+
+	// Decr 原子减少计数并返回新值，键不存在时从0开始并设置过期时间
+	Decr(key string, delta int64, expiration time.Duration) (int64, error)
+
+	// SetNX 键不存在时设置缓存数据，返回是否设置成功
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+
+	// GetSet 设置新值并将旧值写入 dest，旧值不存在时返回 ErrKeyNotFound（新值仍会写入）
+	GetSet(key string, value interface{}, expiration time.Duration, dest interface{}) error
 }
\ No newline at end of file
@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/ugorji/go/codec"
+)
+
+// Codec 缓存值编解码器，按缓存实例配置
+//
+// 除 JSON 外，编码结果以1字节版本头开头：低4位为格式编号，0x10 表示已压缩。
+// 解码时根据版本头选择格式，与实例配置的编码格式无关，
+// 因此切换编码格式后 Redis 中的旧数据仍可读取，无需清空。
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Format 序列化格式编号，取值范围 1~8
+// 版本头始终小于 0x20，不会与 JSON 文本的首字节（含空白字符）冲突
+type Format byte
+
+const (
+	FormatJSON    Format = 1
+	FormatMsgpack Format = 2
+	FormatGob     Format = 3
+
+	// maxFormat 格式编号上限，保证压缩后的版本头不与 \t \n \r 冲突
+	maxFormat Format = 8
+
+	// flagCompressed 压缩标记
+	flagCompressed byte = 0x10
+)
+
+// JSON 不带版本头的 JSON 编码，与历史数据完全兼容（默认）
+var JSON Codec = jsonCodec{}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[Format]Codec{
+		FormatJSON:    rawJSON{},
+		FormatMsgpack: newMsgpack(),
+		FormatGob:     rawGob{},
+	}
+
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// RegisterFormat 注册自定义序列化格式，c 只需处理不带版本头的数据
+func RegisterFormat(format Format, c Codec) error {
+	if format == 0 || format > maxFormat {
+		return errors.Errorf("cache: format must be in 1~%d, got %d", maxFormat, format)
+	}
+
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats[format] = c
+	return nil
+}
+
+// CodecOption 编解码器配置项
+type CodecOption func(*versionedCodec)
+
+// WithCompression 编码结果超过 threshold 字节时使用 zstd 压缩
+func WithCompression(threshold int) CodecOption {
+	return func(c *versionedCodec) {
+		c.compressThreshold = threshold
+	}
+}
+
+// NewCodec 创建带版本头的编解码器
+func NewCodec(format Format, options ...CodecOption) Codec {
+	c := &versionedCodec{format: format}
+	for _, f := range options {
+		f(c)
+	}
+	return c
+}
+
+// decodeValue 根据版本头解码，没有版本头的数据按 JSON 处理
+func decodeValue(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] >= 0x20 {
+		return json.Unmarshal(data, v)
+	}
+
+	header, payload := data[0], data[1:]
+	format := Format(header &^ flagCompressed)
+
+	formatsMu.RLock()
+	c, ok := formats[format]
+	formatsMu.RUnlock()
+	if !ok {
+		return errors.Errorf("cache: unknown format %d", format)
+	}
+
+	if header&flagCompressed != 0 {
+		var err error
+		if payload, err = zstdDecoder.DecodeAll(payload, nil); err != nil {
+			return errors.Wrap(err, "cache: decompress")
+		}
+	}
+
+	return c.Unmarshal(payload, v)
+}
+
+// versionedCodec 带版本头、可选压缩的编解码器
+type versionedCodec struct {
+	format            Format
+	compressThreshold int // 0 表示不压缩
+}
+
+func (c *versionedCodec) Marshal(v interface{}) ([]byte, error) {
+	formatsMu.RLock()
+	inner, ok := formats[c.format]
+	formatsMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("cache: unknown format %d", c.format)
+	}
+
+	payload, err := inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	header := byte(c.format)
+	if c.compressThreshold > 0 && len(payload) > c.compressThreshold {
+		payload = zstdEncoder.EncodeAll(payload, make([]byte, 0, len(payload)/2))
+		header |= flagCompressed
+	}
+
+	data := make([]byte, 0, len(payload)+1)
+	data = append(data, header)
+	return append(data, payload...), nil
+}
+
+func (c *versionedCodec) Unmarshal(data []byte, v interface{}) error {
+	return decodeValue(data, v)
+}
+
+// jsonCodec 不带版本头的 JSON，解码时兼容带版本头的数据
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return decodeValue(data, v)
+}
+
+// rawJSON JSON 格式
+type rawJSON struct{}
+
+func (rawJSON) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (rawJSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// rawMsgpack MessagePack 格式，整数和 time.Time 类型可以完整还原
+// 结构体字段名沿用 json 标签
+type rawMsgpack struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpack() rawMsgpack {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	return rawMsgpack{handle: h}
+}
+
+func (m rawMsgpack) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, m.handle).Encode(v)
+	return data, err
+}
+
+func (m rawMsgpack) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, m.handle).Decode(v)
+}
+
+// rawGob gob 格式，interface{} 中的自定义类型需要先 gob.Register
+type rawGob struct{}
+
+func (rawGob) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (rawGob) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// encodedValue 已编码的数据，缓存实例写入时原样保存
+type encodedValue []byte
+
+// encodeWith 使用指定的编解码器预先编码，codec 或 value 为 nil 时原样返回
+func encodeWith(c Codec, value interface{}) (interface{}, error) {
+	if c == nil || value == nil {
+		return value, nil
+	}
+
+	data, err := c.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return encodedValue(data), nil
+}
+
+// codecName 编解码器名称，用于展示
+func codecName(c Codec) string {
+	switch c := c.(type) {
+	case nil:
+		return "default"
+	case jsonCodec:
+		return "json"
+	case *versionedCodec:
+		name := fmt.Sprintf("format-%d", c.format)
+		switch c.format {
+		case FormatJSON:
+			name = "json"
+		case FormatMsgpack:
+			name = "msgpack"
+		case FormatGob:
+			name = "gob"
+		}
+		if c.compressThreshold > 0 {
+			name += "+zstd"
+		}
+		return name
+	default:
+		return fmt.Sprintf("%T", c)
+	}
+}
+
+// CodecByName 按名称创建编解码器（json / msgpack / gob），compressThreshold 大于0时启用压缩
+// 未启用压缩的 json 不带版本头，与历史数据兼容
+func CodecByName(name string, compressThreshold int) (Codec, error) {
+	var format Format
+	switch name {
+	case "", "json":
+		if compressThreshold <= 0 {
+			return JSON, nil
+		}
+		format = FormatJSON
+	case "msgpack":
+		format = FormatMsgpack
+	case "gob":
+		format = FormatGob
+	default:
+		return nil, errors.Errorf("cache: unknown codec %q", name)
+	}
+
+	return NewCodec(format, WithCompression(compressThreshold)), nil
+}
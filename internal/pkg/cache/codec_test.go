@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecSample struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	want := codecSample{ID: 1, Name: "root", CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)}
+
+	codecs := map[string]Codec{
+		"json":           JSON,
+		"json-versioned": NewCodec(FormatJSON),
+		"msgpack":        NewCodec(FormatMsgpack),
+		"gob":            NewCodec(FormatGob),
+	}
+	for name, c := range codecs {
+		data, err := c.Marshal(&want)
+		if err != nil {
+			t.Fatalf("%s: Marshal() error = %v", name, err)
+		}
+
+		var got codecSample
+		if err := c.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: Unmarshal() error = %v", name, err)
+		}
+		if got.ID != want.ID || got.Name != want.Name || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Fatalf("%s: got %+v; want %+v", name, got, want)
+		}
+	}
+}
+
+func TestCodecMsgpackKeepsIntegers(t *testing.T) {
+	c := NewCodec(FormatMsgpack)
+	data, err := c.Marshal(map[string]interface{}{"n": 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := c.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["n"].(int64); !ok {
+		t.Fatalf("got %T; want int64", got["n"])
+	}
+}
+
+func TestCodecCompressionAndMigration(t *testing.T) {
+	value := strings.Repeat("a", 4096)
+
+	c := NewCodec(FormatMsgpack, WithCompression(1024))
+	data, err := c.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != byte(FormatMsgpack)|flagCompressed || len(data) >= len(value) {
+		t.Fatalf("value should be compressed, header = %#x, len = %d", data[0], len(data))
+	}
+
+	// 任意实例都能读取其他格式写入的数据
+	var got string
+	if err := JSON.Unmarshal(data, &got); err != nil || got != value {
+		t.Fatalf("JSON codec should decode msgpack data, err = %v", err)
+	}
+
+	legacy := []byte(`"legacy"`)
+	if err := c.Unmarshal(legacy, &got); err != nil || got != "legacy" {
+		t.Fatalf("msgpack codec should decode legacy json, got %q, err = %v", got, err)
+	}
+
+	if err := c.Unmarshal([]byte{0x07, 0x01}, &got); err == nil {
+		t.Fatal("unknown format should fail")
+	}
+}
+
+func TestLocalCacheWithCodec(t *testing.T) {
+	l := NewLocalCache(100, time.Minute, WithLocalCodec(NewCodec(FormatMsgpack)))
+	defer l.Close()
+
+	_ = l.Set("a", &codecSample{ID: 1, Name: "root"}, time.Minute)
+	data, _ := l.getRaw("a")
+	if data[0] != byte(FormatMsgpack) {
+		t.Fatalf("header = %#x; want msgpack", data[0])
+	}
+
+	var values map[string]codecSample
+	if err := l.MGet([]string{"a"}, &values); err != nil || values["a"].Name != "root" {
+		t.Fatalf("MGet() = %v, %v", values, err)
+	}
+
+	_ = l.Set("empty", nil, time.Minute)
+	if data, _ := l.getRaw("empty"); !bytes.Equal(data, emptyValue) {
+		t.Fatal("nil value should be stored as empty marker")
+	}
+}
+
+func BenchmarkCodecMarshal(b *testing.B) {
+	value := &codecSample{ID: 1, Name: "root", CreatedAt: time.Now()}
+	for _, c := range []struct {
+		name  string
+		codec Codec
+	}{
+		{"json", JSON},
+		{"msgpack", NewCodec(FormatMsgpack)},
+		{"gob", NewCodec(FormatGob)},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = c.codec.Marshal(value)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gin-example/internal/metrics"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// ModeMultiLevel 多级缓存模式（L1 + L2）
+	ModeMultiLevel = "multi-level"
+	// ModeL1Only 降级模式，仅使用L1本地缓存
+	ModeL1Only = "l1-only"
+
+	// maxPendingDeletes 降级期间每类操作最多记录的待补偿数量
+	maxPendingDeletes = 10000
+)
+
+// HealthReporter 可报告运行状态的缓存
+type HealthReporter interface {
+	Health() HealthStatus
+}
+
+// HealthStatus 缓存运行状态
+type HealthStatus struct {
+	Mode              string    `json:"mode"`               // 当前模式：multi-level / l1-only
+	Degraded          bool      `json:"degraded"`           // 是否处于降级状态
+	ConsecutiveErrors int       `json:"consecutive_errors"` // L2连续错误次数
+	LastError         string    `json:"last_error"`         // 最近一次L2错误
+	LastTransition    time.Time `json:"last_transition"`    // 最近一次状态切换时间
+}
+
+// l2Health L2缓存健康状态跟踪
+type l2Health struct {
+	mu                sync.RWMutex
+	degraded          bool
+	consecutiveErrors int
+	lastError         string
+	lastTransition    time.Time
+	pendingDeletes    map[string]struct{} // 降级期间未能同步到L2的删除操作
+	pendingTags       map[string]struct{} // 降级期间未能同步到L2的标签失效
+	pendingPrefixes   map[string]struct{} // 降级期间未能同步到L2的前缀删除
+	pendingOverflow   bool                // 待补偿操作超过上限，恢复时清空整个命名空间
+}
+
+// newL2Health 创建L2健康状态
+func newL2Health() *l2Health {
+	return &l2Health{
+		pendingDeletes:  make(map[string]struct{}),
+		pendingTags:     make(map[string]struct{}),
+		pendingPrefixes: make(map[string]struct{}),
+	}
+}
+
+// Health 获取缓存运行状态
+func (m *MultiLevelCache) Health() HealthStatus {
+	m.health.mu.RLock()
+	defer m.health.mu.RUnlock()
+
+	mode := ModeMultiLevel
+	if m.health.degraded {
+		mode = ModeL1Only
+	}
+
+	return HealthStatus{
+		Mode:              mode,
+		Degraded:          m.health.degraded,
+		ConsecutiveErrors: m.health.consecutiveErrors,
+		LastError:         m.health.lastError,
+		LastTransition:    m.health.lastTransition,
+	}
+}
+
+// l2Available L2缓存当前是否可用
+func (m *MultiLevelCache) l2Available() bool {
+	m.health.mu.RLock()
+	defer m.health.mu.RUnlock()
+	return !m.health.degraded
+}
+
+// recordL2Result 记录L2操作结果，连续错误达到阈值后切换为L1-only模式
+func (m *MultiLevelCache) recordL2Result(err error) {
+	if err == redis.Nil {
+		err = nil
+	}
+
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+
+	if err == nil {
+		m.health.consecutiveErrors = 0
+		return
+	}
+
+	m.health.consecutiveErrors++
+	m.health.lastError = err.Error()
+
+	if !m.health.degraded && m.health.consecutiveErrors >= m.degradeThreshold {
+		m.degradeLocked(err)
+	}
+}
+
+// degradeLocked 切换为L1-only模式，调用方需持有写锁
+func (m *MultiLevelCache) degradeLocked(err error) {
+	m.health.degraded = true
+	m.health.lastTransition = time.Now()
+
+	metrics.SetCacheDegraded(m.name, true)
+	metrics.RecordCacheStateTransition(m.name, ModeL1Only)
+
+	m.logger.Warn("redis unavailable, cache degraded to l1-only mode",
+		zap.String("cache", m.name),
+		zap.Int("consecutive_errors", m.health.consecutiveErrors),
+		zap.Error(err))
+}
+
+// recordPending 记录未能同步到L2的失效操作，恢复后补偿到L2
+func (m *MultiLevelCache) recordPending(op string, keys ...string) {
+	m.health.mu.Lock()
+	defer m.health.mu.Unlock()
+
+	m.recordPendingLocked(op, keys)
+}
+
+// addPending 记录降级期间的失效操作，恢复后补偿到L2
+// 调用方判断降级后可能已经切回多级缓存模式，此时直接同步到L2，失败时仍然记录
+func (m *MultiLevelCache) addPending(op string, keys ...string) {
+	m.health.mu.Lock()
+	degraded := m.health.degraded
+	if degraded {
+		m.recordPendingLocked(op, keys)
+	}
+	m.health.mu.Unlock()
+
+	if degraded {
+		return
+	}
+
+	if err := m.applyInvalidation(op, keys); err != nil {
+		m.recordPending(op, keys...)
+		return
+	}
+	m.publishInvalidation(op, keys...)
+}
+
+// recordPendingLocked 记录待补偿的失效操作，调用方需持有写锁
+// 超过上限时不再逐个记录，恢复时清空整个命名空间
+func (m *MultiLevelCache) recordPendingLocked(op string, keys []string) {
+	if m.health.pendingOverflow {
+		return
+	}
+
+	pending := m.health.pendingDeletes
+	switch op {
+	case invalidateOpTag:
+		pending = m.health.pendingTags
+	case invalidateOpPrefix:
+		pending = m.health.pendingPrefixes
+	}
+
+	for _, key := range keys {
+		if len(pending) >= maxPendingDeletes {
+			m.health.pendingOverflow = true
+			m.logger.Warn("too many pending cache invalidations, l2 namespace will be flushed on recovery",
+				zap.String("cache", m.name),
+				zap.String("namespace", Namespace()),
+				zap.Int("limit", maxPendingDeletes))
+			return
+		}
+		pending[key] = struct{}{}
+	}
+}
+
+// pendingEmptyLocked 是否没有待补偿的失效操作，调用方需持有锁
+func (m *MultiLevelCache) pendingEmptyLocked() bool {
+	return !m.health.pendingOverflow &&
+		len(m.health.pendingDeletes) == 0 &&
+		len(m.health.pendingTags) == 0 &&
+		len(m.health.pendingPrefixes) == 0
+}
+
+// applyInvalidation 将失效操作同步到L2
+func (m *MultiLevelCache) applyInvalidation(op string, keys []string) error {
+	switch op {
+	case invalidateOpTag:
+		deleted, err := m.l2Cache.invalidateTags(keys...)
+		m.publishInvalidation(invalidateOpDelete, deleted...)
+		return err
+	case invalidateOpPrefix:
+		for _, prefix := range keys {
+			if err := m.l2Cache.DeletePrefix(prefix); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return m.l2Cache.deleteKeys(m.ctx, keys)
+	}
+}
+
+// replayPending 将降级期间的失效操作补偿到L2，失败的操作重新记录
+func (m *MultiLevelCache) replayPending() (int, error) {
+	m.health.mu.Lock()
+	overflow := m.health.pendingOverflow
+	pendingDeletes := m.health.pendingDeletes
+	pendingTags := m.health.pendingTags
+	pendingPrefixes := m.health.pendingPrefixes
+	m.health.pendingOverflow = false
+	m.health.pendingDeletes = make(map[string]struct{})
+	m.health.pendingTags = make(map[string]struct{})
+	m.health.pendingPrefixes = make(map[string]struct{})
+	m.health.mu.Unlock()
+
+	// 超过上限时已经无法知道哪些键需要补偿，清空整个命名空间
+	if overflow {
+		pendingDeletes = nil
+		pendingTags = nil
+		pendingPrefixes = map[string]struct{}{Namespace() + ":": {}}
+	}
+
+	var (
+		replayed int
+		firstErr error
+	)
+	for _, step := range []struct {
+		op      string
+		pending map[string]struct{}
+	}{
+		{invalidateOpDelete, pendingDeletes},
+		{invalidateOpTag, pendingTags},
+		{invalidateOpPrefix, pendingPrefixes},
+	} {
+		if len(step.pending) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(step.pending))
+		for key := range step.pending {
+			keys = append(keys, key)
+		}
+
+		if err := m.applyInvalidation(step.op, keys); err != nil {
+			if overflow {
+				m.health.mu.Lock()
+				m.health.pendingOverflow = true
+				m.health.mu.Unlock()
+			} else {
+				m.recordPending(step.op, keys...)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		replayed += len(keys)
+		m.publishInvalidation(step.op, keys...)
+	}
+
+	return replayed, firstErr
+}
+
+// probeL2 降级期间定期探测Redis，恢复后自动切回多级缓存模式
+func (m *MultiLevelCache) probeL2() {
+	defer close(m.probeDone)
+
+	ticker := time.NewTicker(m.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			if m.l2Available() {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(m.ctx, m.probeInterval)
+			err := m.l2Cache.client.Ping(ctx).Err()
+			cancel()
+
+			if err != nil {
+				m.logger.Debug("redis probe failed", zap.String("cache", m.name), zap.Error(err))
+				continue
+			}
+
+			m.recover()
+		}
+	}
+}
+
+// recover Redis恢复后切回多级缓存模式
+func (m *MultiLevelCache) recover() {
+	// 降级期间可能错过其他实例的失效消息，清空L1保证一致性
+	m.l1Cache.Clear()
+
+	// 补偿降级期间的失效操作，避免L2返回旧数据。补偿期间仍处于降级状态，新的写入继续记录，
+	// 直到没有待补偿的操作时才在同一把锁内切回多级缓存模式，之后的失效操作由 addPending 直接同步到L2
+	replayed := 0
+	for {
+		n, err := m.replayPending()
+		replayed += n
+		if err != nil {
+			m.logger.Warn("replay pending cache invalidations failed", zap.String("cache", m.name), zap.Error(err))
+			return
+		}
+
+		m.health.mu.Lock()
+		if m.pendingEmptyLocked() {
+			m.health.degraded = false
+			m.health.consecutiveErrors = 0
+			m.health.lastTransition = time.Now()
+			m.health.mu.Unlock()
+			break
+		}
+		m.health.mu.Unlock()
+	}
+
+	metrics.SetCacheDegraded(m.name, false)
+	metrics.RecordCacheStateTransition(m.name, ModeMultiLevel)
+
+	m.logger.Info("redis recovered, cache switched back to multi-level mode",
+		zap.String("cache", m.name),
+		zap.Int("replayed_invalidations", replayed))
+}
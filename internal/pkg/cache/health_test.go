@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newDegradableCache(t *testing.T) (*MultiLevelCache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	m := NewMultiLevelCache(client, WithDegradeThreshold(1), WithProbeInterval(10*time.Millisecond))
+	t.Cleanup(func() { _ = m.Close() })
+	return m, mr
+}
+
+func waitRecovered(t *testing.T, m *MultiLevelCache) {
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Health().Degraded {
+		if time.Now().After(deadline) {
+			t.Fatal("cache did not recover")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMultiLevelCacheRecoveryDropsStaleL2(t *testing.T) {
+	m, mr := newDegradableCache(t)
+
+	_ = m.Set("k", "v1", time.Minute)
+	_ = m.Set("other", "v1", time.Minute)
+
+	// L2写入失败后降级，降级期间只写入L1
+	mr.SetError("LOADING")
+	_ = m.Set("k", "v2", time.Minute)
+	if !m.Health().Degraded {
+		t.Fatal("expected cache to degrade after a failed L2 write")
+	}
+	_ = m.Set("other", "v2", time.Minute)
+
+	mr.SetError("")
+	waitRecovered(t, m)
+
+	// L2中的旧值在恢复时删除，不会在清空L1后重新被读到
+	for _, key := range []string{"k", "other"} {
+		if mr.Exists(key) {
+			t.Fatalf("stale L2 value for %q survived recovery", key)
+		}
+	}
+}
+
+func TestMultiLevelCachePendingOverflowFlushesNamespace(t *testing.T) {
+	m, mr := newDegradableCache(t)
+
+	key := Namespace() + ":item:1"
+	_ = m.Set(key, "v1", time.Minute)
+
+	mr.SetError("LOADING")
+	_ = m.Delete("probe")
+	if !m.Health().Degraded {
+		t.Fatal("expected cache to degrade after a failed L2 delete")
+	}
+	for i := 0; i <= maxPendingDeletes; i++ {
+		_ = m.Delete("overflow:" + strconv.Itoa(i))
+	}
+	_ = m.Set(key, "v2", time.Minute)
+
+	mr.SetError("")
+	waitRecovered(t, m)
+
+	if mr.Exists(key) {
+		t.Fatal("expected namespace to be flushed after pending invalidations overflowed")
+	}
+}
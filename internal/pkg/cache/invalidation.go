@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gin-example/internal/pkg/idgen"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultInvalidationChannel 默认的L1失效广播频道
+	DefaultInvalidationChannel = "cache:invalidation"
+
+	invalidateOpSet    = "set"
+	invalidateOpDelete = "delete"
+	invalidateOpTag    = "tag"
+	invalidateOpPrefix = "prefix"
+)
+
+// invalidationMessage L1失效广播消息
+type invalidationMessage struct {
+	Origin string   `json:"origin"` // 发送方实例ID，用于忽略自身消息
+	Op     string   `json:"op"`     // 触发失效的操作：set / delete / tag / prefix
+	Keys   []string `json:"keys"`   // 需要失效的键、标签或前缀
+}
+
+// newInstanceID 生成当前实例的唯一标识
+func newInstanceID() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), idgen.GenerateUniqueID())
+}
+
+// publishInvalidation 广播L1失效消息，其他实例收到后删除本地缓存
+// 对于 tag / prefix 操作，keys 为标签或前缀
+func (m *MultiLevelCache) publishInvalidation(op string, keys ...string) {
+	if m.invalidationChannel == "" || len(keys) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(&invalidationMessage{
+		Origin: m.instanceID,
+		Op:     op,
+		Keys:   keys,
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, time.Second)
+	defer cancel()
+
+	if err := m.l2Cache.client.Publish(ctx, m.invalidationChannel, payload).Err(); err != nil {
+		m.logger.Warn("publish cache invalidation failed",
+			zap.String("channel", m.invalidationChannel),
+			zap.Strings("keys", keys),
+			zap.Error(err))
+	}
+}
+
+// subscribeInvalidation 订阅L1失效消息，连接断开后由客户端自动重连重新订阅
+func (m *MultiLevelCache) subscribeInvalidation() {
+	defer close(m.subscribeDone)
+
+	subscribed := false
+	for msg := range m.pubsub.ChannelWithSubscriptions(m.ctx, 100) {
+		switch v := msg.(type) {
+		case *redis.Subscription:
+			if v.Kind != "subscribe" {
+				continue
+			}
+			// 重连后重新订阅成功，断线期间可能错过失效消息，清空L1保证一致性
+			if subscribed {
+				m.l1Cache.Clear()
+				m.logger.Info("cache invalidation channel resubscribed, local cache cleared",
+					zap.String("channel", m.invalidationChannel))
+			}
+			subscribed = true
+		case *redis.Message:
+			m.handleInvalidation(v.Payload)
+		}
+	}
+}
+
+// handleInvalidation 处理收到的L1失效消息
+func (m *MultiLevelCache) handleInvalidation(payload string) {
+	var message invalidationMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		m.logger.Warn("invalid cache invalidation message", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	// 忽略自身发出的消息
+	if message.Origin == m.instanceID {
+		return
+	}
+
+	switch message.Op {
+	case invalidateOpTag:
+		_ = m.l1Cache.InvalidateTag(message.Keys...)
+	case invalidateOpPrefix:
+		for _, prefix := range message.Keys {
+			_ = m.l1Cache.DeletePrefix(prefix)
+		}
+	default:
+		for _, key := range message.Keys {
+			_ = m.l1Cache.Delete(key)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-example/configs"
+	"gin-example/internal/pkg/env"
+	redisRepo "gin-example/internal/repository/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeySpace 键空间，统一声明键模板、默认过期时间、编解码器和标签
+//
+// 模板中的 {name} 为占位符，{name:int} 只匹配非负整数，避免 admin:{id:int} 与 admin:list 互相覆盖。
+// 生成的键自动带上 项目名:环境 前缀，多个环境共用同一个 Redis 时互不干扰。
+// 例如 fat 环境下模板 admin:{id:int} 生成 gin-example:fat:admin:1
+type KeySpace struct {
+	template string
+	ttl      time.Duration
+	codec    Codec
+	tags     []string
+
+	literals []string // 按占位符切分后的字面量，比占位符多一个
+	params   []string // 占位符名称
+	numeric  []bool   // 占位符是否只匹配非负整数
+}
+
+// KeySpaceOption 键空间配置项
+type KeySpaceOption func(*KeySpace)
+
+// WithKeyTTL 设置默认过期时间
+func WithKeyTTL(ttl time.Duration) KeySpaceOption {
+	return func(k *KeySpace) {
+		k.ttl = ttl
+	}
+}
+
+// WithKeyCodec 设置编解码器，不设置时使用缓存实例的编解码器
+func WithKeyCodec(codec Codec) KeySpaceOption {
+	return func(k *KeySpace) {
+		k.codec = codec
+	}
+}
+
+// WithKeyTags 设置写入时关联的标签，标签同样带有 项目名:环境 前缀
+func WithKeyTags(tags ...string) KeySpaceOption {
+	return func(k *KeySpace) {
+		k.tags = tags
+	}
+}
+
+var (
+	keySpacesMu sync.RWMutex
+	keySpaces   []*KeySpace
+)
+
+// NewKeySpace 声明键空间，应在包级变量中声明，同一模板重复声明时 panic
+func NewKeySpace(template string, options ...KeySpaceOption) *KeySpace {
+	k := &KeySpace{template: template}
+	for _, f := range options {
+		f(k)
+	}
+	k.literals, k.params, k.numeric = parseKeyTemplate(template)
+
+	keySpacesMu.Lock()
+	defer keySpacesMu.Unlock()
+
+	for _, existing := range keySpaces {
+		if existing.template == template {
+			panic("cache: duplicate key space: " + template)
+		}
+	}
+	keySpaces = append(keySpaces, k)
+	return k
+}
+
+// KeySpaces 返回已声明的全部键空间
+func KeySpaces() []*KeySpace {
+	keySpacesMu.RLock()
+	defer keySpacesMu.RUnlock()
+
+	return append([]*KeySpace(nil), keySpaces...)
+}
+
+// Namespace 当前环境的键前缀（项目名:环境）
+func Namespace() string {
+	return configs.ProjectName + ":" + env.Active().Value()
+}
+
+// Template 键模板
+func (k *KeySpace) Template() string {
+	return k.template
+}
+
+// TTL 默认过期时间，0 表示使用缓存实例的默认值
+func (k *KeySpace) TTL() time.Duration {
+	return k.ttl
+}
+
+// Codec 编解码器，nil 表示使用缓存实例的编解码器
+func (k *KeySpace) Codec() Codec {
+	return k.codec
+}
+
+// Tags 带前缀的标签
+func (k *KeySpace) Tags() []string {
+	tags := make([]string, len(k.tags))
+	for i, tag := range k.tags {
+		tags[i] = Namespace() + ":" + tag
+	}
+	return tags
+}
+
+// Key 按顺序填充占位符生成完整的键，参数个数与占位符个数不一致时 panic
+func (k *KeySpace) Key(args ...interface{}) string {
+	if len(args) != len(k.params) {
+		panic(fmt.Sprintf("cache: key space %s expects %d args, got %d", k.template, len(k.params), len(args)))
+	}
+
+	var b strings.Builder
+	b.WriteString(Namespace())
+	b.WriteByte(':')
+	for i, literal := range k.literals {
+		b.WriteString(literal)
+		if i < len(args) {
+			b.WriteString(fmt.Sprint(args[i]))
+		}
+	}
+	return b.String()
+}
+
+// Prefix 第一个占位符之前的部分，可作为 Refresher 加载器的前缀
+func (k *KeySpace) Prefix() string {
+	return Namespace() + ":" + k.literals[0]
+}
+
+// Pattern SCAN 使用的匹配模式，占位符替换为 *，整数占位符替换为 [0-9]*
+// 模式只能缩小范围，仍可能匹配其他键空间的键，需要再用 Parse 过滤
+func (k *KeySpace) Pattern() string {
+	var b strings.Builder
+	b.WriteString(escapeGlob(Namespace() + ":"))
+	for i, literal := range k.literals {
+		b.WriteString(escapeGlob(literal))
+		if i < len(k.params) {
+			if k.numeric[i] {
+				b.WriteString("[0-9]")
+			}
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
+
+// Parse 从完整的键中解析占位符的值，键不属于该键空间时返回 false
+func (k *KeySpace) Parse(key string) ([]string, bool) {
+	rest := strings.TrimPrefix(key, Namespace()+":")
+	if len(rest) == len(key) || !strings.HasPrefix(rest, k.literals[0]) {
+		return nil, false
+	}
+	rest = rest[len(k.literals[0]):]
+
+	values := make([]string, 0, len(k.params))
+	for _, literal := range k.literals[1:] {
+		end := len(rest)
+		if literal != "" {
+			if end = strings.Index(rest, literal); end < 0 {
+				return nil, false
+			}
+		}
+		if end == 0 || (k.numeric[len(values)] && !isDigits(rest[:end])) {
+			return nil, false
+		}
+		values = append(values, rest[:end])
+		rest = rest[end+len(literal):]
+	}
+
+	if rest != "" {
+		return nil, false
+	}
+	return values, true
+}
+
+// Get 读取键空间中的数据
+func (k *KeySpace) Get(c Cache, dest interface{}, args ...interface{}) error {
+	return c.Get(k.Key(args...), dest)
+}
+
+// Set 按键空间的过期时间、编解码器和标签写入数据
+func (k *KeySpace) Set(c Cache, value interface{}, args ...interface{}) error {
+	entry, err := k.encode(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(k.Key(args...), entry, k.ttl, k.Tags()...)
+}
+
+// Delete 删除键空间中的数据
+func (k *KeySpace) Delete(c Cache, args ...interface{}) error {
+	return c.Delete(k.Key(args...))
+}
+
+// encode 使用键空间的编解码器预先编码，缓存实例写入时原样保存
+// 解码时根据版本头选择格式，因此读取不受缓存实例编解码器的影响
+func (k *KeySpace) encode(value interface{}) (interface{}, error) {
+	return encodeWith(k.codec, value)
+}
+
+// parseKeyTemplate 将模板切分为字面量和占位符
+func parseKeyTemplate(template string) (literals []string, params []string, numeric []bool) {
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			return append(literals, rest), params, numeric
+		}
+		literals = append(literals, rest[:start])
+		name := strings.TrimSuffix(rest[start+1:end], ":int")
+		params = append(params, name)
+		numeric = append(numeric, len(name) < end-start-1)
+		rest = rest[end+1:]
+	}
+}
+
+// isDigits 是否为非空的十进制数字串
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// KeySpaceStats 键空间统计信息
+type KeySpaceStats struct {
+	Template string `json:"template"`
+	Pattern  string `json:"pattern"`
+	TTL      string `json:"ttl"`
+	Codec    string `json:"codec"`
+	Count    int64  `json:"count"` // 键数量，Exact 为 false 时为估算值
+	Exact    bool   `json:"exact"` // 是否完整遍历
+}
+
+// StatKeySpaces 统计各键空间的键数量
+//
+// 基于 Redis 的缓存通过 SCAN 遍历并用 Parse 过滤其他键空间的键，每个节点最多遍历约 maxScan 个键，
+// 未遍历完时按已遍历部分的命中比例和 DBSIZE 估算；本地缓存直接精确统计
+func StatKeySpaces(ctx context.Context, c Cache, maxScan int) ([]KeySpaceStats, error) {
+	spaces := KeySpaces()
+	stats := make([]KeySpaceStats, len(spaces))
+
+	for i, k := range spaces {
+		stats[i] = KeySpaceStats{
+			Template: k.template,
+			Pattern:  k.Pattern(),
+			TTL:      k.ttl.String(),
+			Codec:    codecName(k.codec),
+		}
+
+		var err error
+		switch cc := c.(type) {
+		case redisClientProvider:
+			if client := cc.redisClient(); client != nil {
+				stats[i].Count, stats[i].Exact, err = estimateKeys(ctx, client, k, maxScan)
+			}
+		case *LocalCache:
+			stats[i].Count, stats[i].Exact = cc.countKeys(k), true
+		}
+		if err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// estimateKeys 通过 SCAN 统计属于键空间的键数量，集群模式下汇总所有主节点
+func estimateKeys(ctx context.Context, client redis.UniversalClient, k *KeySpace, maxScan int) (int64, bool, error) {
+	var (
+		mu    sync.Mutex
+		total int64
+		exact = true
+	)
+
+	err := redisRepo.ForEachNode(ctx, client, func(ctx context.Context, node redis.UniversalClient) error {
+		var (
+			cursor  uint64
+			matched int64
+			scanned int64
+		)
+		for {
+			keys, next, err := node.Scan(ctx, cursor, k.Pattern(), scanBatchSize).Result()
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if _, ok := k.Parse(key); ok {
+					matched++
+				}
+			}
+			scanned += scanBatchSize
+			cursor = next
+
+			if cursor == 0 {
+				mu.Lock()
+				total += matched
+				mu.Unlock()
+				return nil
+			}
+
+			if maxScan > 0 && scanned >= int64(maxScan) {
+				size, err := node.DBSize(ctx).Result()
+				if err != nil {
+					return err
+				}
+
+				mu.Lock()
+				total += matched * size / scanned
+				exact = false
+				mu.Unlock()
+				return nil
+			}
+		}
+	})
+	return total, exact, err
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestKeySpaceKeyAndParse(t *testing.T) {
+	k := NewKeySpace("test:user:{id}:order:{no}", WithKeyTTL(time.Minute), WithKeyTags("test"))
+	ns := Namespace() + ":"
+
+	key := k.Key(7, "a1")
+	if key != ns+"test:user:7:order:a1" {
+		t.Fatalf("unexpected key %q", key)
+	}
+	if k.Prefix() != ns+"test:user:" {
+		t.Fatalf("unexpected prefix %q", k.Prefix())
+	}
+	if k.Pattern() != ns+"test:user:*:order:*" {
+		t.Fatalf("unexpected pattern %q", k.Pattern())
+	}
+	if tags := k.Tags(); len(tags) != 1 || tags[0] != ns+"test" {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+
+	args, ok := k.Parse(key)
+	if !ok || len(args) != 2 || args[0] != "7" || args[1] != "a1" {
+		t.Fatalf("parse %q = %v, %v", key, args, ok)
+	}
+	for _, bad := range []string{"test:user:7:order:a1", ns + "test:user:7", ns + "test:user::order:a1", ns + "other:7"} {
+		if _, ok := k.Parse(bad); ok {
+			t.Errorf("parse %q should fail", bad)
+		}
+	}
+}
+
+func TestKeySpaceCodecAndStats(t *testing.T) {
+	c := NewLocalCache(100, time.Minute)
+	k := NewKeySpace("test:codec:{id}", WithKeyCodec(NewCodec(FormatMsgpack)))
+
+	type item struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	for i := int64(1); i <= 3; i++ {
+		if err := k.Set(c, &item{ID: i, Name: "n"}, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 缓存实例使用 JSON，键空间使用 msgpack，按版本头解码
+	var got item
+	if err := k.Get(c, &got, 2); err != nil || got.ID != 2 || got.Name != "n" {
+		t.Fatalf("get = %+v, %v", got, err)
+	}
+
+	stats, err := StatKeySpaces(context.Background(), c, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.Template == "test:codec:{id}" {
+			if s.Count != 3 || !s.Exact || s.Codec != "msgpack" {
+				t.Fatalf("unexpected stats %+v", s)
+			}
+			return
+		}
+	}
+	t.Fatal("key space not listed")
+}
+
+func TestKeySpaceNumericPlaceholder(t *testing.T) {
+	list := NewKeySpace("test:overlap:list")
+	items := NewKeySpace("test:overlap:{id:int}")
+	ns := Namespace() + ":"
+
+	if items.Pattern() != ns+"test:overlap:[0-9]*" {
+		t.Fatalf("unexpected pattern %q", items.Pattern())
+	}
+	if args, ok := items.Parse(items.Key(42)); !ok || args[0] != "42" {
+		t.Fatalf("parse = %v, %v", args, ok)
+	}
+	for _, bad := range []string{list.Key(), ns + "test:overlap:1x", ns + "test:overlap:-1"} {
+		if _, ok := items.Parse(bad); ok {
+			t.Errorf("parse %q should fail", bad)
+		}
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	for _, c := range []Cache{NewLocalCache(100, time.Minute), NewRedisCache(client)} {
+		_ = list.Set(c, []int{1, 2})
+		_ = items.Set(c, "a", 1)
+		_ = items.Set(c, "b", 2)
+		_ = c.Set(ns+"test:overlap:1x", "c", time.Minute)
+
+		stats, err := StatKeySpaces(context.Background(), c, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := make(map[string]int64)
+		for _, s := range stats {
+			counts[s.Template] = s.Count
+		}
+		if counts[list.Template()] != 1 || counts[items.Template()] != 2 {
+			t.Fatalf("%T: list = %d, items = %d, want 1 and 2", c, counts[list.Template()], counts[items.Template()])
+		}
+	}
+}
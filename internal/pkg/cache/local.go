@@ -1,29 +1,22 @@
 package cache
 
 import (
-	"container/list"
-	"encoding/json"
-	"sync"
+	"bytes"
+	"strconv"
 	"time"
 )
 
+// emptyValue 空值标记（防止缓存穿透）
+var emptyValue = []byte("<empty>")
+
 // LocalCache 本地内存缓存实现
+// 基于分片的 Store，值以序列化后的字节存储，Get 时只需一次反序列化
 type LocalCache struct {
-	capacity  int                      // 缓存容量
-	ttl       time.Duration            // 过期时间
-	mu        sync.RWMutex             // 读写锁
-	cache     map[string]*list.Element // 缓存映射
-	evictList *list.List               // LRU链表
-}
-
-// cacheEntry 缓存条目
-type cacheEntry struct {
-	key       string
-	value     interface{}
-	expiresAt time.Time
+	store *Store[[]byte]
+	codec Codec
 }
 
-// NewLocalCache 创建本地缓存实例
+// NotFoundError 键不存在错误
 type NotFoundError struct {
 	message string
 }
@@ -35,132 +28,231 @@ func (e *NotFoundError) Error() string {
 // ErrKeyNotFound 键不存在错误
 var ErrKeyNotFound = &NotFoundError{"key not found"}
 
-// NewLocalCache 创建本地缓存实例
-func NewLocalCache(capacity int, ttl time.Duration) *LocalCache {
-	return &LocalCache{
-		capacity:  capacity,
-		ttl:       ttl,
-		cache:     make(map[string]*list.Element),
-		evictList: list.New(),
+// LocalOption 本地缓存配置项
+type LocalOption func(*localOption)
+
+type localOption struct {
+	codec Codec
+}
+
+// WithLocalCodec 设置编解码器，默认 JSON
+func WithLocalCodec(codec Codec) LocalOption {
+	return func(opt *localOption) {
+		opt.codec = codec
 	}
 }
 
-// Get 从缓存获取数据
-func (l *LocalCache) Get(key string, dest interface{}) error {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+// NewLocalCache 创建本地缓存实例（LRU 淘汰）
+func NewLocalCache(capacity int, ttl time.Duration, options ...LocalOption) *LocalCache {
+	return NewLocalCacheWithConfig(StoreConfig[[]byte]{
+		MaxEntries: capacity,
+		DefaultTTL: ttl,
+		Policy:     PolicyLRU,
+	}, options...)
+}
 
-	element, exists := l.cache[key]
-	if !exists {
-		return ErrKeyNotFound
+// NewLocalCacheWithConfig 按配置创建本地缓存实例，可选择淘汰策略和内存上限
+func NewLocalCacheWithConfig(config StoreConfig[[]byte], options ...LocalOption) *LocalCache {
+	opt := &localOption{
+		codec: JSON,
+	}
+	for _, f := range options {
+		f(opt)
 	}
 
-	entry := element.Value.(*cacheEntry)
-	
-	// 检查是否过期
-	if time.Now().After(entry.expiresAt) {
-		// 过期则删除
-		l.mu.RUnlock()
-		l.mu.Lock()
-		l.removeElement(element)
-		l.mu.Unlock()
-		l.mu.RLock()
+	if config.Sizer == nil {
+		config.Sizer = func(data []byte) int { return len(data) }
+	}
+
+	return &LocalCache{
+		store: NewStore(config),
+		codec: opt.codec,
+	}
+}
+
+// Get 从缓存获取数据
+func (l *LocalCache) Get(key string, dest interface{}) error {
+	data, ok := l.getRaw(key)
+	if !ok || bytes.Equal(data, emptyValue) {
 		return ErrKeyNotFound
 	}
 
-	// 移动到链表前端（更新LRU顺序）
-	l.evictList.MoveToFront(element)
+	return l.codec.Unmarshal(data, dest)
+}
 
-	// 反序列化数据
-	data, err := json.Marshal(entry.value)
+// Set 设置缓存数据
+func (l *LocalCache) Set(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	data, err := marshalValue(l.codec, value)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(data, dest)
+	l.setRaw(key, data, expiration, tags...)
+	return nil
 }
 
-// Set 设置缓存数据
-func (l *LocalCache) Set(key string, value interface{}, expiration time.Duration) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// 如果键已存在，更新值
-	if element, exists := l.cache[key]; exists {
-		l.evictList.MoveToFront(element)
-		entry := element.Value.(*cacheEntry)
-		entry.value = value
-		entry.expiresAt = time.Now().Add(expiration)
-		return nil
+// Delete 删除缓存数据
+func (l *LocalCache) Delete(key string) error {
+	l.store.Delete(key)
+	return nil
+}
+
+// Exists 检查键是否存在
+func (l *LocalCache) Exists(key string) (bool, error) {
+	return l.store.Exists(key), nil
+}
+
+// InvalidateTag 删除关联了指定标签的所有缓存数据
+func (l *LocalCache) InvalidateTag(tags ...string) error {
+	l.store.InvalidateTag(tags...)
+	return nil
+}
+
+// DeletePrefix 删除指定前缀的所有缓存数据
+func (l *LocalCache) DeletePrefix(prefix string) error {
+	l.store.DeletePrefix(prefix)
+	return nil
+}
+
+// MGet 批量获取数据，dest 必须为 *map[string]T
+func (l *LocalCache) MGet(keys []string, dest interface{}) error {
+	decoder, err := newMapDecoder(dest, l.codec)
+	if err != nil {
+		return err
 	}
 
-	// 检查是否需要驱逐
-	if l.evictList.Len() >= l.capacity {
-		l.evict()
+	for _, key := range keys {
+		data, ok := l.getRaw(key)
+		if !ok || bytes.Equal(data, emptyValue) {
+			continue
+		}
+		if err := decoder.decode(key, data); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// 添加新条目
-	entry := &cacheEntry{
-		key:       key,
-		value:     value,
-		expiresAt: time.Now().Add(expiration),
+// MSet 批量设置缓存数据
+func (l *LocalCache) MSet(items map[string]interface{}, expiration time.Duration, tags ...string) error {
+	for key, value := range items {
+		if err := l.Set(key, value, expiration, tags...); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	element := l.evictList.PushFront(entry)
-	l.cache[key] = element
+// Incr 原子增加计数并返回新值，已存在的键保留原过期时间
+func (l *LocalCache) Incr(key string, delta int64, expiration time.Duration) (int64, error) {
+	var (
+		result int64
+		err    error
+	)
 
-	return nil
+	l.store.update(key, expiration, true, func(old []byte, exists bool) ([]byte, bool) {
+		var n int64
+		if exists {
+			if n, err = parseCounter(old); err != nil {
+				return nil, false
+			}
+		}
+		result = n + delta
+		return []byte(strconv.FormatInt(result, 10)), true
+	})
+
+	return result, err
 }
 
-// Delete 删除缓存数据
-func (l *LocalCache) Delete(key string) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// Decr 原子减少计数并返回新值
+func (l *LocalCache) Decr(key string, delta int64, expiration time.Duration) (int64, error) {
+	return l.Incr(key, -delta, expiration)
+}
 
-	if element, exists := l.cache[key]; exists {
-		l.removeElement(element)
+// SetNX 键不存在时设置缓存数据
+func (l *LocalCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := marshalValue(l.codec, value)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	return l.store.update(key, expiration, false, func(_ []byte, exists bool) ([]byte, bool) {
+		return data, !exists
+	}), nil
 }
 
-// Exists 检查键是否存在
-func (l *LocalCache) Exists(key string) (bool, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-
-	element, exists := l.cache[key]
-	if !exists {
-		return false, nil
+// GetSet 设置新值并将旧值写入 dest
+func (l *LocalCache) GetSet(key string, value interface{}, expiration time.Duration, dest interface{}) error {
+	data, err := marshalValue(l.codec, value)
+	if err != nil {
+		return err
 	}
 
-	entry := element.Value.(*cacheEntry)
-	
-	// 检查是否过期
-	if time.Now().After(entry.expiresAt) {
-		// 过期则删除
-		l.mu.RUnlock()
-		l.mu.Lock()
-		l.removeElement(element)
-		l.mu.Unlock()
-		l.mu.RLock()
-		return false, nil
+	var old []byte
+	l.store.update(key, expiration, false, func(prev []byte, exists bool) ([]byte, bool) {
+		if exists {
+			old = prev
+		}
+		return data, true
+	})
+
+	if old == nil || bytes.Equal(old, emptyValue) {
+		return ErrKeyNotFound
 	}
+	return l.codec.Unmarshal(old, dest)
+}
+
+// Clear 清空全部缓存
+func (l *LocalCache) Clear() {
+	l.store.Clear()
+}
+
+// Stats 获取命中、淘汰等统计信息
+func (l *LocalCache) Stats() StoreStats {
+	return l.store.Stats()
+}
 
-	return true, nil
+// Close 停止过期清理协程
+func (l *LocalCache) Close() error {
+	l.store.Close()
+	return nil
+}
+
+// getRaw 获取序列化后的原始数据
+func (l *LocalCache) getRaw(key string) ([]byte, bool) {
+	return l.store.Get(key)
+}
+
+// setRaw 直接写入序列化后的原始数据，避免重复序列化
+func (l *LocalCache) setRaw(key string, data []byte, expiration time.Duration, tags ...string) {
+	l.store.Set(key, data, expiration, tags...)
 }
 
-// evict 驱逐最久未使用的条目
-func (l *LocalCache) evict() {
-	element := l.evictList.Back()
-	if element != nil {
-		l.removeElement(element)
+// marshalValue 序列化缓存值，nil 使用空值标记
+func marshalValue(codec Codec, value interface{}) ([]byte, error) {
+	if value == nil {
+		return emptyValue, nil
 	}
+	if data, ok := value.(encodedValue); ok {
+		return data, nil
+	}
+	return codec.Marshal(value)
+}
+
+// valueCodec 返回使用的编解码器
+func (l *LocalCache) valueCodec() Codec {
+	return l.codec
 }
 
-// removeElement 删除指定元素
-func (l *LocalCache) removeElement(element *list.Element) {
-	l.evictList.Remove(element)
-	entry := element.Value.(*cacheEntry)
-	delete(l.cache, entry.key)
-}
\ No newline at end of file
+// countKeys 统计属于指定键空间的未过期键数量
+func (l *LocalCache) countKeys(k *KeySpace) int64 {
+	var n int64
+	now := time.Now()
+	l.store.Range(func(key string, _ []byte, expiresAt time.Time, _ []string) bool {
+		if _, ok := k.Parse(key); ok && (expiresAt.IsZero() || expiresAt.After(now)) {
+			n++
+		}
+		return true
+	})
+	return n
+}
@@ -1,12 +1,15 @@
 package cache
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"time"
 
+	"gin-example/internal/metrics"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 // MultiLevelCache 多级缓存实现
@@ -14,25 +17,156 @@ type MultiLevelCache struct {
 	l1Cache     *LocalCache     // L1缓存（本地内存）
 	l2Cache     *RedisCache     // L2缓存（Redis）
 	ctx         context.Context
+	cancel      context.CancelFunc
+	logger      *zap.Logger
+
+	// L1跨实例失效
+	instanceID          string        // 当前实例ID
+	invalidationChannel string        // 失效广播频道，为空表示不广播
+	pubsub              *redis.PubSub // 失效消息订阅
+	subscribeDone       chan struct{} // 订阅协程退出信号
+
+	// L2健康检查与降级
+	name             string        // 缓存名称，用于监控指标
+	health           *l2Health     // L2健康状态
+	degradeThreshold int           // 连续错误次数达到该值后降级为L1-only
+	probeInterval    time.Duration // 降级期间探测Redis的间隔
+	probeDone        chan struct{} // 探测协程退出信号
+}
+
+// MultiLevelOption 多级缓存配置项
+type MultiLevelOption func(*multiLevelOption)
+
+type multiLevelOption struct {
+	logger              *zap.Logger
+	invalidationChannel string
+	name                string
+	degradeThreshold    int
+	probeInterval       time.Duration
+	codec               Codec
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *zap.Logger) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.logger = logger
+	}
+}
+
+// WithInvalidationChannel 设置L1失效广播频道，传空字符串关闭跨实例失效
+func WithInvalidationChannel(channel string) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.invalidationChannel = channel
+	}
+}
+
+// WithName 设置缓存名称，用于监控指标和健康检查
+func WithName(name string) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.name = name
+	}
+}
+
+// WithDegradeThreshold 设置Redis连续错误多少次后降级为L1-only模式
+func WithDegradeThreshold(threshold int) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.degradeThreshold = threshold
+	}
+}
+
+// WithProbeInterval 设置降级期间探测Redis恢复的间隔
+func WithProbeInterval(interval time.Duration) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.probeInterval = interval
+	}
+}
+
+// WithCodec 设置L1和L2共用的编解码器，默认 JSON
+func WithCodec(codec Codec) MultiLevelOption {
+	return func(opt *multiLevelOption) {
+		opt.codec = codec
+	}
 }
 
 // NewMultiLevelCache 创建多级缓存实例
-func NewMultiLevelCache(redisClient *redis.Client) *MultiLevelCache {
+func NewMultiLevelCache(redisClient redis.UniversalClient, options ...MultiLevelOption) *MultiLevelCache {
 	// 检查Redis客户端是否为空
 	if redisClient == nil {
 		return nil
 	}
 	
-	redisCache := NewRedisCache(redisClient)
+	opt := &multiLevelOption{
+		logger:              zap.NewNop(),
+		invalidationChannel: DefaultInvalidationChannel,
+		name:                "multi-level",
+		degradeThreshold:    5,
+		probeInterval:       5 * time.Second,
+		codec:               JSON,
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	// L2回填到L1的是原始数据，两级缓存必须使用相同的编解码器
+	redisCache := NewRedisCache(redisClient, WithRedisCodec(opt.codec))
 	if redisCache == nil {
 		return nil
 	}
-	
-	return &MultiLevelCache{
-		l1Cache: NewLocalCache(1000, 5*time.Minute), // L1缓存：最多1000个条目，5分钟过期
-		l2Cache: redisCache,
-		ctx:     context.Background(),
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &MultiLevelCache{
+		l1Cache:             NewLocalCache(1000, 5*time.Minute, WithLocalCodec(opt.codec)), // L1缓存：最多1000个条目，5分钟过期
+		l2Cache:             redisCache,
+		ctx:                 ctx,
+		cancel:              cancel,
+		logger:              opt.logger,
+		instanceID:          newInstanceID(),
+		invalidationChannel: opt.invalidationChannel,
+		name:                opt.name,
+		health:              newL2Health(),
+		degradeThreshold:    opt.degradeThreshold,
+		probeInterval:       opt.probeInterval,
+		probeDone:           make(chan struct{}),
+	}
+
+	// 启动时Redis不可用，直接进入L1-only模式，由后台探测负责恢复
+	pingCtx, pingCancel := context.WithTimeout(ctx, time.Second)
+	if err := redisClient.Ping(pingCtx).Err(); err != nil {
+		m.health.mu.Lock()
+		m.health.lastError = err.Error()
+		m.degradeLocked(err)
+		m.health.mu.Unlock()
+	} else {
+		metrics.SetCacheDegraded(m.name, false)
+	}
+	pingCancel()
+
+	go m.probeL2()
+
+	// 订阅其他实例的失效消息
+	if m.invalidationChannel != "" {
+		m.pubsub = redisClient.Subscribe(ctx, m.invalidationChannel)
+		m.subscribeDone = make(chan struct{})
+		go m.subscribeInvalidation()
+	}
+
+	return m
+}
+
+// Close 停止失效消息订阅、Redis探测和L1过期清理
+func (m *MultiLevelCache) Close() error {
+	m.cancel()
+	<-m.probeDone
+	_ = m.l1Cache.Close()
+
+	if m.pubsub == nil {
+		return nil
 	}
+
+	err := m.pubsub.Close()
+	<-m.subscribeDone
+	return err
 }
 
 // Get 从缓存获取数据（先查L1，再查L2）
@@ -43,53 +177,75 @@ func (m *MultiLevelCache) Get(key string, dest interface{}) error {
 	}
 	
 	// 先从L1缓存获取
-	if err := m.l1Cache.Get(key, dest); err == nil {
-		return nil // L1缓存命中
+	if data, ok := m.l1Cache.getRaw(key); ok {
+		// L1中的空值标记直接视为未命中，不再访问L2
+		if bytes.Equal(data, emptyValue) {
+			return ErrKeyNotFound
+		}
+		return m.l2Cache.codec.Unmarshal(data, dest) // L1缓存命中
+	}
+
+	// 降级模式下仅使用L1缓存
+	if !m.l2Available() {
+		return ErrKeyNotFound
 	}
 
 	// L1未命中，从L2缓存获取
-	val, err := m.l2Cache.client.Get(m.ctx, key).Result()
+	val, err := m.l2Cache.client.Get(m.ctx, key).Bytes()
+	m.recordL2Result(err)
 	if err != nil {
-		if err == redis.Nil {
-			return ErrKeyNotFound
-		}
-		return err // L2也未命中或出错
+		// L2未命中或出错，均视为未命中，由调用方回源
+		return ErrKeyNotFound
 	}
 
 	// 检查是否是空值标记（防止缓存穿透）
-	if val == "<empty>" {
+	if bytes.Equal(val, emptyValue) {
 		// 同时在L1缓存中存储空值标记
-		m.l1Cache.Set(key, "<empty>", time.Minute)
+		m.l1Cache.setRaw(key, emptyValue, time.Minute)
 		return ErrKeyNotFound
 	}
 
 	// 反序列化数据
-	if err := json.Unmarshal([]byte(val), dest); err != nil {
+	if err := m.l2Cache.codec.Unmarshal(val, dest); err != nil {
 		return err
 	}
 
-	// L2命中，将数据写入L1缓存
-	m.l1Cache.Set(key, val, 1*time.Minute) // L1缓存时间短一些
+	// L2命中，将原始数据写入L1缓存，避免重复序列化
+	m.l1Cache.setRaw(key, val, 1*time.Minute) // L1缓存时间短一些
 
 	return nil
 }
 
 // Set 设置缓存数据（同时写入L1和L2）
-func (m *MultiLevelCache) Set(key string, value interface{}, expiration time.Duration) error {
+func (m *MultiLevelCache) Set(key string, value interface{}, expiration time.Duration, tags ...string) error {
 	// 检查缓存实例是否为空
 	if m.l1Cache == nil || m.l2Cache == nil {
 		return errors.New("cache instance is nil")
 	}
 	
+	// 降级模式下仅写入L1缓存，恢复后删除L2中的旧值
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, key)
+		return m.l1Cache.Set(key, value, expiration, tags...)
+	}
+
 	// 同时写入L1和L2缓存
 	// 注意：这里我们不直接返回错误，因为即使一个缓存层失败，另一个可能成功
-	err1 := m.l1Cache.Set(key, value, expiration)
-	err2 := m.l2Cache.Set(key, value, expiration)
+	err1 := m.l1Cache.Set(key, value, expiration, tags...)
+	err2 := m.l2Cache.Set(key, value, expiration, tags...)
+	m.recordL2Result(err2)
 	
 	// 如果两个缓存层都失败了，则返回错误
 	if err1 != nil && err2 != nil {
 		return errors.New("failed to set cache in both L1 and L2")
 	}
+
+	if err2 != nil {
+		m.recordPending(invalidateOpDelete, key)
+	} else {
+		// 通知其他实例删除旧的L1数据
+		m.publishInvalidation(invalidateOpSet, key)
+	}
 	
 	// 如果其中一个失败，记录警告但不返回错误
 	if err1 != nil {
@@ -112,14 +268,28 @@ func (m *MultiLevelCache) Delete(key string) error {
 		return errors.New("cache instance is nil")
 	}
 	
+	// 降级模式下仅删除L1缓存，L2的删除在恢复后补偿
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, key)
+		return m.l1Cache.Delete(key)
+	}
+
 	// 同时删除L1和L2缓存
 	err1 := m.l1Cache.Delete(key)
 	err2 := m.l2Cache.Delete(key)
+	m.recordL2Result(err2)
 	
 	// 如果两个缓存层都失败了，则返回错误
 	if err1 != nil && err2 != nil {
 		return errors.New("failed to delete cache in both L1 and L2")
 	}
+
+	if err2 != nil {
+		m.recordPending(invalidateOpDelete, key)
+	} else {
+		// 通知其他实例删除L1数据
+		m.publishInvalidation(invalidateOpDelete, key)
+	}
 	
 	// 如果其中一个失败，记录警告但不返回错误
 	if err1 != nil {
@@ -135,6 +305,19 @@ func (m *MultiLevelCache) Delete(key string) error {
 	return nil
 }
 
+// valueCodec 返回两级缓存共用的编解码器
+func (m *MultiLevelCache) valueCodec() Codec {
+	return m.l2Cache.codec
+}
+
+// redisClient 返回L2使用的 Redis 客户端
+func (m *MultiLevelCache) redisClient() redis.UniversalClient {
+	if m.l2Cache == nil {
+		return nil
+	}
+	return m.l2Cache.client
+}
+
 // Exists 检查键是否存在（先查L1，再查L2）
 func (m *MultiLevelCache) Exists(key string) (bool, error) {
 	// 检查缓存实例是否为空
@@ -147,6 +330,241 @@ func (m *MultiLevelCache) Exists(key string) (bool, error) {
 		return true, nil
 	}
 
+	// 降级模式下仅检查L1缓存
+	if !m.l2Available() {
+		return false, nil
+	}
+
 	// 再检查L2缓存
-	return m.l2Cache.Exists(key)
-}
\ No newline at end of file
+	exists, err := m.l2Cache.Exists(key)
+	m.recordL2Result(err)
+	if err != nil {
+		return false, nil
+	}
+	return exists, nil
+}
+
+// InvalidateTag 删除关联了指定标签的所有缓存数据（同时删除L1和L2）
+func (m *MultiLevelCache) InvalidateTag(tags ...string) error {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return errors.New("cache instance is nil")
+	}
+
+	_ = m.l1Cache.InvalidateTag(tags...)
+
+	// 降级模式下仅删除L1缓存，L2的删除在恢复后补偿
+	if !m.l2Available() {
+		m.addPending(invalidateOpTag, tags...)
+		return nil
+	}
+
+	keys, err := m.l2Cache.invalidateTags(tags...)
+	m.recordL2Result(err)
+
+	// 从L2回填到L1的数据不携带标签，按L2标签集合中的键再删除一次
+	for _, key := range keys {
+		_ = m.l1Cache.Delete(key)
+	}
+
+	if err != nil {
+		m.recordPending(invalidateOpTag, tags...)
+		return nil
+	}
+
+	// 通知其他实例删除L1数据
+	m.publishInvalidation(invalidateOpDelete, keys...)
+	m.publishInvalidation(invalidateOpTag, tags...)
+
+	return nil
+}
+
+// DeletePrefix 删除指定前缀的所有缓存数据（同时删除L1和L2）
+func (m *MultiLevelCache) DeletePrefix(prefix string) error {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return errors.New("cache instance is nil")
+	}
+
+	_ = m.l1Cache.DeletePrefix(prefix)
+
+	// 降级模式下仅删除L1缓存，L2的删除在恢复后补偿
+	if !m.l2Available() {
+		m.addPending(invalidateOpPrefix, prefix)
+		return nil
+	}
+
+	err := m.l2Cache.DeletePrefix(prefix)
+	m.recordL2Result(err)
+	if err != nil {
+		m.recordPending(invalidateOpPrefix, prefix)
+		return nil
+	}
+
+	// 通知其他实例删除L1数据
+	m.publishInvalidation(invalidateOpPrefix, prefix)
+
+	return nil
+}
+
+// MGet 批量获取数据（先查L1，未命中的键通过 pipeline 从L2获取并回填L1）
+func (m *MultiLevelCache) MGet(keys []string, dest interface{}) error {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return errors.New("cache instance is nil")
+	}
+
+	decoder, err := newMapDecoder(dest, m.l2Cache.codec)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string][]byte, len(keys))
+	var missing []string
+	for _, key := range keys {
+		if data, ok := m.l1Cache.getRaw(key); ok {
+			values[key] = data
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	// 降级模式下仅使用L1缓存
+	if len(missing) > 0 && m.l2Available() {
+		l2Values, err := m.l2Cache.mgetRaw(missing)
+		m.recordL2Result(err)
+		if err == nil {
+			for key, data := range l2Values {
+				values[key] = data
+				// L2命中，将原始数据（包括空值标记）回填到L1缓存
+				m.l1Cache.setRaw(key, data, 1*time.Minute)
+			}
+		}
+	}
+
+	for key, data := range values {
+		if bytes.Equal(data, emptyValue) {
+			continue
+		}
+		if err := decoder.decode(key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MSet 批量设置缓存数据（同时写入L1和L2）
+func (m *MultiLevelCache) MSet(items map[string]interface{}, expiration time.Duration, tags ...string) error {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return errors.New("cache instance is nil")
+	}
+
+	if err := m.l1Cache.MSet(items, expiration, tags...); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	// 降级模式下仅写入L1缓存，恢复后删除L2中的旧值
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, keys...)
+		return nil
+	}
+
+	err := m.l2Cache.MSet(items, expiration, tags...)
+	m.recordL2Result(err)
+	if err != nil {
+		m.recordPending(invalidateOpDelete, keys...)
+		return nil
+	}
+
+	// 通知其他实例删除旧的L1数据
+	m.publishInvalidation(invalidateOpSet, keys...)
+
+	return nil
+}
+
+// Incr 原子增加计数并返回新值（以L2为准，降级模式下使用L1计数）
+func (m *MultiLevelCache) Incr(key string, delta int64, expiration time.Duration) (int64, error) {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return 0, errors.New("cache instance is nil")
+	}
+
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, key)
+		return m.l1Cache.Incr(key, delta, expiration)
+	}
+
+	n, err := m.l2Cache.Incr(key, delta, expiration)
+	m.recordL2Result(err)
+	if err != nil {
+		return 0, err
+	}
+
+	// 计数以L2为准，删除各实例L1中的旧值
+	_ = m.l1Cache.Delete(key)
+	m.publishInvalidation(invalidateOpSet, key)
+
+	return n, nil
+}
+
+// Decr 原子减少计数并返回新值
+func (m *MultiLevelCache) Decr(key string, delta int64, expiration time.Duration) (int64, error) {
+	return m.Incr(key, -delta, expiration)
+}
+
+// SetNX 键不存在时设置缓存数据（以L2为准，降级模式下使用L1）
+func (m *MultiLevelCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return false, errors.New("cache instance is nil")
+	}
+
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, key)
+		return m.l1Cache.SetNX(key, value, expiration)
+	}
+
+	ok, err := m.l2Cache.SetNX(key, value, expiration)
+	m.recordL2Result(err)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	_ = m.l1Cache.Set(key, value, expiration)
+	m.publishInvalidation(invalidateOpSet, key)
+
+	return true, nil
+}
+
+// GetSet 设置新值并将旧值写入 dest（以L2为准，降级模式下使用L1）
+func (m *MultiLevelCache) GetSet(key string, value interface{}, expiration time.Duration, dest interface{}) error {
+	// 检查缓存实例是否为空
+	if m.l1Cache == nil || m.l2Cache == nil {
+		return errors.New("cache instance is nil")
+	}
+
+	if !m.l2Available() {
+		m.addPending(invalidateOpDelete, key)
+		return m.l1Cache.GetSet(key, value, expiration, dest)
+	}
+
+	data, old, err := m.l2Cache.getSetRaw(key, value, expiration)
+	m.recordL2Result(err)
+	if err != nil {
+		return err
+	}
+
+	m.l1Cache.setRaw(key, data, expiration)
+	m.publishInvalidation(invalidateOpSet, key)
+
+	if old == nil || bytes.Equal(old, emptyValue) {
+		return ErrKeyNotFound
+	}
+	return m.l2Cache.codec.Unmarshal(old, dest)
+}
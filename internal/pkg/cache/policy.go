@@ -0,0 +1,220 @@
+package cache
+
+import "container/heap"
+
+// evictionPolicy 分片内的淘汰策略，所有方法均在持有分片锁时调用
+type evictionPolicy[V any] interface {
+	// add 新增条目
+	add(entry *storeEntry[V])
+	// access 条目被访问或更新
+	access(entry *storeEntry[V])
+	// remove 条目被删除
+	remove(entry *storeEntry[V])
+	// victim 返回下一个待淘汰的条目
+	victim() *storeEntry[V]
+	// reset 清空
+	reset()
+}
+
+// lruPolicy 基于侵入式双向链表的 LRU
+type lruPolicy[V any] struct {
+	head, tail *storeEntry[V] // head 为最近使用，tail 为最久未使用
+}
+
+func newLRUPolicy[V any]() *lruPolicy[V] {
+	return &lruPolicy[V]{}
+}
+
+func (p *lruPolicy[V]) add(entry *storeEntry[V]) {
+	entry.prev = nil
+	entry.next = p.head
+	if p.head != nil {
+		p.head.prev = entry
+	}
+	p.head = entry
+	if p.tail == nil {
+		p.tail = entry
+	}
+}
+
+func (p *lruPolicy[V]) access(entry *storeEntry[V]) {
+	if p.head == entry {
+		return
+	}
+	p.remove(entry)
+	p.add(entry)
+}
+
+func (p *lruPolicy[V]) remove(entry *storeEntry[V]) {
+	if entry.prev != nil {
+		entry.prev.next = entry.next
+	} else if p.head == entry {
+		p.head = entry.next
+	}
+
+	if entry.next != nil {
+		entry.next.prev = entry.prev
+	} else if p.tail == entry {
+		p.tail = entry.prev
+	}
+
+	entry.prev, entry.next = nil, nil
+}
+
+func (p *lruPolicy[V]) victim() *storeEntry[V] {
+	return p.tail
+}
+
+func (p *lruPolicy[V]) reset() {
+	p.head, p.tail = nil, nil
+}
+
+// lfuPolicy 基于最小堆的 LFU，访问频率相同时淘汰最久未访问的条目
+type lfuPolicy[V any] struct {
+	entries lfuHeap[V]
+	clock   uint64
+}
+
+func newLFUPolicy[V any]() *lfuPolicy[V] {
+	return &lfuPolicy[V]{}
+}
+
+func (p *lfuPolicy[V]) add(entry *storeEntry[V]) {
+	p.clock++
+	entry.freq = 1
+	entry.tick = p.clock
+	heap.Push(&p.entries, entry)
+}
+
+func (p *lfuPolicy[V]) access(entry *storeEntry[V]) {
+	p.clock++
+	if entry.freq < ^uint32(0) {
+		entry.freq++
+	}
+	entry.tick = p.clock
+	heap.Fix(&p.entries, entry.heapIndex)
+}
+
+func (p *lfuPolicy[V]) remove(entry *storeEntry[V]) {
+	if entry.heapIndex < 0 || entry.heapIndex >= len(p.entries) || p.entries[entry.heapIndex] != entry {
+		return
+	}
+	heap.Remove(&p.entries, entry.heapIndex)
+}
+
+func (p *lfuPolicy[V]) victim() *storeEntry[V] {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[0]
+}
+
+func (p *lfuPolicy[V]) reset() {
+	p.entries = nil
+}
+
+// lfuHeap 按 (freq, tick) 排序的最小堆
+type lfuHeap[V any] []*storeEntry[V]
+
+func (h lfuHeap[V]) Len() int { return len(h) }
+
+func (h lfuHeap[V]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+
+func (h lfuHeap[V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *lfuHeap[V]) Push(x any) {
+	entry := x.(*storeEntry[V])
+	entry.heapIndex = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *lfuHeap[V]) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.heapIndex = -1
+	*h = old[:n-1]
+	return entry
+}
+
+// countMinSketch TinyLFU 使用的频率估算器（4行计数器，定期减半实现老化）
+type countMinSketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+
+	width := nextPowerOfTwo(capacity * 4)
+	s := &countMinSketch{
+		mask:    uint64(width - 1),
+		resetAt: capacity * 10,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// increment 记录一次访问
+func (s *countMinSketch) increment(hash uint64) {
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.age()
+	}
+}
+
+// estimate 估算访问频率
+func (s *countMinSketch) estimate(hash uint64) uint8 {
+	min := uint8(15)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(hash, i)]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// admit 候选条目的访问频率高于待淘汰条目时允许写入
+func (s *countMinSketch) admit(candidate, victim uint64) bool {
+	return s.estimate(candidate) > s.estimate(victim)
+}
+
+// age 所有计数器减半，使频率估算偏向近期访问
+func (s *countMinSketch) age() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *countMinSketch) index(hash uint64, row int) uint64 {
+	// 由一个64位哈希派生出多个独立哈希
+	h := hash*(uint64(row)*0x9E3779B97F4A7C15+0xBF58476D1CE4E5B9) + uint64(row)
+	h ^= h >> 31
+	return h & s.mask
+}
@@ -1,17 +1,35 @@
 package cache
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"strings"
 	"time"
 	"sync"
 
+	redisRepo "gin-example/internal/repository/redis"
+
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	// tagKeyPrefix 标签集合的键前缀，集合成员为关联的缓存键
+	tagKeyPrefix = "cache:tag:"
+
+	// scanBatchSize SCAN 每批次扫描数量
+	scanBatchSize = 500
+)
+
+var (
+	// tagAddScript 将键加入标签集合，集合过期时间只延长不缩短
+	tagAddScript = redisRepo.GetScript("cache_tag_add")
+	// incrScript 增加计数，键没有过期时间时（新建）设置过期时间
+	incrScript = redisRepo.GetScript("cache_incr")
+)
+
 // RedisCache Redis缓存实现
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ctx    context.Context
 	// 添加互斥锁，防止缓存击穿
 	mu     sync.RWMutex
@@ -19,20 +37,44 @@ type RedisCache struct {
 	emptyExpiration time.Duration
 	// 正常缓存的默认过期时间
 	defaultExpiration time.Duration
+	// 编解码器
+	codec Codec
+}
+
+// RedisOption Redis缓存配置项
+type RedisOption func(*redisOption)
+
+type redisOption struct {
+	codec Codec
+}
+
+// WithRedisCodec 设置编解码器，默认 JSON
+func WithRedisCodec(codec Codec) RedisOption {
+	return func(opt *redisOption) {
+		opt.codec = codec
+	}
 }
 
 // NewRedisCache 创建Redis缓存实例
-func NewRedisCache(client *redis.Client) *RedisCache {
+func NewRedisCache(client redis.UniversalClient, options ...RedisOption) *RedisCache {
 	// 检查客户端是否为空
 	if client == nil {
 		return nil
 	}
+
+	opt := &redisOption{
+		codec: JSON,
+	}
+	for _, f := range options {
+		f(opt)
+	}
 	
 	return &RedisCache{
 		client: client,
 		ctx:    context.Background(),
 		emptyExpiration: time.Minute, // 空值缓存1分钟
 		defaultExpiration: time.Hour, // 默认缓存1小时
+		codec:  opt.codec,
 	}
 }
 
@@ -57,11 +99,11 @@ func (r *RedisCache) Get(key string, dest interface{}) error {
 	}
 
 	// 检查是否是空值标记（防止缓存穿透）
-	if val == "<empty>" {
+	if val == string(emptyValue) {
 		return ErrKeyNotFound // 视为空值，让调用方从数据源获取
 	}
 
-	err = json.Unmarshal([]byte(val), dest)
+	err = r.codec.Unmarshal([]byte(val), dest)
 	if err != nil {
 		return err
 	}
@@ -69,7 +111,7 @@ func (r *RedisCache) Get(key string, dest interface{}) error {
 }
 
 // Set 设置缓存数据
-func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration) error {
+func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration, tags ...string) error {
 	// 检查客户端是否为空
 	if r.client == nil {
 		return nil
@@ -84,10 +126,10 @@ func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration
 	
 	// 检查值是否为空，防止缓存穿透
 	if value == nil {
-		data = []byte("<empty>")
+		data = emptyValue
 		expiration = r.emptyExpiration // 空值使用较短的过期时间
 	} else {
-		data, err = json.Marshal(value)
+		data, err = marshalValue(r.codec, value)
 		if err != nil {
 			return err
 		}
@@ -97,7 +139,18 @@ func (r *RedisCache) Set(key string, value interface{}, expiration time.Duration
 		}
 	}
 
-	return r.client.Set(r.ctx, key, data, expiration).Err()
+	if err := r.client.Set(r.ctx, key, data, expiration).Err(); err != nil {
+		return err
+	}
+
+	// 建立标签索引
+	for _, tag := range tags {
+		if err := tagAddScript.Run(r.ctx, r.client, []string{tagKeyPrefix + tag}, key, expiration.Milliseconds()).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Delete 删除缓存数据
@@ -128,4 +181,280 @@ func (r *RedisCache) Exists(key string) (bool, error) {
 		return false, err
 	}
 	return exists > 0, nil
-}
\ No newline at end of file
+}
+
+// InvalidateTag 删除关联了指定标签的所有缓存数据
+func (r *RedisCache) InvalidateTag(tags ...string) error {
+	_, err := r.invalidateTags(tags...)
+	return err
+}
+
+// invalidateTags 删除标签关联的缓存数据，返回被删除的键
+func (r *RedisCache) invalidateTags(tags ...string) ([]string, error) {
+	// 检查客户端是否为空
+	if r.client == nil {
+		return nil, nil
+	}
+
+	// 只访问 Redis，不持有 r.mu，避免失效期间阻塞其他读写
+	var deleted []string
+	for _, tag := range tags {
+		tagKey := tagKeyPrefix + tag
+
+		keys, err := r.client.SMembers(r.ctx, tagKey).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		if err := r.deleteKeys(r.ctx, append(keys, tagKey)); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, keys...)
+	}
+
+	return deleted, nil
+}
+
+// DeletePrefix 删除指定前缀的所有缓存数据（基于 SCAN，避免 KEYS 阻塞 Redis）
+func (r *RedisCache) DeletePrefix(prefix string) error {
+	// 检查客户端是否为空
+	if r.client == nil {
+		return nil
+	}
+
+	// SCAN 需要遍历整个键空间，不持有 r.mu，避免期间阻塞其他读写
+	match := escapeGlob(prefix) + "*"
+	return redisRepo.ForEachNode(r.ctx, r.client, func(ctx context.Context, node redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := node.Scan(ctx, cursor, match, scanBatchSize).Result()
+			if err != nil {
+				return err
+			}
+
+			if err := r.deleteKeys(ctx, keys); err != nil {
+				return err
+			}
+
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+}
+
+// deleteKeys 通过 pipeline 逐个删除键
+// 集群模式下多个键通常不在同一个 slot，不能使用多键 DEL
+func (r *RedisCache) deleteKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// MGet 批量获取数据，通过 pipeline 一次往返完成
+func (r *RedisCache) MGet(keys []string, dest interface{}) error {
+	decoder, err := newMapDecoder(dest, r.codec)
+	if err != nil {
+		return err
+	}
+
+	values, err := r.mgetRaw(keys)
+	if err != nil {
+		return err
+	}
+
+	for key, data := range values {
+		if bytes.Equal(data, emptyValue) {
+			continue
+		}
+		if err := decoder.decode(key, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mgetRaw 批量获取序列化后的原始数据（包含空值标记），不存在的键不会出现在结果中
+// 使用 pipeline 逐个 GET 而非 MGET，避免集群模式下跨 slot 报错
+func (r *RedisCache) mgetRaw(keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	if r.client == nil || len(keys) == 0 {
+		return result, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(r.ctx, key)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		result[keys[i]] = data
+	}
+	return result, nil
+}
+
+// MSet 批量设置缓存数据，通过 pipeline 一次往返完成
+func (r *RedisCache) MSet(items map[string]interface{}, expiration time.Duration, tags ...string) error {
+	// 检查客户端是否为空
+	if r.client == nil || len(items) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pipe := r.client.Pipeline()
+	for key, value := range items {
+		data, exp, err := r.encode(value, expiration)
+		if err != nil {
+			return err
+		}
+
+		pipe.Set(r.ctx, key, data, exp)
+		for _, tag := range tags {
+			// pipeline 中无法处理 NOSCRIPT，直接使用 EVAL
+			tagAddScript.Eval(r.ctx, pipe, []string{tagKeyPrefix + tag}, key, exp.Milliseconds())
+		}
+	}
+
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// Incr 原子增加计数并返回新值，键不存在时设置过期时间
+func (r *RedisCache) Incr(key string, delta int64, expiration time.Duration) (int64, error) {
+	// 检查客户端是否为空
+	if r.client == nil {
+		return 0, redis.Nil
+	}
+
+	n, err := incrScript.Run(r.ctx, r.client, []string{key}, delta, expiration.Milliseconds()).Int64()
+	if err != nil && strings.Contains(err.Error(), "not an integer") {
+		return 0, ErrNotInteger
+	}
+	return n, err
+}
+
+// Decr 原子减少计数并返回新值
+func (r *RedisCache) Decr(key string, delta int64, expiration time.Duration) (int64, error) {
+	return r.Incr(key, -delta, expiration)
+}
+
+// SetNX 键不存在时设置缓存数据
+func (r *RedisCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	// 检查客户端是否为空
+	if r.client == nil {
+		return false, nil
+	}
+
+	data, exp, err := r.encode(value, expiration)
+	if err != nil {
+		return false, err
+	}
+
+	return r.client.SetNX(r.ctx, key, data, exp).Result()
+}
+
+// GetSet 设置新值并将旧值写入 dest，通过 MULTI 保证原子性
+func (r *RedisCache) GetSet(key string, value interface{}, expiration time.Duration, dest interface{}) error {
+	// 检查客户端是否为空
+	if r.client == nil {
+		return redis.Nil
+	}
+
+	_, old, err := r.getSetRaw(key, value, expiration)
+	if err != nil {
+		return err
+	}
+	if old == nil || bytes.Equal(old, emptyValue) {
+		return ErrKeyNotFound
+	}
+	return r.codec.Unmarshal(old, dest)
+}
+
+// getSetRaw 设置新值，返回序列化后的新值和旧值（旧值不存在时为 nil）
+func (r *RedisCache) getSetRaw(key string, value interface{}, expiration time.Duration) ([]byte, []byte, error) {
+	data, exp, err := r.encode(value, expiration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var get *redis.StringCmd
+	if _, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(r.ctx, key)
+		pipe.Set(r.ctx, key, data, exp)
+		return nil
+	}); err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	old, err := get.Bytes()
+	if err == redis.Nil {
+		return data, nil, nil
+	}
+	return data, old, err
+}
+
+// encode 序列化缓存值并确定过期时间
+func (r *RedisCache) encode(value interface{}, expiration time.Duration) ([]byte, time.Duration, error) {
+	if value == nil {
+		return emptyValue, r.emptyExpiration, nil
+	}
+
+	data, err := marshalValue(r.codec, value)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expiration <= 0 {
+		expiration = r.defaultExpiration
+	}
+	return data, expiration, nil
+}
+
+// valueCodec 返回使用的编解码器
+func (r *RedisCache) valueCodec() Codec {
+	return r.codec
+}
+
+// redisClient 返回底层 Redis 客户端
+func (r *RedisCache) redisClient() redis.UniversalClient {
+	return r.client
+}
+
+// escapeGlob 转义 SCAN MATCH 中的通配符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,422 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-example/internal/metrics"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrLoaderNotFound 没有为该键注册加载器
+var ErrLoaderNotFound = errors.New("cache loader not found")
+
+// LoaderFunc 回源加载函数，返回 nil 或 ErrKeyNotFound 表示数据不存在（会缓存空值）
+type LoaderFunc func(ctx context.Context, key string) (interface{}, error)
+
+// RefreshPolicy 软/硬过期策略
+//
+//	写入后 SoftTTL 内：直接返回缓存值（按 XFetch 概率提前刷新）
+//	SoftTTL ~ HardTTL：返回旧值，同时后台执行加载器刷新
+//	超过 HardTTL：缓存失效，同步回源
+type RefreshPolicy struct {
+	SoftTTL  time.Duration // 软过期时间
+	HardTTL  time.Duration // 硬过期时间，即缓存实际过期时间
+	Beta     float64       // XFetch 系数，越大越倾向提前刷新，0 表示关闭提前刷新
+	EmptyTTL time.Duration // 空值缓存时间，默认1分钟
+	Tags     []string      // 写入缓存时关联的标签
+	Codec    Codec         // 可选的编解码器，nil 表示使用缓存实例的编解码器
+
+	// Filter 可选的布隆过滤器，以去掉前缀后的键作为元素，一定不存在时直接返回 ErrKeyNotFound
+	Filter BloomFilter
+}
+
+// refreshEnvelope 缓存中实际存储的数据，附带软过期时间和回源耗时
+// Value 使用策略或缓存实例的编解码器编码，带版本头时解码不依赖写入方的配置
+type refreshEnvelope struct {
+	Value         []byte `json:"v"`
+	SoftExpiresAt int64  `json:"s"` // 软过期时间（UnixNano）
+	Delta         int64  `json:"d"` // 上次回源耗时（纳秒），用于 XFetch
+}
+
+type registeredLoader struct {
+	prefix string
+	loader LoaderFunc
+	policy RefreshPolicy
+}
+
+// Refresher 在任意 Cache 之上提供 stale-while-revalidate 和 XFetch 提前刷新
+type Refresher struct {
+	cache          Cache
+	name           string
+	logger         *zap.Logger
+	refreshTimeout time.Duration
+
+	mu      sync.RWMutex
+	loaders []registeredLoader // 按前缀长度降序，最长前缀优先匹配
+
+	group       singleflight.Group // 合并同一键的并发回源
+	refreshing  sync.Map           // 正在后台刷新的键
+	generations sync.Map           // 标签 -> *uint64，每次通过 InvalidateTag 失效时递增
+}
+
+// RefreshOption Refresher 配置项
+type RefreshOption func(*refreshOption)
+
+type refreshOption struct {
+	name           string
+	logger         *zap.Logger
+	refreshTimeout time.Duration
+}
+
+// WithRefreshName 设置名称，用于监控指标
+func WithRefreshName(name string) RefreshOption {
+	return func(opt *refreshOption) {
+		opt.name = name
+	}
+}
+
+// WithRefreshLogger 设置日志记录器
+func WithRefreshLogger(logger *zap.Logger) RefreshOption {
+	return func(opt *refreshOption) {
+		opt.logger = logger
+	}
+}
+
+// WithRefreshTimeout 设置回源（包括后台刷新）的超时时间
+func WithRefreshTimeout(timeout time.Duration) RefreshOption {
+	return func(opt *refreshOption) {
+		opt.refreshTimeout = timeout
+	}
+}
+
+// NewRefresher 创建 Refresher 实例
+func NewRefresher(cache Cache, options ...RefreshOption) *Refresher {
+	opt := &refreshOption{
+		name:           "default",
+		logger:         zap.NewNop(),
+		refreshTimeout: 5 * time.Second,
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	return &Refresher{
+		cache:          cache,
+		name:           opt.name,
+		logger:         opt.logger,
+		refreshTimeout: opt.refreshTimeout,
+	}
+}
+
+// RegisterLoader 为指定前缀的键注册加载器，存在多个匹配时使用最长前缀
+func (r *Refresher) RegisterLoader(prefix string, loader LoaderFunc, policy RefreshPolicy) {
+	if policy.HardTTL <= 0 {
+		policy.HardTTL = time.Hour
+	}
+	if policy.SoftTTL <= 0 || policy.SoftTTL > policy.HardTTL {
+		policy.SoftTTL = policy.HardTTL
+	}
+	if policy.EmptyTTL <= 0 {
+		policy.EmptyTTL = time.Minute
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.loaders {
+		if r.loaders[i].prefix == prefix {
+			r.loaders[i] = registeredLoader{prefix: prefix, loader: loader, policy: policy}
+			return
+		}
+	}
+
+	r.loaders = append(r.loaders, registeredLoader{prefix: prefix, loader: loader, policy: policy})
+	sort.SliceStable(r.loaders, func(i, j int) bool {
+		return len(r.loaders[i].prefix) > len(r.loaders[j].prefix)
+	})
+}
+
+// RegisterKeySpace 为键空间注册加载器，前缀取键空间第一个占位符之前的部分
+// policy 未设置的 HardTTL、Codec 和 Tags 使用键空间的配置
+func (r *Refresher) RegisterKeySpace(k *KeySpace, loader LoaderFunc, policy RefreshPolicy) {
+	if policy.HardTTL <= 0 {
+		policy.HardTTL = k.TTL()
+	}
+	if policy.Codec == nil {
+		policy.Codec = k.Codec()
+	}
+	if policy.Tags == nil {
+		policy.Tags = k.Tags()
+	}
+	r.RegisterLoader(k.Prefix(), loader, policy)
+}
+
+// GetOrLoad 获取缓存数据，未命中时通过注册的加载器回源
+// 数据不存在时返回 ErrKeyNotFound
+func (r *Refresher) GetOrLoad(ctx context.Context, key string, dest interface{}) error {
+	rl, ok := r.lookup(key)
+	if !ok {
+		return ErrLoaderNotFound
+	}
+
+	// 布隆过滤器判定一定不存在时，既不访问缓存也不回源
+	if rl.policy.Filter != nil {
+		if ok, err := rl.policy.Filter.MightContain(ctx, strings.TrimPrefix(key, rl.prefix)); err == nil && !ok {
+			metrics.RecordCacheFilterRejected(r.name)
+			return ErrKeyNotFound
+		}
+	}
+
+	var env refreshEnvelope
+	if err := r.cache.Get(key, &env); err == nil && env.SoftExpiresAt > 0 {
+		now := time.Now()
+		if shouldRefresh(now, &env, rl.policy.Beta) {
+			if now.UnixNano() >= env.SoftExpiresAt {
+				metrics.RecordCacheStaleServed(r.name)
+			} else {
+				metrics.RecordCacheEarlyRefresh(r.name)
+			}
+			r.refreshAsync(key, rl)
+		}
+		return decodeEnvelope(&env, dest)
+	}
+
+	// 缓存未命中（或已超过硬过期时间），同步回源
+	// 并发请求共享同一次回源，使用独立的上下文，发起回源的请求被取消时其他请求不受影响
+	ch := r.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.refreshTimeout)
+		defer cancel()
+		return r.load(loadCtx, key, rl)
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		return decodeEnvelope(res.Val.(*refreshEnvelope), dest)
+	}
+}
+
+// InvalidateTag 删除关联了指定标签的所有缓存数据
+// 失效前已经开始的回源不再写入缓存，避免把失效前加载的旧数据重新写回
+func (r *Refresher) InvalidateTag(tags ...string) error {
+	for _, tag := range tags {
+		counter, _ := r.generations.LoadOrStore(tag, new(uint64))
+		atomic.AddUint64(counter.(*uint64), 1)
+	}
+	return r.cache.InvalidateTag(tags...)
+}
+
+// generation 标签的失效次数之和，回源前后不一致说明期间发生过失效
+func (r *Refresher) generation(tags []string) uint64 {
+	var sum uint64
+	for _, tag := range tags {
+		if counter, ok := r.generations.Load(tag); ok {
+			sum += atomic.LoadUint64(counter.(*uint64))
+		}
+	}
+	return sum
+}
+
+// lookup 查找匹配的加载器
+func (r *Refresher) lookup(key string) (registeredLoader, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rl := range r.loaders {
+		if strings.HasPrefix(key, rl.prefix) {
+			return rl, true
+		}
+	}
+	return registeredLoader{}, false
+}
+
+// refreshAsync 后台刷新，同一键同时只有一个刷新任务
+func (r *Refresher) refreshAsync(key string, rl registeredLoader) {
+	if _, loaded := r.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	go func() {
+		defer r.refreshing.Delete(key)
+
+		// 请求可能已经结束，刷新使用独立的上下文
+		ctx, cancel := context.WithTimeout(context.Background(), r.refreshTimeout)
+		defer cancel()
+
+		if _, err, _ := r.group.Do(key, func() (interface{}, error) {
+			return r.load(ctx, key, rl)
+		}); err != nil {
+			// 刷新失败时保留旧值，直到硬过期
+			r.logger.Warn("cache background refresh failed",
+				zap.String("cache", r.name),
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// load 执行加载器并写入缓存
+func (r *Refresher) load(ctx context.Context, key string, rl registeredLoader) (*refreshEnvelope, error) {
+	generation := r.generation(rl.policy.Tags)
+	start := time.Now()
+	value, err := rl.loader(ctx, key)
+	delta := time.Since(start)
+
+	if err != nil && err != ErrKeyNotFound {
+		metrics.RecordCacheRefresh(r.name, false)
+		return nil, err
+	}
+	metrics.RecordCacheRefresh(r.name, true)
+
+	if err == ErrKeyNotFound {
+		value = nil
+	}
+	return r.store(key, rl, value, delta, generation)
+}
+
+// Prime 直接写入已加载的数据（如预热时批量查询的结果），按键对应加载器的过期策略批量写入
+func (r *Refresher) Prime(items map[string]interface{}) error {
+	type batch struct {
+		policy RefreshPolicy
+		items  map[string]interface{}
+	}
+
+	batches := make(map[string]*batch)
+	for key, value := range items {
+		rl, ok := r.lookup(key)
+		if !ok {
+			return ErrLoaderNotFound
+		}
+
+		env, ttl, err := newEnvelope(rl.policy, r.valueCodec(rl.policy), value, 0)
+		if err != nil {
+			return err
+		}
+		entry, err := encodeWith(rl.policy.Codec, env)
+		if err != nil {
+			return err
+		}
+
+		// 空值与正常数据过期时间不同，单独写入
+		if value == nil {
+			if err := r.cache.Set(key, entry, ttl, rl.policy.Tags...); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, ok := batches[rl.prefix]
+		if !ok {
+			b = &batch{policy: rl.policy, items: make(map[string]interface{})}
+			batches[rl.prefix] = b
+		}
+		b.items[key] = entry
+	}
+
+	for _, b := range batches {
+		if err := r.cache.MSet(b.items, b.policy.HardTTL, b.policy.Tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// store 封装数据并写入缓存，回源期间标签被失效（generation 已变化）时只返回数据不写入
+func (r *Refresher) store(key string, rl registeredLoader, value interface{}, delta time.Duration, generation uint64) (*refreshEnvelope, error) {
+	env, ttl, err := newEnvelope(rl.policy, r.valueCodec(rl.policy), value, delta)
+	if err != nil {
+		return nil, err
+	}
+	if r.generation(rl.policy.Tags) != generation {
+		return env, nil
+	}
+
+	entry, err := encodeWith(rl.policy.Codec, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.Set(key, entry, ttl, rl.policy.Tags...); err != nil {
+		r.logger.Warn("cache set after load failed",
+			zap.String("cache", r.name),
+			zap.String("key", key),
+			zap.Error(err),
+		)
+	}
+
+	// 写入的同时发生了失效，删除可能已经写入的旧数据
+	if r.generation(rl.policy.Tags) != generation {
+		_ = r.cache.Delete(key)
+	}
+
+	return env, nil
+}
+
+// valueCodec 编码数据使用的编解码器：策略指定的编解码器，否则为缓存实例的编解码器
+func (r *Refresher) valueCodec(policy RefreshPolicy) Codec {
+	if policy.Codec != nil {
+		return policy.Codec
+	}
+	if p, ok := r.cache.(valueCodecProvider); ok && p.valueCodec() != nil {
+		return p.valueCodec()
+	}
+	return JSON
+}
+
+// valueCodecProvider 能提供编解码器的缓存实现
+type valueCodecProvider interface {
+	valueCodec() Codec
+}
+
+// newEnvelope 封装缓存数据，返回封装结果和缓存过期时间，value 为 nil 时使用空值
+func newEnvelope(policy RefreshPolicy, codec Codec, value interface{}, delta time.Duration) (*refreshEnvelope, time.Duration, error) {
+	now := time.Now()
+	env := &refreshEnvelope{Delta: int64(delta)}
+
+	// 数据不存在时缓存空值，防止缓存穿透
+	if value == nil {
+		env.SoftExpiresAt = now.Add(policy.EmptyTTL).UnixNano()
+		return env, policy.EmptyTTL, nil
+	}
+
+	var err error
+	if env.Value, err = codec.Marshal(value); err != nil {
+		return nil, 0, err
+	}
+	env.SoftExpiresAt = now.Add(policy.SoftTTL).UnixNano()
+
+	return env, policy.HardTTL, nil
+}
+
+// shouldRefresh XFetch 判定：now - delta * beta * ln(rand) >= 软过期时间
+// 回源越慢、越接近软过期时间，提前刷新的概率越大
+func shouldRefresh(now time.Time, env *refreshEnvelope, beta float64) bool {
+	gap := 0.0
+	if beta > 0 && env.Delta > 0 {
+		gap = -float64(env.Delta) * beta * math.Log(1-rand.Float64())
+	}
+	return float64(now.UnixNano())+gap >= float64(env.SoftExpiresAt)
+}
+
+// decodeEnvelope 解析缓存数据，空值返回 ErrKeyNotFound
+func decodeEnvelope(env *refreshEnvelope, dest interface{}) error {
+	if len(env.Value) == 0 || string(env.Value) == "null" {
+		return ErrKeyNotFound
+	}
+	return decodeValue(env.Value, dest)
+}
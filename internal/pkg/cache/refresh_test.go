@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefresherLoadAndStale(t *testing.T) {
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	var calls int32
+	r := NewRefresher(l)
+	r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return n, nil
+	}, RefreshPolicy{SoftTTL: 20 * time.Millisecond, HardTTL: time.Minute})
+
+	var got int32
+	if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil || got != 1 {
+		t.Fatalf("first load = %d, %v; want 1, nil", got, err)
+	}
+
+	// 软过期前直接命中缓存
+	if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil || got != 1 {
+		t.Fatalf("fresh hit = %d, %v; want 1, nil", got, err)
+	}
+
+	// 软过期后返回旧值，后台刷新
+	time.Sleep(30 * time.Millisecond)
+	if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil || got != 1 {
+		t.Fatalf("stale hit = %d, %v; want 1, nil", got, err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil || got != 2 {
+		t.Fatalf("after refresh = %d, %v; want 2, nil", got, err)
+	}
+}
+
+func TestRefresherNotFound(t *testing.T) {
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	var calls int32
+	r := NewRefresher(l)
+	r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, ErrKeyNotFound
+	}, RefreshPolicy{HardTTL: time.Minute})
+
+	var got int
+	for i := 0; i < 3; i++ {
+		if err := r.GetOrLoad(context.Background(), "item:404", &got); err != ErrKeyNotFound {
+			t.Fatalf("GetOrLoad() = %v; want ErrKeyNotFound", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("loader calls = %d; want 1 (empty value should be cached)", calls)
+	}
+
+	if err := r.GetOrLoad(context.Background(), "other:1", &got); err != ErrLoaderNotFound {
+		t.Fatalf("GetOrLoad() = %v; want ErrLoaderNotFound", err)
+	}
+}
+
+func TestShouldRefreshXFetch(t *testing.T) {
+	now := time.Now()
+	env := &refreshEnvelope{
+		SoftExpiresAt: now.Add(time.Second).UnixNano(),
+		Delta:         int64(100 * time.Millisecond),
+	}
+
+	if shouldRefresh(now, env, 0) {
+		t.Fatal("beta=0 should not refresh before soft expiration")
+	}
+
+	// 回源耗时远大于剩余时间时几乎总是提前刷新
+	refreshed := 0
+	for i := 0; i < 1000; i++ {
+		if shouldRefresh(now, env, 1000) {
+			refreshed++
+		}
+	}
+	if refreshed < 900 {
+		t.Fatalf("refreshed %d/1000 times; want most requests to refresh early", refreshed)
+	}
+
+	if !shouldRefresh(now.Add(2*time.Second), env, 0) {
+		t.Fatal("should refresh after soft expiration")
+	}
+}
+
+func TestRefresherInvalidateDuringLoad(t *testing.T) {
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := NewRefresher(l)
+	r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+		close(started)
+		<-release
+		return 1, nil
+	}, RefreshPolicy{HardTTL: time.Minute, Tags: []string{"item"}})
+
+	// 发起回源的请求被取消时，共享回源的其他请求不受影响
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		var got int
+		leaderErr <- r.GetOrLoad(leaderCtx, "item:1", &got)
+	}()
+	<-started
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		var got int
+		waiterErr <- r.GetOrLoad(context.Background(), "item:1", &got)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Fatalf("leader err = %v; want context.Canceled", err)
+	}
+
+	// 回源期间失效，加载到的旧数据不写入缓存
+	if err := r.InvalidateTag("item"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-waiterErr; err != nil {
+		t.Fatalf("waiter err = %v; want nil", err)
+	}
+
+	var env refreshEnvelope
+	if err := l.Get("item:1", &env); err != ErrKeyNotFound {
+		t.Fatalf("expected value loaded before invalidation not to be cached, err=%v", err)
+	}
+}
+
+func TestRefresherKeepsCodecTypes(t *testing.T) {
+	for _, format := range []Format{FormatMsgpack, FormatGob} {
+		codec := NewCodec(format)
+		l := NewLocalCache(100, time.Minute, WithLocalCodec(codec))
+
+		r := NewRefresher(l)
+		r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+			return map[string]interface{}{"n": int64(7)}, nil
+		}, RefreshPolicy{SoftTTL: time.Minute, HardTTL: time.Minute})
+
+		// 第一次回源后从缓存读取，数据经过编解码器
+		for i := 0; i < 2; i++ {
+			var got map[string]interface{}
+			if err := r.GetOrLoad(context.Background(), "item:1", &got); err != nil {
+				t.Fatalf("%s: GetOrLoad() error = %v", codecName(codec), err)
+			}
+			if n, ok := got["n"].(int64); !ok || n != 7 {
+				t.Fatalf("%s: got %#v (%T); want int64(7)", codecName(codec), got["n"], got["n"])
+			}
+		}
+		l.Close()
+	}
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// snapshotVersion 快照文件格式版本，格式不兼容时递增
+const snapshotVersion = 1
+
+// Snapshotter 支持将本地缓存持久化到文件的缓存实现
+type Snapshotter interface {
+	// SaveSnapshot 将未过期的本地缓存写入文件，返回写入的条目数
+	SaveSnapshot(path string) (int, error)
+	// LoadSnapshot 从文件加载未过期的条目，快照早于 maxAge 时忽略，返回加载的条目数
+	LoadSnapshot(path string, maxAge time.Duration) (int, error)
+}
+
+// snapshotFile 快照文件内容
+type snapshotFile struct {
+	Version int
+	SavedAt time.Time
+	Entries []snapshotEntry
+}
+
+// snapshotEntry 快照条目，值为序列化后的原始数据
+type snapshotEntry struct {
+	Key       string
+	Value     []byte
+	ExpiresAt time.Time
+	Tags      []string
+}
+
+// SaveSnapshot 将未过期的本地缓存写入文件（先写临时文件再重命名，避免文件损坏）
+func (l *LocalCache) SaveSnapshot(path string) (int, error) {
+	snapshot := snapshotFile{
+		Version: snapshotVersion,
+		SavedAt: time.Now(),
+	}
+	l.store.Range(func(key string, value []byte, expiresAt time.Time, tags []string) bool {
+		snapshot.Entries = append(snapshot.Entries, snapshotEntry{
+			Key:       key,
+			Value:     value,
+			ExpiresAt: expiresAt,
+			Tags:      tags,
+		})
+		return true
+	})
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(&snapshot); err != nil {
+		_ = tmp.Close()
+		return 0, errors.Wrap(err, "encode snapshot")
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return len(snapshot.Entries), nil
+}
+
+// LoadSnapshot 从文件加载未过期的条目，文件不存在时返回0
+func (l *LocalCache) LoadSnapshot(path string, maxAge time.Duration) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var snapshot snapshotFile
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil {
+		return 0, errors.Wrap(err, "decode snapshot")
+	}
+	if snapshot.Version != snapshotVersion {
+		return 0, errors.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	// 快照过旧时，停机期间的失效消息已经丢失，数据可能与L2不一致
+	if maxAge > 0 && time.Since(snapshot.SavedAt) > maxAge {
+		return 0, nil
+	}
+
+	now := time.Now()
+	loaded := 0
+	for _, entry := range snapshot.Entries {
+		if !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(now) {
+			continue
+		}
+		if l.store.setWithExpiresAt(entry.Key, entry.Value, entry.ExpiresAt, entry.Tags) {
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// SaveSnapshot 将L1缓存写入文件
+func (m *MultiLevelCache) SaveSnapshot(path string) (int, error) {
+	return m.l1Cache.SaveSnapshot(path)
+}
+
+// LoadSnapshot 从文件加载L1缓存
+func (m *MultiLevelCache) LoadSnapshot(path string, maxAge time.Duration) (int, error) {
+	return m.l1Cache.LoadSnapshot(path, maxAge)
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestLocalCacheSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	l := NewLocalCache(100, time.Minute)
+	_ = l.Set("a", 1, time.Minute, "tag")
+	_ = l.Set("b", 2, 20*time.Millisecond)
+	if n, err := l.SaveSnapshot(path); err != nil || n != 2 {
+		t.Fatalf("SaveSnapshot() = %d, %v; want 2", n, err)
+	}
+	l.Close()
+
+	time.Sleep(30 * time.Millisecond)
+
+	restored := NewLocalCache(100, time.Minute)
+	defer restored.Close()
+
+	if n, err := restored.LoadSnapshot(path, time.Minute); err != nil || n != 1 {
+		t.Fatalf("LoadSnapshot() = %d, %v; want 1 (expired entry skipped)", n, err)
+	}
+
+	var got int
+	if err := restored.Get("a", &got); err != nil || got != 1 {
+		t.Fatalf("Get(a) = %d, %v; want 1", got, err)
+	}
+
+	// 标签随快照一起恢复
+	_ = restored.InvalidateTag("tag")
+	if exists, _ := restored.Exists("a"); exists {
+		t.Fatal("tags should be restored from snapshot")
+	}
+
+	// 快照过旧时忽略
+	if n, _ := restored.LoadSnapshot(path, time.Millisecond); n != 0 {
+		t.Fatalf("LoadSnapshot() = %d; want 0 for stale snapshot", n)
+	}
+
+	if n, err := restored.LoadSnapshot(filepath.Join(t.TempDir(), "missing"), 0); err != nil || n != 0 {
+		t.Fatalf("LoadSnapshot(missing) = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	warmupsMu.Lock()
+	saved := warmups
+	warmups = nil
+	warmupsMu.Unlock()
+	defer func() {
+		warmupsMu.Lock()
+		warmups = saved
+		warmupsMu.Unlock()
+	}()
+
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	r := NewRefresher(l)
+	r.RegisterLoader("item:", func(ctx context.Context, key string) (interface{}, error) {
+		t.Error("loader should not run for primed keys")
+		return nil, nil
+	}, RefreshPolicy{HardTTL: time.Minute})
+
+	RegisterWarmup("items", func(ctx context.Context) error {
+		return r.Prime(map[string]interface{}{"item:1": "one", "item:2": "two"})
+	})
+	RegisterWarmup("broken", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+
+	if err := Warmup(context.Background(), zap.NewNop()); err == nil {
+		t.Fatal("Warmup() should report failed hooks")
+	}
+
+	var got string
+	if err := r.GetOrLoad(context.Background(), "item:2", &got); err != nil || got != "two" {
+		t.Fatalf("GetOrLoad() = %q, %v; want two", got, err)
+	}
+}
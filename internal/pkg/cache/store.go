@@ -0,0 +1,606 @@
+package cache
+
+import (
+	"hash/maphash"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// entryOverhead 每个条目除键和值之外的估算内存开销（字节）
+	entryOverhead = 96
+
+	// defaultJanitorInterval 默认过期条目清理间隔
+	defaultJanitorInterval = time.Minute
+)
+
+// EvictionPolicy 淘汰策略
+type EvictionPolicy int
+
+const (
+	// PolicyLRU 最近最少使用
+	PolicyLRU EvictionPolicy = iota
+	// PolicyLFU 最不经常使用
+	PolicyLFU
+	// PolicyTinyLFU LRU淘汰 + TinyLFU准入，新条目访问频率低于待淘汰条目时拒绝写入
+	PolicyTinyLFU
+)
+
+// String 实现EvictionPolicy的字符串表示
+func (p EvictionPolicy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "lru"
+	case PolicyLFU:
+		return "lfu"
+	case PolicyTinyLFU:
+		return "tinylfu"
+	default:
+		return "unknown"
+	}
+}
+
+// StoreConfig 分片缓存配置
+type StoreConfig[V any] struct {
+	Shards          int            // 分片数量，向上取整为2的幂，默认为 CPU 核数的4倍
+	MaxEntries      int            // 最大条目数，0表示不限制
+	MaxBytes        int64          // 最大占用内存（估算），0表示不限制
+	DefaultTTL      time.Duration  // Set 未指定过期时间时使用的默认过期时间
+	Policy          EvictionPolicy // 淘汰策略
+	JanitorInterval time.Duration  // 过期条目清理间隔，负数表示不启动清理协程
+	Sizer           func(V) int    // 估算值占用的字节数，MaxBytes 大于0时使用
+}
+
+// StoreStats 缓存统计信息
+type StoreStats struct {
+	Hits        uint64 `json:"hits"`        // 命中次数
+	Misses      uint64 `json:"misses"`      // 未命中次数
+	Evictions   uint64 `json:"evictions"`   // 容量淘汰次数
+	Expirations uint64 `json:"expirations"` // 过期删除次数
+	Rejections  uint64 `json:"rejections"`  // TinyLFU 准入拒绝次数
+	Entries     int    `json:"entries"`     // 当前条目数
+	Bytes       int64  `json:"bytes"`       // 当前占用内存（估算）
+}
+
+// HitRatio 命中率
+func (s StoreStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Store 分片本地缓存，V 为存储的值类型
+// 每个分片独立加锁，读操作只锁定键所在的分片
+type Store[V any] struct {
+	shards []*shard[V]
+	mask   uint64
+	seed   maphash.Seed
+	ttl    time.Duration
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	rejections  atomic.Uint64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// storeEntry 缓存条目
+type storeEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+	tags      []string
+	size      int64
+
+	// 淘汰策略使用的字段
+	prev, next *storeEntry[V] // LRU 双向链表
+	freq       uint32         // LFU 访问频率
+	tick       uint64         // LFU 最近访问时刻，频率相同时淘汰较早访问的条目
+	heapIndex  int            // LFU 堆中的位置
+}
+
+// expired 是否已过期
+func (e *storeEntry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// shard 缓存分片
+type shard[V any] struct {
+	mu         sync.Mutex
+	items      map[string]*storeEntry[V]
+	tagIndex   map[string]map[string]struct{}
+	policy     evictionPolicy[V]
+	sketch     *countMinSketch // 仅 TinyLFU 使用
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	sizer      func(V) int
+}
+
+// NewStore 创建分片本地缓存
+func NewStore[V any](config StoreConfig[V]) *Store[V] {
+	shardCount := config.Shards
+	if shardCount <= 0 {
+		shardCount = runtime.GOMAXPROCS(0) * 4
+	}
+	shardCount = nextPowerOfTwo(shardCount)
+
+	// 条目数较少时减少分片数，避免每个分片容量过小
+	if config.MaxEntries > 0 {
+		for shardCount > 1 && config.MaxEntries/shardCount < 8 {
+			shardCount /= 2
+		}
+	}
+
+	s := &Store[V]{
+		shards: make([]*shard[V], shardCount),
+		mask:   uint64(shardCount - 1),
+		seed:   maphash.MakeSeed(),
+		ttl:    config.DefaultTTL,
+		stop:   make(chan struct{}),
+	}
+
+	for i := range s.shards {
+		sh := &shard[V]{
+			items:    make(map[string]*storeEntry[V]),
+			tagIndex: make(map[string]map[string]struct{}),
+			sizer:    config.Sizer,
+		}
+		if config.MaxEntries > 0 {
+			sh.maxEntries = (config.MaxEntries + shardCount - 1) / shardCount
+		}
+		if config.MaxBytes > 0 {
+			sh.maxBytes = (config.MaxBytes + int64(shardCount) - 1) / int64(shardCount)
+		}
+
+		switch config.Policy {
+		case PolicyLFU:
+			sh.policy = newLFUPolicy[V]()
+		case PolicyTinyLFU:
+			sh.policy = newLRUPolicy[V]()
+			sh.sketch = newCountMinSketch(sh.maxEntries)
+		default:
+			sh.policy = newLRUPolicy[V]()
+		}
+
+		s.shards[i] = sh
+	}
+
+	interval := config.JanitorInterval
+	if interval == 0 {
+		interval = defaultJanitorInterval
+	}
+	if interval > 0 {
+		go s.janitor(interval)
+	}
+
+	return s
+}
+
+// Get 获取缓存值
+func (s *Store[V]) Get(key string) (V, bool) {
+	value, _, ok := s.GetWithExpiration(key)
+	return value, ok
+}
+
+// GetWithExpiration 获取缓存值及其过期时间
+func (s *Store[V]) GetWithExpiration(key string) (V, time.Time, bool) {
+	hash := s.hash(key)
+	sh := s.shardFor(hash)
+	now := time.Now()
+
+	sh.mu.Lock()
+	if sh.sketch != nil {
+		sh.sketch.increment(hash)
+	}
+
+	entry, exists := sh.items[key]
+	if !exists {
+		sh.mu.Unlock()
+		s.misses.Add(1)
+		var zero V
+		return zero, time.Time{}, false
+	}
+
+	if entry.expired(now) {
+		sh.removeEntry(entry)
+		sh.mu.Unlock()
+		s.expirations.Add(1)
+		s.misses.Add(1)
+		var zero V
+		return zero, time.Time{}, false
+	}
+
+	sh.policy.access(entry)
+	value, expiresAt := entry.value, entry.expiresAt
+	sh.mu.Unlock()
+
+	s.hits.Add(1)
+	return value, expiresAt, true
+}
+
+// Set 设置缓存值，expiration 小于等于0时使用默认过期时间，默认过期时间也为0则永不过期
+// 返回 false 表示被 TinyLFU 准入策略拒绝或条目超过分片容量
+func (s *Store[V]) Set(key string, value V, expiration time.Duration, tags ...string) bool {
+	if expiration <= 0 {
+		expiration = s.ttl
+	}
+
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+
+	return s.setWithExpiresAt(key, value, expiresAt, tags)
+}
+
+// setWithExpiresAt 按绝对过期时间设置缓存值
+func (s *Store[V]) setWithExpiresAt(key string, value V, expiresAt time.Time, tags []string) bool {
+	hash := s.hash(key)
+	sh := s.shardFor(hash)
+
+	size := int64(len(key) + entryOverhead)
+	if sh.sizer != nil {
+		size += int64(sh.sizer(value))
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	return s.setLocked(sh, hash, key, value, size, expiresAt, tags)
+}
+
+// update 在持有分片锁时读取并更新缓存值，用于实现原子操作
+// fn 接收当前值（不存在或已过期时 exists 为 false），返回新值以及是否写入
+// keepTTL 为 true 时已存在的键保留原过期时间和标签
+func (s *Store[V]) update(key string, expiration time.Duration, keepTTL bool, fn func(old V, exists bool) (V, bool)) bool {
+	hash := s.hash(key)
+	sh := s.shardFor(hash)
+	now := time.Now()
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	var old V
+	entry, exists := sh.items[key]
+	if exists && entry.expired(now) {
+		sh.removeEntry(entry)
+		s.expirations.Add(1)
+		exists = false
+	}
+	if exists {
+		old = entry.value
+	}
+
+	value, ok := fn(old, exists)
+	if !ok {
+		return false
+	}
+
+	if expiration <= 0 {
+		expiration = s.ttl
+	}
+
+	var expiresAt time.Time
+	var tags []string
+	switch {
+	case exists && keepTTL:
+		expiresAt, tags = entry.expiresAt, entry.tags
+	case expiration > 0:
+		expiresAt = now.Add(expiration)
+	}
+
+	size := int64(len(key) + entryOverhead)
+	if sh.sizer != nil {
+		size += int64(sh.sizer(value))
+	}
+
+	return s.setLocked(sh, hash, key, value, size, expiresAt, tags)
+}
+
+// setLocked 在持有分片锁时写入缓存值
+func (s *Store[V]) setLocked(sh *shard[V], hash uint64, key string, value V, size int64, expiresAt time.Time, tags []string) bool {
+	// 单个条目超过分片容量，直接拒绝
+	if sh.maxBytes > 0 && size > sh.maxBytes {
+		s.rejections.Add(1)
+		return false
+	}
+
+	if sh.sketch != nil {
+		sh.sketch.increment(hash)
+	}
+
+	// 已存在则原地更新
+	if entry, exists := sh.items[key]; exists {
+		sh.bytes += size - entry.size
+		entry.value = value
+		entry.expiresAt = expiresAt
+		entry.size = size
+		sh.unindexTags(entry)
+		entry.tags = tags
+		sh.indexTags(entry)
+		sh.policy.access(entry)
+		s.evictLocked(sh, entry)
+		return true
+	}
+
+	// 容量不足时淘汰，TinyLFU 模式下需要先通过准入判断
+	for sh.full(size) {
+		victim := sh.policy.victim()
+		if victim == nil {
+			break
+		}
+
+		if victim.expired(time.Now()) {
+			sh.removeEntry(victim)
+			s.expirations.Add(1)
+			continue
+		}
+
+		if sh.sketch != nil && !sh.sketch.admit(hash, s.hash(victim.key)) {
+			s.rejections.Add(1)
+			return false
+		}
+
+		sh.removeEntry(victim)
+		s.evictions.Add(1)
+	}
+
+	entry := &storeEntry[V]{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+		tags:      tags,
+		size:      size,
+	}
+	sh.items[key] = entry
+	sh.bytes += size
+	sh.policy.add(entry)
+	sh.indexTags(entry)
+
+	return true
+}
+
+// Delete 删除缓存值
+func (s *Store[V]) Delete(key string) {
+	sh := s.shardFor(s.hash(key))
+
+	sh.mu.Lock()
+	if entry, exists := sh.items[key]; exists {
+		sh.removeEntry(entry)
+	}
+	sh.mu.Unlock()
+}
+
+// Exists 检查键是否存在且未过期，不影响淘汰顺序和命中统计
+func (s *Store[V]) Exists(key string) bool {
+	sh := s.shardFor(s.hash(key))
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	entry, exists := sh.items[key]
+	if !exists {
+		return false
+	}
+
+	if entry.expired(time.Now()) {
+		sh.removeEntry(entry)
+		s.expirations.Add(1)
+		return false
+	}
+
+	return true
+}
+
+// InvalidateTag 删除关联了指定标签的所有缓存值
+func (s *Store[V]) InvalidateTag(tags ...string) {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for _, tag := range tags {
+			for key := range sh.tagIndex[tag] {
+				if entry, exists := sh.items[key]; exists {
+					sh.removeEntry(entry)
+				}
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// DeletePrefix 删除指定前缀的所有缓存值
+func (s *Store[V]) DeletePrefix(prefix string) {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for key, entry := range sh.items {
+			if strings.HasPrefix(key, prefix) {
+				sh.removeEntry(entry)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// Range 遍历未过期的条目，fn 返回 false 时停止
+// 遍历时逐个锁定分片，fn 中不能再调用 Store 的方法
+func (s *Store[V]) Range(fn func(key string, value V, expiresAt time.Time, tags []string) bool) {
+	now := time.Now()
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for key, entry := range sh.items {
+			if entry.expired(now) {
+				continue
+			}
+			if !fn(key, entry.value, entry.expiresAt, entry.tags) {
+				sh.mu.Unlock()
+				return
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// Clear 清空全部缓存
+func (s *Store[V]) Clear() {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		sh.items = make(map[string]*storeEntry[V])
+		sh.tagIndex = make(map[string]map[string]struct{})
+		sh.policy.reset()
+		sh.bytes = 0
+		sh.mu.Unlock()
+	}
+}
+
+// Len 当前条目数（包含尚未被清理的过期条目）
+func (s *Store[V]) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n += len(sh.items)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Stats 获取统计信息
+func (s *Store[V]) Stats() StoreStats {
+	stats := StoreStats{
+		Hits:        s.hits.Load(),
+		Misses:      s.misses.Load(),
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+		Rejections:  s.rejections.Load(),
+	}
+
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		stats.Entries += len(sh.items)
+		stats.Bytes += sh.bytes
+		sh.mu.Unlock()
+	}
+
+	return stats
+}
+
+// Close 停止过期清理协程
+func (s *Store[V]) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// DeleteExpired 删除所有已过期条目，返回删除数量
+func (s *Store[V]) DeleteExpired() int {
+	removed := 0
+	now := time.Now()
+
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for _, entry := range sh.items {
+			if entry.expired(now) {
+				sh.removeEntry(entry)
+				removed++
+			}
+		}
+		sh.mu.Unlock()
+	}
+
+	s.expirations.Add(uint64(removed))
+	return removed
+}
+
+// janitor 定期清理过期条目
+func (s *Store[V]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.DeleteExpired()
+		}
+	}
+}
+
+// evictLocked 更新后条目变大时按容量淘汰，调用方需持有分片锁
+func (s *Store[V]) evictLocked(sh *shard[V], keep *storeEntry[V]) {
+	for sh.maxBytes > 0 && sh.bytes > sh.maxBytes {
+		victim := sh.policy.victim()
+		if victim == nil || victim == keep {
+			return
+		}
+		sh.removeEntry(victim)
+		s.evictions.Add(1)
+	}
+}
+
+func (s *Store[V]) hash(key string) uint64 {
+	return maphash.String(s.seed, key)
+}
+
+func (s *Store[V]) shardFor(hash uint64) *shard[V] {
+	return s.shards[hash&s.mask]
+}
+
+// full 写入 size 字节的新条目前分片是否需要淘汰
+func (sh *shard[V]) full(size int64) bool {
+	if len(sh.items) == 0 {
+		return false
+	}
+	if sh.maxEntries > 0 && len(sh.items) >= sh.maxEntries {
+		return true
+	}
+	return sh.maxBytes > 0 && sh.bytes+size > sh.maxBytes
+}
+
+// removeEntry 删除条目，调用方需持有分片锁
+func (sh *shard[V]) removeEntry(entry *storeEntry[V]) {
+	delete(sh.items, entry.key)
+	sh.policy.remove(entry)
+	sh.unindexTags(entry)
+	sh.bytes -= entry.size
+}
+
+// indexTags 建立标签索引
+func (sh *shard[V]) indexTags(entry *storeEntry[V]) {
+	for _, tag := range entry.tags {
+		keys, exists := sh.tagIndex[tag]
+		if !exists {
+			keys = make(map[string]struct{})
+			sh.tagIndex[tag] = keys
+		}
+		keys[entry.key] = struct{}{}
+	}
+}
+
+// unindexTags 删除标签索引
+func (sh *shard[V]) unindexTags(entry *storeEntry[V]) {
+	for _, tag := range entry.tags {
+		if keys, exists := sh.tagIndex[tag]; exists {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(sh.tagIndex, tag)
+			}
+		}
+	}
+}
+
+// nextPowerOfTwo 向上取整为2的幂
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
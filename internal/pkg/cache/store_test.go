@@ -0,0 +1,329 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreGetSet(t *testing.T) {
+	s := NewStore(StoreConfig[int]{MaxEntries: 100, JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("a", 1, time.Minute)
+	if v, ok := s.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v; want 1, true", v, ok)
+	}
+
+	if _, ok := s.Get("missing"); ok {
+		t.Fatal("Get(missing) should miss")
+	}
+
+	stats := s.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v; want 1 hit and 1 miss", stats)
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	s := NewStore(StoreConfig[string]{JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("k", "v", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := s.Get("k"); ok {
+		t.Fatal("expired entry should not be returned")
+	}
+
+	s.Set("k1", "v", 10*time.Millisecond)
+	s.Set("k2", "v", time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	if n := s.DeleteExpired(); n != 1 {
+		t.Fatalf("DeleteExpired() = %d; want 1", n)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d; want 1", s.Len())
+	}
+}
+
+func TestStoreLRUEviction(t *testing.T) {
+	s := NewStore(StoreConfig[int]{Shards: 1, MaxEntries: 3, Policy: PolicyLRU, JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("a", 1, time.Minute)
+	s.Set("b", 2, time.Minute)
+	s.Set("c", 3, time.Minute)
+	s.Get("a") // a 成为最近使用
+	s.Set("d", 4, time.Minute)
+
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("b should be evicted as least recently used")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := s.Get(key); !ok {
+			t.Fatalf("%s should be kept", key)
+		}
+	}
+	if s.Stats().Evictions != 1 {
+		t.Fatalf("evictions = %d; want 1", s.Stats().Evictions)
+	}
+}
+
+func TestStoreLFUEviction(t *testing.T) {
+	s := NewStore(StoreConfig[int]{Shards: 1, MaxEntries: 3, Policy: PolicyLFU, JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("a", 1, time.Minute)
+	s.Set("b", 2, time.Minute)
+	s.Set("c", 3, time.Minute)
+	for i := 0; i < 3; i++ {
+		s.Get("a")
+		s.Get("c")
+	}
+	s.Set("d", 4, time.Minute)
+
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("b should be evicted as least frequently used")
+	}
+}
+
+func TestStoreTinyLFUAdmission(t *testing.T) {
+	s := NewStore(StoreConfig[int]{Shards: 1, MaxEntries: 2, Policy: PolicyTinyLFU, JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("hot1", 1, time.Minute)
+	s.Set("hot2", 2, time.Minute)
+	for i := 0; i < 5; i++ {
+		s.Get("hot1")
+		s.Get("hot2")
+	}
+
+	// 只出现一次的新键不应挤掉热点键
+	if s.Set("cold", 3, time.Minute) {
+		t.Fatal("cold key should be rejected by admission policy")
+	}
+	if s.Stats().Rejections != 1 {
+		t.Fatalf("rejections = %d; want 1", s.Stats().Rejections)
+	}
+}
+
+func TestStoreMaxBytes(t *testing.T) {
+	s := NewStore(StoreConfig[[]byte]{
+		Shards:          1,
+		MaxBytes:        3 * (entryOverhead + 1 + 100),
+		Sizer:           func(b []byte) int { return len(b) },
+		JanitorInterval: -1,
+	})
+	defer s.Close()
+
+	value := make([]byte, 100)
+	for i := 0; i < 10; i++ {
+		s.Set(strconv.Itoa(i), value, time.Minute)
+	}
+
+	stats := s.Stats()
+	if stats.Entries != 3 {
+		t.Fatalf("entries = %d; want 3", stats.Entries)
+	}
+	if stats.Bytes > 3*(entryOverhead+1+100) {
+		t.Fatalf("bytes = %d exceeds limit", stats.Bytes)
+	}
+}
+
+func TestStoreTagsAndPrefix(t *testing.T) {
+	s := NewStore(StoreConfig[int]{JanitorInterval: -1})
+	defer s.Close()
+
+	s.Set("admin:1", 1, time.Minute, "admin")
+	s.Set("admin:list", 2, time.Minute, "admin")
+	s.Set("user:1", 3, time.Minute, "user")
+
+	s.InvalidateTag("admin")
+	if s.Exists("admin:1") || s.Exists("admin:list") {
+		t.Fatal("entries tagged admin should be invalidated")
+	}
+	if !s.Exists("user:1") {
+		t.Fatal("user:1 should be kept")
+	}
+
+	s.DeletePrefix("user:")
+	if s.Exists("user:1") {
+		t.Fatal("user:1 should be deleted by prefix")
+	}
+}
+
+func TestLocalCacheRoundTrip(t *testing.T) {
+	type admin struct {
+		ID   int32  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	if err := l.Set("admin:1", &admin{ID: 1, Name: "root"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var got admin
+	if err := l.Get("admin:1", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != 1 || got.Name != "root" {
+		t.Fatalf("got %+v", got)
+	}
+
+	_ = l.Set("admin:2", nil, time.Minute)
+	if err := l.Get("admin:2", &got); err != ErrKeyNotFound {
+		t.Fatalf("empty marker should be reported as not found, got %v", err)
+	}
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	s := NewStore(StoreConfig[int]{MaxEntries: 1000, Policy: PolicyTinyLFU, JanitorInterval: time.Millisecond})
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := strconv.Itoa((g*7 + i) % 1500)
+				s.Set(key, i, time.Millisecond*time.Duration(1+i%5))
+				s.Get(key)
+				if i%100 == 0 {
+					s.Delete(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if s.Len() > 1000+len(s.shards) {
+		t.Fatalf("Len() = %d exceeds capacity", s.Len())
+	}
+}
+
+func benchmarkStoreGet(b *testing.B, policy EvictionPolicy) {
+	s := NewStore(StoreConfig[[]byte]{MaxEntries: 10000, Policy: policy, JanitorInterval: -1})
+	defer s.Close()
+
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+		s.Set(keys[i], []byte("value"), time.Hour)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func BenchmarkStoreGetLRU(b *testing.B)     { benchmarkStoreGet(b, PolicyLRU) }
+func BenchmarkStoreGetLFU(b *testing.B)     { benchmarkStoreGet(b, PolicyLFU) }
+func BenchmarkStoreGetTinyLFU(b *testing.B) { benchmarkStoreGet(b, PolicyTinyLFU) }
+
+func BenchmarkStoreSetParallel(b *testing.B) {
+	s := NewStore(StoreConfig[[]byte]{MaxEntries: 10000, JanitorInterval: -1})
+	defer s.Close()
+
+	value := []byte("value")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Set("key:"+strconv.Itoa(i%20000), value, time.Hour)
+			i++
+		}
+	})
+}
+
+func BenchmarkLocalCacheGet(b *testing.B) {
+	type admin struct {
+		ID   int32  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	l := NewLocalCache(10000, time.Hour)
+	defer l.Close()
+	_ = l.Set("admin:1", &admin{ID: 1, Name: "root"}, time.Hour)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var dest admin
+		for pb.Next() {
+			_ = l.Get("admin:1", &dest)
+		}
+	})
+}
+
+func TestLocalCacheBatchAndAtomic(t *testing.T) {
+	l := NewLocalCache(100, time.Minute)
+	defer l.Close()
+
+	if err := l.MSet(map[string]interface{}{"a": 1, "b": 2, "c": nil}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]int
+	if err := l.MGet([]string{"a", "b", "c", "d"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] != 1 || got["b"] != 2 {
+		t.Fatalf("MGet() = %v; want map[a:1 b:2]", got)
+	}
+
+	if err := l.MGet([]string{"a"}, got); err == nil {
+		t.Fatal("MGet with non-pointer dest should fail")
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		if n, err := l.Incr("counter", 1, time.Minute); err != nil || n != i {
+			t.Fatalf("Incr() = %d, %v; want %d", n, err, i)
+		}
+	}
+	if n, _ := l.Decr("counter", 2, time.Minute); n != 1 {
+		t.Fatalf("Decr() = %d; want 1", n)
+	}
+	var counter int64
+	if err := l.Get("counter", &counter); err != nil || counter != 1 {
+		t.Fatalf("Get(counter) = %d, %v; want 1", counter, err)
+	}
+	if _, err := l.Incr("a", 1, time.Minute); err != nil {
+		t.Fatalf("Incr on numeric value should succeed, got %v", err)
+	}
+	_ = l.Set("s", "text", time.Minute)
+	if _, err := l.Incr("s", 1, time.Minute); err != ErrNotInteger {
+		t.Fatalf("Incr on string = %v; want ErrNotInteger", err)
+	}
+
+	if ok, _ := l.SetNX("nx", 1, time.Minute); !ok {
+		t.Fatal("first SetNX should succeed")
+	}
+	if ok, _ := l.SetNX("nx", 2, time.Minute); ok {
+		t.Fatal("second SetNX should fail")
+	}
+
+	typed := NewTyped[int](l)
+	if _, err := typed.GetSet("gs", 1, time.Minute); err != ErrKeyNotFound {
+		t.Fatalf("GetSet on missing key = %v; want ErrKeyNotFound", err)
+	}
+	if old, err := typed.GetSet("gs", 2, time.Minute); err != nil || old != 1 {
+		t.Fatalf("GetSet() = %d, %v; want 1", old, err)
+	}
+	if v, err := typed.Get("gs"); err != nil || v != 2 {
+		t.Fatalf("Get(gs) = %d, %v; want 2", v, err)
+	}
+	if values, _ := typed.MGet("gs", "nx", "missing"); len(values) != 2 || values["nx"] != 1 {
+		t.Fatalf("typed MGet() = %v", values)
+	}
+}
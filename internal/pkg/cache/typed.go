@@ -0,0 +1,66 @@
+package cache
+
+import "time"
+
+// Typed 泛型缓存包装，调用方无需声明目标变量和做类型断言
+//
+//	admins := cache.NewTyped[*model.Admin](c)
+//	admin, err := admins.Get("admin:1")
+type Typed[T any] struct {
+	cache Cache
+}
+
+// NewTyped 创建泛型缓存包装
+func NewTyped[T any](c Cache) *Typed[T] {
+	return &Typed[T]{cache: c}
+}
+
+// Get 获取缓存数据，不存在时返回 ErrKeyNotFound
+func (t *Typed[T]) Get(key string) (T, error) {
+	var value T
+	err := t.cache.Get(key, &value)
+	return value, err
+}
+
+// Set 设置缓存数据
+func (t *Typed[T]) Set(key string, value T, expiration time.Duration, tags ...string) error {
+	return t.cache.Set(key, value, expiration, tags...)
+}
+
+// MGet 批量获取数据，不存在的键不会出现在结果中
+func (t *Typed[T]) MGet(keys ...string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	err := t.cache.MGet(keys, &values)
+	return values, err
+}
+
+// MSet 批量设置缓存数据
+func (t *Typed[T]) MSet(items map[string]T, expiration time.Duration, tags ...string) error {
+	values := make(map[string]interface{}, len(items))
+	for key, value := range items {
+		values[key] = value
+	}
+	return t.cache.MSet(values, expiration, tags...)
+}
+
+// SetNX 键不存在时设置缓存数据
+func (t *Typed[T]) SetNX(key string, value T, expiration time.Duration) (bool, error) {
+	return t.cache.SetNX(key, value, expiration)
+}
+
+// GetSet 设置新值并返回旧值，旧值不存在时返回 ErrKeyNotFound
+func (t *Typed[T]) GetSet(key string, value T, expiration time.Duration) (T, error) {
+	var old T
+	err := t.cache.GetSet(key, value, expiration, &old)
+	return old, err
+}
+
+// Delete 删除缓存数据
+func (t *Typed[T]) Delete(key string) error {
+	return t.cache.Delete(key)
+}
+
+// Cache 返回底层缓存实例
+func (t *Typed[T]) Cache() Cache {
+	return t.cache
+}
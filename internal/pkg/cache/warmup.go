@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// WarmupFunc 预热函数，在实例对外提供服务前加载热点数据
+type WarmupFunc func(ctx context.Context) error
+
+type warmupHook struct {
+	name string
+	fn   WarmupFunc
+}
+
+var (
+	warmups   []warmupHook
+	warmupsMu sync.Mutex
+)
+
+// RegisterWarmup 注册预热函数
+func RegisterWarmup(name string, fn WarmupFunc) {
+	warmupsMu.Lock()
+	defer warmupsMu.Unlock()
+
+	warmups = append(warmups, warmupHook{name: name, fn: fn})
+}
+
+// Warmup 并发执行所有预热函数，单个失败不影响其他预热，返回合并后的错误
+func Warmup(ctx context.Context, logger *zap.Logger) error {
+	warmupsMu.Lock()
+	hooks := make([]warmupHook, len(warmups))
+	copy(hooks, warmups)
+	warmupsMu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs error
+	)
+	for _, hook := range hooks {
+		wg.Add(1)
+		go func(hook warmupHook) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := hook.fn(ctx)
+			if err != nil {
+				logger.Warn("cache warm-up failed", zap.String("name", hook.name), zap.Error(err))
+				mu.Lock()
+				errs = multierr.Append(errs, err)
+				mu.Unlock()
+				return
+			}
+			logger.Info("cache warm-up finished", zap.String("name", hook.name), zap.Duration("cost", time.Since(start)))
+		}(hook)
+	}
+	wg.Wait()
+
+	return errs
+}
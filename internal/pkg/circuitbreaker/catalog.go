@@ -0,0 +1,120 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// 管理操作
+const (
+	// ActionForceOpen 强制开启
+	ActionForceOpen = "force_open"
+	// ActionForceClosed 强制关闭
+	ActionForceClosed = "force_closed"
+	// ActionReset 重置并解除强制状态
+	ActionReset = "reset"
+)
+
+// ErrUnknownAction 不支持的管理操作
+var ErrUnknownAction = errors.New("circuit breaker: unknown action")
+
+// catalog 全局熔断器目录，供管理接口按名称查看和强制状态
+var catalog = struct {
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+	pending  map[string]string // 熔断器尚未创建时收到的强制操作
+}{
+	breakers: make(map[string]*CircuitBreaker),
+	pending:  make(map[string]string),
+}
+
+// Register 将熔断器加入全局目录，同名的熔断器会被替换
+// Default() 创建的熔断器会自动加入，单独创建的熔断器（如服务端路由组熔断器 http-server:<name>）需要显式加入
+func Register(cb *CircuitBreaker) {
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+
+	catalog.breakers[cb.Name()] = cb
+	if action, ok := catalog.pending[cb.Name()]; ok {
+		_ = apply(cb, action)
+	}
+}
+
+// Lookup 按名称查找目录中的熔断器
+func Lookup(name string) (*CircuitBreaker, bool) {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	cb, ok := catalog.breakers[name]
+	return cb, ok
+}
+
+// All 返回目录中的全部熔断器，按名称排序
+func All() []*CircuitBreaker {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	breakers := make([]*CircuitBreaker, 0, len(catalog.breakers))
+	for _, cb := range catalog.breakers {
+		breakers = append(breakers, cb)
+	}
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].Name() < breakers[j].Name()
+	})
+	return breakers
+}
+
+// Apply 对目录中的熔断器执行管理操作，返回熔断器是否存在
+// 强制操作会被记录下来，熔断器在之后创建（按需创建的下游熔断器）时立即生效
+func Apply(name, action string) (bool, error) {
+	if action != ActionForceOpen && action != ActionForceClosed && action != ActionReset {
+		return false, ErrUnknownAction
+	}
+
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+
+	if action == ActionReset {
+		delete(catalog.pending, name)
+	} else {
+		catalog.pending[name] = action
+	}
+
+	cb, ok := catalog.breakers[name]
+	if !ok {
+		return false, nil
+	}
+	return true, apply(cb, action)
+}
+
+// forcedNames 返回本实例处于强制状态的熔断器名称，包括尚未创建、强制操作还在等待的
+func forcedNames() []string {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	names := make([]string, 0, len(catalog.pending))
+	for name := range catalog.pending {
+		names = append(names, name)
+	}
+	for name, cb := range catalog.breakers {
+		if _, ok := catalog.pending[name]; !ok && cb.Forced() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func apply(cb *CircuitBreaker, action string) error {
+	switch action {
+	case ActionForceOpen:
+		cb.ForceOpen()
+	case ActionForceClosed:
+		cb.ForceClosed()
+	case ActionReset:
+		cb.Reset()
+	default:
+		return ErrUnknownAction
+	}
+	return nil
+}
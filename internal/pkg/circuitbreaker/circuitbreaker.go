@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 
@@ -34,6 +35,48 @@ func (s State) String() string {
 	}
 }
 
+var (
+	// ErrOpen 熔断器开启，请求被拒绝
+	ErrOpen = errors.New("circuit breaker is open")
+	// ErrTooManyCalls 半开启状态下试探请求数已达上限
+	ErrTooManyCalls = errors.New("circuit breaker is half-open: too many trial calls")
+)
+
+// StatusError 以 HTTP 状态码表示的调用错误，用于区分调用方错误和服务端错误
+type StatusError struct {
+	StatusCode int
+	Wait       time.Duration // 响应中 Retry-After 头要求的重试等待时间
+}
+
+func (e *StatusError) Error() string {
+	return "http status " + strconv.Itoa(e.StatusCode)
+}
+
+// HTTPCode 返回 HTTP 状态码
+func (e *StatusError) HTTPCode() int {
+	return e.StatusCode
+}
+
+// RetryAfter 服务端要求的重试等待时间
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+// IsFailure 默认的错误分类，带有 4xx 状态码的错误（如 StatusError、core.BusinessError）
+// 是调用方的问题，不计为失败
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var coded interface{ HTTPCode() int }
+	if errors.As(err, &coded) {
+		code := coded.HTTPCode()
+		return code < 400 || code >= 500
+	}
+	return true
+}
+
 // CircuitBreaker 熔断器
 type CircuitBreaker struct {
 	// 配置参数
@@ -42,20 +85,35 @@ type CircuitBreaker struct {
 	resetTimeout     time.Duration // 重置超时时间
 	successThreshold int           // 半开启状态下成功阈值
 
+	// 滑动窗口配置
+	windowType            WindowType
+	minimumCalls          int           // 窗口内调用数达到该值后才计算失败率
+	failureRateThreshold  float64       // 失败率阈值（百分比）
+	slowCallDuration      time.Duration // 耗时超过该值视为慢调用
+	slowCallRateThreshold float64       // 慢调用率阈值（百分比）
+	permittedHalfOpen     int           // 半开启状态下允许的试探请求数
+	isFailure             func(err error) bool
+
 	// 状态
-	state State // 当前状态
+	state      State // 当前状态
+	generation int64 // 每次状态变化加一，丢弃状态变化前发起的调用结果
+	forced     bool  // 状态由运维强制设置，调用结果不再改变状态，直到 Reset
 
 	// 统计信息
-	failureCount   int       // 连续失败次数
-	lastFailure    time.Time // 上次失败时间
-	trippedTime    time.Time // 熔断触发时间
-	successCount   int       // 半开启状态下成功次数
-	requestCount   int       // 总请求数
-	totalSuccess   int       // 总成功数
-	totalFailure   int       // 总失败数
+	failureCount int       // 连续失败次数
+	lastFailure  time.Time // 上次失败时间
+	trippedTime  time.Time // 熔断触发时间
+	successCount int       // 半开启状态下成功次数
+	requestCount int       // 总请求数
+	totalSuccess int       // 总成功数
+	totalFailure int       // 总失败数
+
+	window        window      // 关闭状态下的滑动窗口
+	halfOpenCalls int         // 半开启状态下已放行的试探请求数
+	halfOpenStats windowStats // 半开启状态下已完成的试探请求统计
 
 	// 服务标识
-	serviceName    string    // 服务名称，用于监控指标
+	serviceName string // 服务名称，用于监控指标
 
 	// 锁
 	mutex sync.Mutex
@@ -68,6 +126,16 @@ type Config struct {
 	ResetTimeout     time.Duration // 重置超时时间
 	SuccessThreshold int           // 半开启状态下成功阈值
 	ServiceName      string        // 服务名称
+
+	// 滑动窗口模式，WindowType 为空时使用连续失败次数
+	WindowType               WindowType           // WindowCount / WindowTime
+	WindowSize               int                  // 计数窗口为调用次数，时间窗口为秒数，默认 100
+	MinimumCalls             int                  // 窗口内调用数达到该值后才判断是否熔断，默认 10
+	FailureRateThreshold     float64              // 失败率达到该值（百分比）时熔断，默认 50
+	SlowCallDuration         time.Duration        // 耗时超过该值视为慢调用，0 表示不统计
+	SlowCallRateThreshold    float64              // 慢调用率达到该值（百分比）时熔断，0 表示不按慢调用熔断
+	PermittedCallsInHalfOpen int                  // 半开启状态下允许的试探请求数，默认等于 SuccessThreshold
+	IsFailure                func(err error) bool // 错误分类，返回 false 的错误视为成功，默认 IsFailure
 }
 
 // DefaultConfig 默认配置
@@ -88,12 +156,38 @@ func NewCircuitBreaker(config *Config) *CircuitBreaker {
 	}
 
 	cb := &CircuitBreaker{
-		failureThreshold: config.FailureThreshold,
-		timeout:          config.Timeout,
-		resetTimeout:     config.ResetTimeout,
-		successThreshold: config.SuccessThreshold,
-		state:            Closed,
-		serviceName:      config.ServiceName,
+		failureThreshold:      config.FailureThreshold,
+		timeout:               config.Timeout,
+		resetTimeout:          config.ResetTimeout,
+		successThreshold:      config.SuccessThreshold,
+		windowType:            config.WindowType,
+		minimumCalls:          config.MinimumCalls,
+		failureRateThreshold:  config.FailureRateThreshold,
+		slowCallDuration:      config.SlowCallDuration,
+		slowCallRateThreshold: config.SlowCallRateThreshold,
+		permittedHalfOpen:     config.PermittedCallsInHalfOpen,
+		isFailure:             config.IsFailure,
+		state:                 Closed,
+		serviceName:           config.ServiceName,
+	}
+
+	if cb.successThreshold <= 0 {
+		cb.successThreshold = 1
+	}
+	if cb.permittedHalfOpen <= 0 {
+		cb.permittedHalfOpen = cb.successThreshold
+	}
+	if cb.isFailure == nil {
+		cb.isFailure = IsFailure
+	}
+	if cb.windowType != WindowNone {
+		if cb.minimumCalls <= 0 {
+			cb.minimumCalls = 10
+		}
+		if cb.failureRateThreshold <= 0 {
+			cb.failureRateThreshold = 50
+		}
+		cb.window = newWindow(cb.windowType, config.WindowSize)
 	}
 
 	// 初始化监控指标
@@ -102,96 +196,175 @@ func NewCircuitBreaker(config *Config) *CircuitBreaker {
 	return cb
 }
 
-// Execute 执行受保护的函数
+// Execute 执行受保护的函数，fn 执行期间不持有锁，多个调用可以并发执行
 func (cb *CircuitBreaker) Execute(fn func() error) error {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
-
-	// 检查是否可以执行请求
-	if !cb.canExecute() {
+	generation, err := cb.beforeCall()
+	if err != nil {
 		// 记录熔断器触发事件
 		metrics.RecordCircuitBreakerTripped(cb.serviceName)
-		return errors.New("circuit breaker is open")
+		return err
 	}
 
-	// 增加请求数
-	cb.requestCount++
-
-	// 执行函数
-	err := fn()
-
-	// 更新状态
-	cb.updateState(err)
-
-	// 更新监控指标
-	metrics.SetCircuitBreakerState(cb.serviceName, float64(cb.state))
+	start := time.Now()
+	err = fn()
+	cb.afterCall(generation, err, time.Since(start))
 
 	return err
 }
 
-// canExecute 是否可以执行请求
-func (cb *CircuitBreaker) canExecute() bool {
+// beforeCall 检查是否可以执行请求，返回当前的状态代数
+func (cb *CircuitBreaker) beforeCall() (int64, error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
 	switch cb.state {
-	case Closed:
-		return true
 	case Open:
-		// 检查是否可以进入半开启状态
-		if time.Since(cb.trippedTime) >= cb.resetTimeout {
-			cb.state = HalfOpen
-			cb.successCount = 0
-			return true
+		// 检查是否可以进入半开启状态，强制开启时一直拒绝
+		if cb.forced || time.Since(cb.trippedTime) < cb.resetTimeout {
+			return 0, ErrOpen
 		}
-		return false
+		cb.setState(HalfOpen)
+		fallthrough
 	case HalfOpen:
 		// 半开启状态下只允许有限的请求通过
-		return cb.successCount < cb.successThreshold
-	default:
-		return false
+		if cb.halfOpenCalls >= cb.permittedHalfOpen {
+			return 0, ErrTooManyCalls
+		}
+		cb.halfOpenCalls++
 	}
-}
 
-// updateState 更新状态
-func (cb *CircuitBreaker) updateState(err error) {
+	// 增加请求数
 	cb.requestCount++
+	return cb.generation, nil
+}
 
-	if err != nil {
-		// 请求失败
-		cb.failureCount++
+// afterCall 记录调用结果并更新状态
+func (cb *CircuitBreaker) afterCall(generation int64, err error, duration time.Duration) {
+	o := outcome{
+		failed: cb.isFailure(err),
+		slow:   cb.slowCallDuration > 0 && duration >= cb.slowCallDuration,
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	now := time.Now()
+	if o.failed {
 		cb.totalFailure++
-		cb.lastFailure = time.Now()
-
-		switch cb.state {
-		case Closed:
-			// 检查是否需要打开熔断器
-			if cb.failureCount >= cb.failureThreshold {
-				cb.state = Open
-				cb.trippedTime = time.Now()
-			}
-		case HalfOpen:
-			// 半开启状态下失败，重新打开熔断器
-			cb.state = Open
-			cb.trippedTime = time.Now()
-		}
+		cb.lastFailure = now
 	} else {
-		// 请求成功
 		cb.totalSuccess++
+	}
 
-		switch cb.state {
-		case Closed:
-			// 重置失败计数
-			cb.failureCount = 0
-		case HalfOpen:
-			// 半开启状态下成功，增加成功计数
-			cb.successCount++
-			// 如果连续成功次数达到阈值，则关闭熔断器
-			if cb.successCount >= cb.successThreshold {
-				cb.state = Closed
-				cb.failureCount = 0
+	// 状态已经变化或被强制设置，调用结果不再影响当前状态
+	if generation != cb.generation || cb.forced {
+		return
+	}
+
+	switch cb.state {
+	case Closed:
+		if cb.windowType == WindowNone {
+			cb.recordConsecutive(o)
+			return
+		}
+
+		cb.window.record(now, o)
+		if cb.exceeds(cb.window.snapshot(now)) {
+			cb.setState(Open)
+		}
+	case HalfOpen:
+		if cb.windowType == WindowNone {
+			cb.recordHalfOpen(o)
+			return
+		}
+
+		// 试探请求全部完成后按失败率和慢调用率决定开启还是关闭
+		cb.halfOpenStats.calls++
+		if o.failed {
+			cb.halfOpenStats.failures++
+		}
+		if o.slow {
+			cb.halfOpenStats.slow++
+		}
+		if cb.halfOpenStats.calls >= cb.permittedHalfOpen {
+			if cb.exceeds(cb.halfOpenStats) {
+				cb.setState(Open)
+			} else {
+				cb.setState(Closed)
 			}
 		}
 	}
 }
 
+// recordConsecutive 连续失败模式下关闭状态的计数
+func (cb *CircuitBreaker) recordConsecutive(o outcome) {
+	if !o.failed {
+		// 重置失败计数
+		cb.failureCount = 0
+		return
+	}
+
+	// 检查是否需要打开熔断器
+	cb.failureCount++
+	if cb.failureCount >= cb.failureThreshold {
+		cb.setState(Open)
+	}
+}
+
+// recordHalfOpen 连续失败模式下半开启状态的计数
+func (cb *CircuitBreaker) recordHalfOpen(o outcome) {
+	if o.failed {
+		// 半开启状态下失败，重新打开熔断器
+		cb.setState(Open)
+		return
+	}
+
+	// 如果成功次数达到阈值，则关闭熔断器
+	cb.successCount++
+	if cb.successCount >= cb.successThreshold {
+		cb.setState(Closed)
+	}
+}
+
+// exceeds 调用数达到最小值且失败率或慢调用率达到阈值
+func (cb *CircuitBreaker) exceeds(stats windowStats) bool {
+	minimum := cb.minimumCalls
+	if cb.state == HalfOpen {
+		minimum = cb.permittedHalfOpen
+	}
+	if stats.calls < minimum {
+		return false
+	}
+
+	if stats.failureRate() >= cb.failureRateThreshold {
+		return true
+	}
+	return cb.slowCallRateThreshold > 0 && stats.slowCallRate() >= cb.slowCallRateThreshold
+}
+
+// setState 切换状态并重置对应的统计，调用方需持有锁
+func (cb *CircuitBreaker) setState(state State) {
+	cb.state = state
+	cb.generation++
+
+	switch state {
+	case Open:
+		cb.trippedTime = time.Now()
+	case HalfOpen:
+		cb.successCount = 0
+		cb.halfOpenCalls = 0
+		cb.halfOpenStats = windowStats{}
+	case Closed:
+		cb.failureCount = 0
+		if cb.window != nil {
+			cb.window.reset()
+		}
+	}
+
+	// 更新监控指标
+	metrics.SetCircuitBreakerState(cb.serviceName, float64(cb.state))
+}
+
 // State 获取当前状态
 func (cb *CircuitBreaker) State() State {
 	cb.mutex.Lock()
@@ -204,36 +377,74 @@ func (cb *CircuitBreaker) Metrics() map[string]interface{} {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 
+	stats := windowStats{}
+	if cb.window != nil {
+		stats = cb.window.snapshot(time.Now())
+	}
+
 	return map[string]interface{}{
-		"state":            cb.state.String(),
-		"failure_count":    cb.failureCount,
-		"success_count":    cb.successCount,
-		"request_count":    cb.requestCount,
-		"total_success":    cb.totalSuccess,
-		"total_failure":    cb.totalFailure,
-		"last_failure":     cb.lastFailure,
-		"tripped_time":     cb.trippedTime,
-		"service_name":     cb.serviceName,
+		"state":             cb.state.String(),
+		"forced":            cb.forced,
+		"window_type":       string(cb.windowType),
+		"window_calls":      stats.calls,
+		"failure_rate":      stats.failureRate(),
+		"slow_call_rate":    stats.slowCallRate(),
+		"failure_count":     cb.failureCount,
+		"success_count":     cb.successCount,
+		"half_open_calls":   cb.halfOpenCalls,
+		"request_count":     cb.requestCount,
+		"total_success":     cb.totalSuccess,
+		"total_failure":     cb.totalFailure,
+		"last_failure":      cb.lastFailure,
+		"tripped_time":      cb.trippedTime,
+		"service_name":      cb.serviceName,
 		"failure_threshold": cb.failureThreshold,
 		"success_threshold": cb.successThreshold,
-		"timeout":          cb.timeout,
-		"reset_timeout":    cb.resetTimeout,
+		"timeout":           cb.timeout,
+		"reset_timeout":     cb.resetTimeout,
 	}
 }
 
-// Reset 重置熔断器
+// Name 熔断器名称
+func (cb *CircuitBreaker) Name() string {
+	return cb.serviceName
+}
+
+// ForceOpen 强制开启熔断器，拒绝全部请求，直到 Reset 或 ForceClosed
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = true
+	cb.setState(Open)
+}
+
+// ForceClosed 强制关闭熔断器，放行全部请求，失败不会触发熔断，直到 Reset 或 ForceOpen
+func (cb *CircuitBreaker) ForceClosed() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.forced = true
+	cb.setState(Closed)
+}
+
+// Forced 熔断器状态是否被强制设置
+func (cb *CircuitBreaker) Forced() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.forced
+}
+
+// Reset 重置熔断器，同时解除强制状态
 func (cb *CircuitBreaker) Reset() {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 
-	cb.state = Closed
-	cb.failureCount = 0
+	cb.forced = false
+	cb.setState(Closed)
 	cb.successCount = 0
 	cb.lastFailure = time.Time{}
 	cb.trippedTime = time.Time{}
-	
-	// 更新监控指标
-	metrics.SetCircuitBreakerState(cb.serviceName, float64(cb.state))
 }
 
 // IsOpen 熔断器是否开启
@@ -255,4 +466,4 @@ func (cb *CircuitBreaker) IsHalfOpen() bool {
 	cb.mutex.Lock()
 	defer cb.mutex.Unlock()
 	return cb.state == HalfOpen
-}
\ No newline at end of file
+}
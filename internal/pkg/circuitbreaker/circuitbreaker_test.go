@@ -0,0 +1,308 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/cache"
+	"gin-example/internal/pkg/core"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+var errServer = errors.New("server error")
+
+func TestSlidingWindowFailureRate(t *testing.T) {
+	cb := NewCircuitBreaker(&Config{
+		ServiceName:              "test-rate",
+		ResetTimeout:             time.Hour,
+		WindowType:               WindowCount,
+		WindowSize:               10,
+		MinimumCalls:             4,
+		FailureRateThreshold:     50,
+		PermittedCallsInHalfOpen: 2,
+	})
+
+	// 4xx 不计为失败
+	for i := 0; i < 4; i++ {
+		_ = cb.Execute(func() error { return &StatusError{StatusCode: 404} })
+	}
+
+	// 3 次失败 / 7 次调用，再失败一次达到 50%
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(func() error { return errServer })
+	}
+	if !cb.IsClosed() {
+		t.Fatalf("breaker should stay closed below 50%% failure rate, metrics %v", cb.Metrics())
+	}
+	_ = cb.Execute(func() error { return errServer })
+	if !cb.IsOpen() {
+		t.Fatalf("breaker should open at 50%% failure rate, metrics %v", cb.Metrics())
+	}
+	if err := cb.Execute(func() error { return nil }); err != ErrOpen {
+		t.Fatalf("err = %v, want ErrOpen", err)
+	}
+}
+
+func TestSlidingWindowMinimumCalls(t *testing.T) {
+	cb := NewCircuitBreaker(&Config{
+		ServiceName:  "test-minimum",
+		ResetTimeout: time.Hour,
+		WindowType:   WindowCount,
+		MinimumCalls: 4,
+	})
+
+	// 调用数未达到最小值时不熔断
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(func() error { return errServer })
+	}
+	if !cb.IsClosed() {
+		t.Fatal("breaker should stay closed below minimum calls")
+	}
+	_ = cb.Execute(func() error { return errServer })
+	if !cb.IsOpen() {
+		t.Fatal("breaker should open once minimum calls reached")
+	}
+}
+
+func TestHalfOpenPermittedCalls(t *testing.T) {
+	cb := NewCircuitBreaker(&Config{
+		ServiceName:              "test-half-open",
+		ResetTimeout:             time.Millisecond,
+		WindowType:               WindowTime,
+		WindowSize:               10,
+		MinimumCalls:             1,
+		FailureRateThreshold:     50,
+		SlowCallDuration:         10 * time.Millisecond,
+		SlowCallRateThreshold:    100,
+		PermittedCallsInHalfOpen: 2,
+	})
+
+	// 慢调用率达到 100% 时熔断
+	_ = cb.Execute(func() error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if !cb.IsOpen() {
+		t.Fatal("breaker should open on slow calls")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// 半开启状态下只放行 2 个试探请求
+	release := make(chan struct{})
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- cb.Execute(func() error {
+				<-release
+				return nil
+			})
+		}()
+	}
+	for cb.Metrics()["half_open_calls"].(int) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := cb.Execute(func() error { return nil }); err != ErrTooManyCalls {
+		t.Fatalf("err = %v, want ErrTooManyCalls", err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !cb.IsClosed() {
+		t.Fatalf("breaker should close after successful trials, metrics %v", cb.Metrics())
+	}
+}
+
+func TestRegistryPerHost(t *testing.T) {
+	r := NewRegistry(&Config{FailureThreshold: 1, ResetTimeout: time.Hour})
+	r.Configure("payment", &Config{FailureThreshold: 3, ResetTimeout: time.Hour}, "pay.example.com")
+
+	if name := r.ServiceName("pay.example.com:443"); name != "payment" {
+		t.Fatalf("service name = %q", name)
+	}
+
+	_ = r.ForHost("bad.example.com").Execute(func() error { return errServer })
+	if !r.ForHost("bad.example.com").IsOpen() {
+		t.Fatal("bad host should be open")
+	}
+	if !r.ForHost("good.example.com").IsClosed() {
+		t.Fatal("other hosts should not be affected")
+	}
+
+	_ = r.ForHost("pay.example.com").Execute(func() error { return errServer })
+	if !r.Get("payment").IsClosed() {
+		t.Fatal("payment uses its own failure threshold")
+	}
+}
+
+func TestTransportFallback(t *testing.T) {
+	r := NewRegistry(&Config{FailureThreshold: 1, ResetTimeout: time.Hour})
+	_ = r.Get("down.example.com").Execute(func() error { return errServer })
+
+	transport := NewTransport(nil, r, StaticResponse(http.StatusOK, nil, []byte("cached")))
+	req, _ := http.NewRequest(http.MethodGet, "http://down.example.com/x", nil)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "cached" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestForcedStates(t *testing.T) {
+	cb := NewCircuitBreaker(&Config{
+		ServiceName:      "test-forced",
+		FailureThreshold: 1,
+		ResetTimeout:     time.Millisecond,
+	})
+	Register(cb)
+
+	if found, err := Apply("test-forced", ActionForceOpen); !found || err != nil {
+		t.Fatalf("expected breaker to be found, found=%v err=%v", found, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected forced open breaker to reject after reset timeout, got %v", err)
+	}
+
+	cb.ForceClosed()
+	for i := 0; i < 3; i++ {
+		_ = cb.Execute(func() error { return errors.New("boom") })
+	}
+	if cb.State() != Closed || !cb.Forced() {
+		t.Fatalf("expected forced closed breaker to ignore failures, got %s", cb.State())
+	}
+
+	if _, err := Apply("test-forced", ActionReset); err != nil {
+		t.Fatal(err)
+	}
+	_ = cb.Execute(func() error { return errors.New("boom") })
+	if cb.State() != Open || cb.Forced() {
+		t.Fatalf("expected reset breaker to trip normally, got %s", cb.State())
+	}
+}
+
+func TestApplyBeforeRegister(t *testing.T) {
+	// 目录是全局的，使用唯一的名称避免多次运行互相影响
+	name := "test-pending-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if found, err := Apply(name, ActionForceOpen); found || err != nil {
+		t.Fatalf("expected pending action, found=%v err=%v", found, err)
+	}
+
+	cb := NewCircuitBreaker(&Config{ServiceName: name})
+	Register(cb)
+	if cb.State() != Open || !cb.Forced() {
+		t.Fatalf("expected pending force open to apply on register, got %s", cb.State())
+	}
+
+	if _, err := Apply(name, "unknown"); !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("expected ErrUnknownAction, got %v", err)
+	}
+}
+
+func TestPropagatorRestoreResetsMissedStates(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	kept := NewCircuitBreaker(&Config{ServiceName: "test-restore-kept-" + suffix})
+	missed := NewCircuitBreaker(&Config{ServiceName: "test-restore-missed-" + suffix})
+	Register(kept)
+	Register(missed)
+	_, _ = Apply(kept.Name(), ActionForceOpen)
+	_, _ = Apply(missed.Name(), ActionForceOpen)
+
+	// Hash 中只剩 kept，missed 的 reset 在断线期间广播，已经错过
+	payload, _ := json.Marshal(&Command{Name: kept.Name(), Action: ActionForceOpen})
+	mr.HSet(DefaultChannel, kept.Name(), string(payload))
+
+	p := NewPropagator(client, zap.NewNop(), "")
+	defer p.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for missed.Forced() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if missed.Forced() || missed.State() != Closed {
+		t.Fatalf("expected breaker reset while disconnected to be reset on restore, got %s", missed.State())
+	}
+	if !kept.Forced() || kept.State() != Open {
+		t.Fatalf("expected breaker still in hash to stay forced open, got %s", kept.State())
+	}
+}
+
+func TestServerMiddlewareFallback(t *testing.T) {
+	mux, err := core.New(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cb := NewCircuitBreaker(&Config{ServiceName: ServerNamePrefix + "test", FailureThreshold: 2, ResetTimeout: time.Hour})
+	failing := false
+	handler := func(ctx core.Context) {
+		if failing {
+			ctx.AbortWithError(core.Error(http.StatusInternalServerError, code.ServerError, "db down"))
+			return
+		}
+		ctx.Payload(map[string]int{"id": 1})
+	}
+
+	group := mux.Group("/api", Middleware(cb,
+		WithCacheFallback(cache.NewLocalCache(100, time.Minute), time.Minute),
+		WithStaticFallback(json.RawMessage(`{"fallback":true}`)),
+	))
+	group.GET("/item", handler)
+	group.GET("/other", handler)
+	group.POST("/item", handler)
+
+	serve := func(method, path string) (int, string) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code, w.Body.String()
+	}
+
+	if status, body := serve(http.MethodGet, "/api/item"); status != http.StatusOK || body != `{"id":1}` {
+		t.Fatalf("unexpected response %d %s", status, body)
+	}
+
+	failing = true
+	serve(http.MethodGet, "/api/item")
+	serve(http.MethodGet, "/api/item")
+	if !cb.IsOpen() {
+		t.Fatalf("expected 5xx responses to open the breaker, got %s", cb.State())
+	}
+
+	if status, body := serve(http.MethodGet, "/api/item"); status != http.StatusOK || body != `{"id":1}` {
+		t.Fatalf("expected cached response, got %d %s", status, body)
+	}
+	if status, body := serve(http.MethodGet, "/api/other"); status != http.StatusOK || body != `{"fallback":true}` {
+		t.Fatalf("expected static fallback, got %d %s", status, body)
+	}
+	// 未受保护的请求方法直接放行
+	if status, _ := serve(http.MethodPost, "/api/item"); status != http.StatusInternalServerError {
+		t.Fatalf("expected POST to reach the handler, got %d", status)
+	}
+
+	cb.ForceOpen()
+	mux.Group("/unavailable", Middleware(cb)).GET("/item", handler)
+	if status, _ := serve(http.MethodGet, "/unavailable/item"); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without fallback, got %d", status)
+	}
+}
@@ -1,88 +1,115 @@
 package circuitbreaker
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"time"
 )
 
-// HTTPClient 熔断器包装的HTTP客户端
+// HTTPClientOption HTTP客户端配置项
+type HTTPClientOption func(*httpClientOption)
+
+type httpClientOption struct {
+	registry *Registry
+	fallback HTTPFallback
+	base     http.RoundTripper
+}
+
+// WithRegistry 使用指定的熔断器注册表，默认按失败阈值创建独立的注册表
+func WithRegistry(registry *Registry) HTTPClientOption {
+	return func(opt *httpClientOption) {
+		opt.registry = registry
+	}
+}
+
+// WithFallback 设置熔断时的降级处理
+func WithFallback(fallback HTTPFallback) HTTPClientOption {
+	return func(opt *httpClientOption) {
+		opt.fallback = fallback
+	}
+}
+
+// WithBaseTransport 设置实际发送请求的 Transport，如 bulkhead.NewTransport，默认 http.DefaultTransport
+func WithBaseTransport(base http.RoundTripper) HTTPClientOption {
+	return func(opt *httpClientOption) {
+		opt.base = base
+	}
+}
+
+// HTTPClient 熔断器包装的HTTP客户端，每个 host 使用独立的熔断器
 type HTTPClient struct {
-	client         *http.Client
-	circuitBreaker *CircuitBreaker
+	client    *http.Client
+	transport *Transport
 }
 
 // NewHTTPClient 创建带熔断器的HTTP客户端
-func NewHTTPClient(timeout time.Duration, failureThreshold int) *HTTPClient {
-	// 创建HTTP客户端
-	httpClient := &http.Client{
-		Timeout: timeout,
+func NewHTTPClient(timeout time.Duration, failureThreshold int, options ...HTTPClientOption) *HTTPClient {
+	opt := new(httpClientOption)
+	for _, f := range options {
+		f(opt)
 	}
 
-	config := &Config{
-		FailureThreshold: failureThreshold,
-		ServiceName:      "HTTPClient",
-		Timeout:          timeout,
-		ResetTimeout:     timeout * 2,
-		SuccessThreshold: 1,
+	if opt.registry == nil {
+		// 每个 host 的熔断器：失败阈值为failureThreshold，超时时间为timeout，重置超时时间为timeout*2
+		opt.registry = NewRegistry(&Config{
+			FailureThreshold: failureThreshold,
+			ServiceName:      "HTTPClient",
+			Timeout:          timeout,
+			ResetTimeout:     timeout * 2,
+			SuccessThreshold: 1,
+		})
 	}
-	// 创建熔断器 (失败阈值为failureThreshold，超时时间为timeout，重置超时时间为timeout*2)
-	circuitBreaker := NewCircuitBreaker(config)
+
+	transport := NewTransport(opt.base, opt.registry, opt.fallback)
 
 	return &HTTPClient{
-		client:         httpClient,
-		circuitBreaker: circuitBreaker,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		transport: transport,
 	}
 }
 
 // Get 执行GET请求，带有熔断机制
 func (hc *HTTPClient) Get(ctx context.Context, url string) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-
-	// 使用熔断器执行请求
-	err = hc.circuitBreaker.Execute(func() error {
-		resp, err = hc.client.Get(url)
-		return err
-	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	return resp, err
+	return hc.client.Do(req)
 }
 
 // Post 执行POST请求，带有熔断机制
 func (hc *HTTPClient) Post(ctx context.Context, url, contentType string, body []byte) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-
-	// 使用熔断器执行请求
-	err = hc.circuitBreaker.Execute(func() error {
-		resp, err = hc.client.Post(url, contentType, nil) // 简化处理，忽略body
-		return err
-	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
 
-	return resp, err
+	return hc.client.Do(req)
 }
 
 // Do 执行任意HTTP请求，带有熔断机制
 func (hc *HTTPClient) Do(req *http.Request) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-
-	// 使用熔断器执行请求
-	err = hc.circuitBreaker.Execute(func() error {
-		resp, err = hc.client.Do(req)
-		return err
-	})
-
-	return resp, err
+	return hc.client.Do(req)
 }
 
-// State 获取当前熔断器状态
-func (hc *HTTPClient) State() State {
-	return hc.circuitBreaker.State()
+// State 获取 host 的熔断器状态
+func (hc *HTTPClient) State(host string) State {
+	return hc.transport.Registry().ForHost(host).State()
 }
 
-// Metrics 获取熔断器统计信息
+// Metrics 获取各 host 熔断器的统计信息
 func (hc *HTTPClient) Metrics() map[string]interface{} {
-	return hc.circuitBreaker.Metrics()
+	registry := hc.transport.Registry()
+
+	metrics := make(map[string]interface{})
+	for _, name := range registry.Names() {
+		metrics[name] = registry.Get(name).Metrics()
+	}
+	return metrics
 }
@@ -0,0 +1,167 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gin-example/internal/pkg/idgen"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// DefaultChannel 默认的管理操作广播频道，强制状态保存在同名的 Hash 中
+const DefaultChannel = "circuitbreaker:commands"
+
+// Command 熔断器管理操作
+type Command struct {
+	Origin   string `json:"origin"`   // 发起操作的实例ID
+	Name     string `json:"name"`     // 熔断器名称
+	Action   string `json:"action"`   // force_open / force_closed / reset
+	Operator string `json:"operator"` // 操作人
+	Reason   string `json:"reason"`   // 操作原因
+	Time     string `json:"time"`     // 操作时间，格式：2006-01-02 15:04:05
+}
+
+// Propagator 通过 Redis 将强制状态同步到所有实例
+// 操作通过 Pub/Sub 广播，当前的强制状态保存在 Hash 中，实例启动或重新订阅后从 Hash 恢复
+type Propagator struct {
+	client     redis.UniversalClient
+	channel    string
+	instanceID string
+	logger     *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+// NewPropagator 创建强制状态同步，channel 为空时使用 DefaultChannel
+func NewPropagator(client redis.UniversalClient, logger *zap.Logger, channel string) *Propagator {
+	if channel == "" {
+		channel = DefaultChannel
+	}
+
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Propagator{
+		client:     client,
+		channel:    channel,
+		instanceID: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), idgen.GenerateUniqueID()),
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+		pubsub:     client.Subscribe(ctx, channel),
+		done:       make(chan struct{}),
+	}
+	go p.subscribe()
+
+	return p
+}
+
+// InstanceID 当前实例的唯一标识
+func (p *Propagator) InstanceID() string {
+	return p.instanceID
+}
+
+// Publish 保存强制状态并广播给其他实例，reset 会清除保存的强制状态
+func (p *Propagator) Publish(ctx context.Context, cmd *Command) error {
+	cmd.Origin = p.instanceID
+
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if cmd.Action == ActionReset {
+			pipe.HDel(ctx, p.channel, cmd.Name)
+		} else {
+			pipe.HSet(ctx, p.channel, cmd.Name, payload)
+		}
+		pipe.Publish(ctx, p.channel, payload)
+		return nil
+	})
+	return err
+}
+
+// Close 停止订阅
+func (p *Propagator) Close() error {
+	p.cancel()
+	err := p.pubsub.Close()
+	<-p.done
+	return err
+}
+
+// subscribe 订阅其他实例的管理操作，连接断开后由客户端自动重连重新订阅
+func (p *Propagator) subscribe() {
+	defer close(p.done)
+
+	for msg := range p.pubsub.ChannelWithSubscriptions(p.ctx, 100) {
+		switch v := msg.(type) {
+		case *redis.Subscription:
+			// 首次订阅和重连后都从 Hash 恢复，断线期间可能错过广播
+			if v.Kind == "subscribe" {
+				p.restore()
+			}
+		case *redis.Message:
+			p.handle(v.Payload)
+		}
+	}
+}
+
+// restore 应用 Redis 中保存的强制状态，本地强制但 Hash 中已经没有的说明断线期间被 reset，一并重置
+func (p *Propagator) restore() {
+	ctx, cancel := context.WithTimeout(p.ctx, 5*time.Second)
+	defer cancel()
+
+	states, err := p.client.HGetAll(ctx, p.channel).Result()
+	if err != nil {
+		p.logger.Warn("restore forced circuit breaker states failed", zap.String("key", p.channel), zap.Error(err))
+		return
+	}
+
+	for _, payload := range states {
+		var cmd Command
+		if err := json.Unmarshal([]byte(payload), &cmd); err != nil {
+			continue
+		}
+		_, _ = Apply(cmd.Name, cmd.Action)
+	}
+
+	for _, name := range forcedNames() {
+		if _, ok := states[name]; !ok {
+			_, _ = Apply(name, ActionReset)
+			p.logger.Info("circuit breaker reset missed while disconnected", zap.String("name", name))
+		}
+	}
+}
+
+// handle 应用其他实例广播的管理操作
+func (p *Propagator) handle(payload string) {
+	var cmd Command
+	if err := json.Unmarshal([]byte(payload), &cmd); err != nil {
+		p.logger.Warn("invalid circuit breaker command", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	// 忽略自身发出的操作
+	if cmd.Origin == p.instanceID {
+		return
+	}
+
+	found, err := Apply(cmd.Name, cmd.Action)
+	p.logger.Info("circuit breaker command applied from remote",
+		zap.String("name", cmd.Name),
+		zap.String("action", cmd.Action),
+		zap.String("operator", cmd.Operator),
+		zap.String("reason", cmd.Reason),
+		zap.String("origin", cmd.Origin),
+		zap.Bool("found", found),
+		zap.Error(err))
+}
@@ -0,0 +1,174 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"gin-example/configs"
+	"gin-example/internal/pkg/hostmap"
+)
+
+// Registry 熔断器注册表，按服务名称（或 host）分别维护熔断器，一个上游故障不影响其他上游
+type Registry struct {
+	mu       sync.RWMutex
+	defaults Config
+	configs  map[string]Config // 服务名称 -> 配置
+	hosts    hostmap.Map
+	breakers map[string]*CircuitBreaker
+	catalog  bool // 创建的熔断器加入全局目录
+}
+
+// NewRegistry 创建熔断器注册表，未单独配置的服务使用 defaults
+func NewRegistry(defaults *Config) *Registry {
+	if defaults == nil {
+		defaults = DefaultConfig()
+	}
+
+	return &Registry{
+		defaults: *defaults,
+		configs:  make(map[string]Config),
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Configure 设置服务的熔断器配置，hosts 中的 host 共用该服务的熔断器
+// 已经创建的熔断器不受影响，应在使用前完成配置
+func (r *Registry) Configure(name string, config *Config, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if config != nil {
+		r.configs[name] = *config
+	}
+	r.hosts.Set(name, hosts...)
+}
+
+// ServiceName 返回 host 所属的服务名称，未配置时为去掉默认端口的 host
+func (r *Registry) ServiceName(host string) string {
+	return r.hosts.ServiceName(host)
+}
+
+// Configured 服务是否单独配置了熔断器
+func (r *Registry) Configured(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.configs[name]
+	return ok
+}
+
+// ForHost 返回 host 所属服务的熔断器
+func (r *Registry) ForHost(host string) *CircuitBreaker {
+	return r.Get(r.ServiceName(host))
+}
+
+// Get 返回服务的熔断器，不存在时按配置创建
+func (r *Registry) Get(name string) *CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cb, ok := r.breakers[name]; ok {
+		return cb
+	}
+
+	config, ok := r.configs[name]
+	if !ok {
+		config = r.defaults
+	}
+	config.ServiceName = name
+
+	cb = NewCircuitBreaker(&config)
+	r.breakers[name] = cb
+	if r.catalog {
+		Register(cb)
+	}
+	return cb
+}
+
+// Names 返回已创建的熔断器名称，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// Default 返回按配置文件创建的全局熔断器注册表，供 HTTP 客户端和 gRPC 客户端共用
+func Default() *Registry {
+	defaultRegistryOnce.Do(func() {
+		cfg := configs.Get().CircuitBreaker
+
+		defaultRegistry = NewRegistry(policyConfig(cfg.Default, DefaultConfig()))
+		defaultRegistry.catalog = true
+		for _, service := range cfg.Services {
+			defaultRegistry.Configure(service.Name, policyConfig(service.Policy, &defaultRegistry.defaults), service.Hosts...)
+			// 配置的服务预先创建，管理接口在发生调用前就可以看到和操作
+			defaultRegistry.Get(service.Name)
+		}
+	})
+	return defaultRegistry
+}
+
+// PolicyConfig 将配置文件中的策略转换为熔断器配置，未设置的字段使用 [circuitBreaker.default] 中的值
+func PolicyConfig(name string, policy configs.CircuitBreakerPolicy) *Config {
+	config := policyConfig(policy, &Default().defaults)
+	config.ServiceName = name
+	return config
+}
+
+// policyConfig 将配置文件中的策略转换为熔断器配置，未设置的字段使用 base 中的值
+func policyConfig(policy configs.CircuitBreakerPolicy, base *Config) *Config {
+	config := *base
+
+	if policy.FailureThreshold > 0 {
+		config.FailureThreshold = policy.FailureThreshold
+	}
+	if policy.ResetTimeout > 0 {
+		config.ResetTimeout = policy.ResetTimeout
+	}
+	if policy.WindowType != "" {
+		config.WindowType = WindowType(policy.WindowType)
+	}
+	if policy.WindowSize > 0 {
+		config.WindowSize = policy.WindowSize
+	}
+	if policy.MinimumCalls > 0 {
+		config.MinimumCalls = policy.MinimumCalls
+	}
+	if policy.FailureRateThreshold > 0 {
+		config.FailureRateThreshold = policy.FailureRateThreshold
+	}
+	if policy.SlowCallDuration > 0 {
+		config.SlowCallDuration = policy.SlowCallDuration
+	}
+	if policy.SlowCallRateThreshold > 0 {
+		config.SlowCallRateThreshold = policy.SlowCallRateThreshold
+	}
+	if policy.PermittedCallsInHalfOpen > 0 {
+		config.PermittedCallsInHalfOpen = policy.PermittedCallsInHalfOpen
+	}
+	return &config
+}
+
+// IsRejected 错误是否由熔断器拒绝请求产生，支持被包装的错误（如 *url.Error）
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrOpen) || errors.Is(err, ErrTooManyCalls)
+}
@@ -0,0 +1,183 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/cache"
+	"gin-example/internal/pkg/core"
+
+	"go.uber.org/zap"
+)
+
+// 服务端熔断后的降级方式
+const (
+	// FallbackUnavailable 返回 503 和业务码 ServiceUnavailable（默认）
+	FallbackUnavailable = "unavailable"
+	// FallbackStatic 返回固定的数据
+	FallbackStatic = "static"
+	// FallbackCache 返回缓存的最近一次正常响应，没有缓存时返回 503
+	FallbackCache = "cache"
+)
+
+// ServerNamePrefix 服务端熔断器的名称前缀，路由组 admin 的熔断器名称为 http-server:admin
+const ServerNamePrefix = "http-server:"
+
+// errHandlerPanic 处理器发生 panic，计为失败后继续抛出
+var errHandlerPanic = errors.New("circuit breaker: handler panic")
+
+// responseKeys 缓存降级保存的正常响应，按熔断器和请求区分
+var responseKeys = cache.NewKeySpace("circuitbreaker:{name}:{request}")
+
+// ServerOption 服务端熔断配置项
+type ServerOption func(*serverOption)
+
+type serverOption struct {
+	methods  map[string]bool
+	timeout  time.Duration
+	payload  json.RawMessage
+	cache    cache.Cache
+	cacheTTL time.Duration
+}
+
+// WithMethods 受保护的请求方法，默认 GET / HEAD，其他方法的请求直接放行
+func WithMethods(methods ...string) ServerOption {
+	return func(opt *serverOption) {
+		opt.methods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			opt.methods[strings.ToUpper(method)] = true
+		}
+	}
+}
+
+// WithHandlerTimeout 处理耗时超过 timeout 时计为失败，处理器本身不会被中断
+func WithHandlerTimeout(timeout time.Duration) ServerOption {
+	return func(opt *serverOption) {
+		opt.timeout = timeout
+	}
+}
+
+// WithStaticFallback 熔断时返回固定的 JSON 数据
+func WithStaticFallback(payload json.RawMessage) ServerOption {
+	return func(opt *serverOption) {
+		opt.payload = payload
+	}
+}
+
+// WithCacheFallback 正常响应保存到缓存 ttl 时长，熔断时按请求返回最近一次的正常响应
+// 只适用于响应与调用方无关的接口，否则可能返回其他调用方的数据
+func WithCacheFallback(c cache.Cache, ttl time.Duration) ServerOption {
+	return func(opt *serverOption) {
+		opt.cache = c
+		opt.cacheTTL = ttl
+	}
+}
+
+// Middleware 服务端熔断中间件，路由组使用独立的熔断器
+// 处理器返回 5xx、处理耗时超过 WithHandlerTimeout 或请求 context 超时计为失败，
+// 熔断时按配置降级：固定数据、缓存的正常响应或 503
+func Middleware(cb *CircuitBreaker, options ...ServerOption) core.HandlerFunc {
+	opt := &serverOption{
+		methods: map[string]bool{http.MethodGet: true, http.MethodHead: true},
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	return func(ctx core.Context) {
+		if !opt.methods[ctx.Method()] {
+			ctx.Next()
+			return
+		}
+
+		var recovered interface{}
+		err := cb.Execute(func() (err error) {
+			defer func() {
+				if recovered = recover(); recovered != nil {
+					err = errHandlerPanic
+				}
+			}()
+
+			start := time.Now()
+			ctx.Next()
+			return handlerError(ctx, opt.timeout, time.Since(start))
+		})
+		if recovered != nil {
+			panic(recovered)
+		}
+
+		if IsRejected(err) {
+			opt.fallback(ctx, cb)
+			return
+		}
+
+		if err == nil && opt.cache != nil {
+			opt.store(ctx, cb)
+		}
+	}
+}
+
+// handlerError 根据响应状态和处理耗时判断处理结果
+func handlerError(ctx core.Context, timeout, elapsed time.Duration) error {
+	if status := ctx.ResponseWriter().Status(); status >= http.StatusInternalServerError {
+		return &StatusError{StatusCode: status}
+	}
+	if timeout > 0 && elapsed >= timeout {
+		return context.DeadlineExceeded
+	}
+	if errors.Is(ctx.Request().Context().Err(), context.DeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// fallback 返回降级响应
+func (opt *serverOption) fallback(ctx core.Context, cb *CircuitBreaker) {
+	if opt.cache != nil {
+		var data []byte
+		if err := opt.cache.Get(responseKeys.Key(cb.Name(), requestKey(ctx)), &data); err == nil && len(data) > 0 {
+			ctx.Payload(json.RawMessage(data))
+			ctx.Abort()
+			return
+		}
+	}
+
+	if opt.payload != nil {
+		ctx.Payload(opt.payload)
+		ctx.Abort()
+		return
+	}
+
+	ctx.AbortWithError(core.Error(
+		http.StatusServiceUnavailable,
+		code.ServiceUnavailable,
+		code.Text(code.ServiceUnavailable)),
+	)
+}
+
+// store 保存正常响应，序列化为 JSON 后写入，与缓存实例的编解码器无关
+func (opt *serverOption) store(ctx core.Context, cb *CircuitBreaker) {
+	payload := ctx.GetPayload()
+	if payload == nil {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	if err := opt.cache.Set(responseKeys.Key(cb.Name(), requestKey(ctx)), data, opt.cacheTTL); err != nil {
+		ctx.Logger().Warn("cache circuit breaker fallback response failed", zap.String("name", cb.Name()), zap.Error(err))
+	}
+}
+
+// requestKey 按请求方法和带参数的路径区分缓存的响应
+func requestKey(ctx core.Context) string {
+	return ctx.Method() + ":" + ctx.URI()
+}
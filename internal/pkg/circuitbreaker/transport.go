@@ -0,0 +1,100 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// HTTPFallback 熔断器拒绝请求时的降级处理，可以返回缓存或默认的响应
+type HTTPFallback func(req *http.Request, err error) (*http.Response, error)
+
+// StaticResponse 返回固定响应的降级处理
+func StaticResponse(statusCode int, header http.Header, body []byte) HTTPFallback {
+	return func(req *http.Request, err error) (*http.Response, error) {
+		h := make(http.Header, len(header))
+		for k, v := range header {
+			h[k] = v
+		}
+
+		return &http.Response{
+			Status:        http.StatusText(statusCode),
+			StatusCode:    statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        h,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// Transport 带熔断的 http.RoundTripper，按请求的 host 选择熔断器
+// 5xx 响应计为失败，4xx 响应不会触发熔断
+type Transport struct {
+	base     http.RoundTripper
+	registry *Registry
+	fallback HTTPFallback
+}
+
+// NewTransport 创建带熔断的 Transport，base 为空时使用 http.DefaultTransport，fallback 可以为空
+func NewTransport(base http.RoundTripper, registry *Registry, fallback HTTPFallback) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if registry == nil {
+		registry = Default()
+	}
+
+	return &Transport{
+		base:     base,
+		registry: registry,
+		fallback: fallback,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+
+	err := t.registry.ForHost(req.URL.Host).Execute(func() error {
+		var err error
+		resp, err = t.base.RoundTrip(req)
+		return statusError(resp, err)
+	})
+
+	if IsRejected(err) {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		if t.fallback != nil {
+			return t.fallback(req, err)
+		}
+		return nil, err
+	}
+
+	return resp, unwrapStatusError(err)
+}
+
+// Registry 返回使用的熔断器注册表
+func (t *Transport) Registry() *Registry {
+	return t.registry
+}
+
+// statusError 将 4xx、5xx 响应转换为 StatusError，交由熔断器分类，4xx 不会触发熔断
+func statusError(resp *http.Response, err error) error {
+	if err == nil && resp != nil && resp.StatusCode >= http.StatusBadRequest {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return err
+}
+
+// unwrapStatusError 响应已经返回给调用方，状态码错误不再作为 error 返回
+func unwrapStatusError(err error) error {
+	if _, ok := err.(*StatusError); ok {
+		return nil
+	}
+	return err
+}
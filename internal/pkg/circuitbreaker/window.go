@@ -0,0 +1,149 @@
+package circuitbreaker
+
+import "time"
+
+// WindowType 滑动窗口类型
+type WindowType string
+
+const (
+	// WindowNone 不使用滑动窗口，连续失败 FailureThreshold 次后熔断（默认）
+	WindowNone WindowType = ""
+	// WindowCount 基于次数的滑动窗口，统计最近 WindowSize 次调用
+	WindowCount WindowType = "count"
+	// WindowTime 基于时间的滑动窗口，统计最近 WindowSize 秒内的调用
+	WindowTime WindowType = "time"
+)
+
+// outcome 单次调用的结果
+type outcome struct {
+	failed bool
+	slow   bool
+}
+
+// window 滑动窗口统计
+type window interface {
+	record(now time.Time, o outcome)
+	snapshot(now time.Time) windowStats
+	reset()
+}
+
+// windowStats 窗口内的调用统计
+type windowStats struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (s windowStats) failureRate() float64 {
+	if s.calls == 0 {
+		return 0
+	}
+	return float64(s.failures) * 100 / float64(s.calls)
+}
+
+func (s windowStats) slowCallRate() float64 {
+	if s.calls == 0 {
+		return 0
+	}
+	return float64(s.slow) * 100 / float64(s.calls)
+}
+
+func newWindow(typ WindowType, size int) window {
+	if size <= 0 {
+		size = 100
+	}
+	if typ == WindowTime {
+		return &timeWindow{buckets: make([]timeBucket, size)}
+	}
+	return &countWindow{outcomes: make([]outcome, size)}
+}
+
+// countWindow 环形缓冲区保存最近 N 次调用的结果
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	filled   int
+	stats    windowStats
+}
+
+func (w *countWindow) record(_ time.Time, o outcome) {
+	if w.filled == len(w.outcomes) {
+		w.remove(w.outcomes[w.next])
+	} else {
+		w.filled++
+	}
+
+	w.outcomes[w.next] = o
+	w.next = (w.next + 1) % len(w.outcomes)
+
+	w.stats.calls++
+	if o.failed {
+		w.stats.failures++
+	}
+	if o.slow {
+		w.stats.slow++
+	}
+}
+
+func (w *countWindow) remove(o outcome) {
+	w.stats.calls--
+	if o.failed {
+		w.stats.failures--
+	}
+	if o.slow {
+		w.stats.slow--
+	}
+}
+
+func (w *countWindow) snapshot(time.Time) windowStats {
+	return w.stats
+}
+
+func (w *countWindow) reset() {
+	w.next, w.filled, w.stats = 0, 0, windowStats{}
+}
+
+// timeWindow 每秒一个桶，统计最近 N 秒的调用
+type timeWindow struct {
+	buckets []timeBucket
+}
+
+type timeBucket struct {
+	second int64
+	stats  windowStats
+}
+
+func (w *timeWindow) record(now time.Time, o outcome) {
+	second := now.Unix()
+	b := &w.buckets[second%int64(len(w.buckets))]
+	if b.second != second {
+		b.second, b.stats = second, windowStats{}
+	}
+
+	b.stats.calls++
+	if o.failed {
+		b.stats.failures++
+	}
+	if o.slow {
+		b.stats.slow++
+	}
+}
+
+func (w *timeWindow) snapshot(now time.Time) windowStats {
+	var stats windowStats
+	oldest := now.Unix() - int64(len(w.buckets)) + 1
+	for _, b := range w.buckets {
+		if b.second >= oldest {
+			stats.calls += b.stats.calls
+			stats.failures += b.stats.failures
+			stats.slow += b.stats.slow
+		}
+	}
+	return stats
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = timeBucket{}
+	}
+}
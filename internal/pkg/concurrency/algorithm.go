@@ -0,0 +1,225 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// AlgorithmAIMD 加性增、乘性减，出现丢弃或超时后按比例收缩
+	AlgorithmAIMD = "aimd"
+	// AlgorithmVegas 按 RTT 相对空载 RTT 的增长估算排队量
+	AlgorithmVegas = "vegas"
+	// AlgorithmGradient 按短期 RTT 与长期 RTT 的比值调整
+	AlgorithmGradient = "gradient"
+)
+
+// Sample 一次请求的测量结果
+type Sample struct {
+	RTT      time.Duration // 请求耗时
+	InFlight int           // 请求开始时的并发数
+	Dropped  bool          // 请求因过载失败（如超时、下游 503）
+}
+
+// Algorithm 并发上限调整算法，由 Limiter 串行调用，无需自行加锁
+type Algorithm interface {
+	// Limit 当前并发上限
+	Limit() int
+
+	// Update 根据测量结果调整并发上限，返回新的上限
+	Update(sample Sample) int
+}
+
+// Bounds 并发上限的范围
+type Bounds struct {
+	Initial int
+	Min     int
+	Max     int
+}
+
+func (b Bounds) normalize() Bounds {
+	if b.Min <= 0 {
+		b.Min = 1
+	}
+	if b.Max < b.Min {
+		b.Max = 1000
+	}
+	if b.Initial < b.Min || b.Initial > b.Max {
+		b.Initial = b.Min
+	}
+	return b
+}
+
+func (b Bounds) clamp(limit float64) float64 {
+	return math.Min(math.Max(limit, float64(b.Min)), float64(b.Max))
+}
+
+// NewAlgorithm 按名称创建算法，未知名称使用 AlgorithmGradient
+func NewAlgorithm(name string, bounds Bounds, timeout time.Duration) Algorithm {
+	switch name {
+	case AlgorithmAIMD:
+		return NewAIMD(bounds, timeout)
+	case AlgorithmVegas:
+		return NewVegas(bounds)
+	default:
+		return NewGradient(bounds)
+	}
+}
+
+// AIMD 并发数接近上限时上限加一，出现丢弃或耗时超过 timeout 时上限乘以 backoff
+type AIMD struct {
+	bounds  Bounds
+	timeout time.Duration
+	backoff float64
+	limit   float64
+}
+
+// NewAIMD 创建 AIMD 算法，timeout 为 0 时只根据丢弃收缩
+func NewAIMD(bounds Bounds, timeout time.Duration) *AIMD {
+	bounds = bounds.normalize()
+	return &AIMD{
+		bounds:  bounds,
+		timeout: timeout,
+		backoff: 0.9,
+		limit:   float64(bounds.Initial),
+	}
+}
+
+// Limit 当前并发上限
+func (a *AIMD) Limit() int {
+	return int(a.limit)
+}
+
+// Update 根据测量结果调整并发上限
+func (a *AIMD) Update(s Sample) int {
+	switch {
+	case s.Dropped || (a.timeout > 0 && s.RTT > a.timeout):
+		a.limit = a.bounds.clamp(math.Floor(a.limit * a.backoff))
+	case s.InFlight*2 >= int(a.limit):
+		// 并发数不到上限的一半时说明上限没有成为瓶颈，不再增加
+		a.limit = a.bounds.clamp(a.limit + 1)
+	}
+	return int(a.limit)
+}
+
+// Vegas 以观测到的最小 RTT 作为空载 RTT，估算排队请求数 queue = limit * (1 - rttNoLoad / rtt)
+// 排队少于 alpha 时增加上限，多于 beta 时减少上限
+type Vegas struct {
+	bounds    Bounds
+	limit     float64
+	rttNoLoad time.Duration
+	probe     int // 每隔若干次请求重置空载 RTT，适应下游性能的长期变化
+	samples   int
+}
+
+// NewVegas 创建 Vegas 算法
+func NewVegas(bounds Bounds) *Vegas {
+	bounds = bounds.normalize()
+	return &Vegas{
+		bounds: bounds,
+		limit:  float64(bounds.Initial),
+		probe:  1000,
+	}
+}
+
+// Limit 当前并发上限
+func (v *Vegas) Limit() int {
+	return int(v.limit)
+}
+
+// Update 根据测量结果调整并发上限
+func (v *Vegas) Update(s Sample) int {
+	if s.RTT <= 0 {
+		return int(v.limit)
+	}
+
+	v.samples++
+	if v.samples >= v.probe {
+		v.samples = 0
+		v.rttNoLoad = 0
+	}
+	if v.rttNoLoad == 0 || s.RTT < v.rttNoLoad {
+		v.rttNoLoad = s.RTT
+		return int(v.limit)
+	}
+
+	step := math.Max(1, math.Log10(v.limit))
+	if s.Dropped {
+		v.limit = v.bounds.clamp(v.limit - step)
+		return int(v.limit)
+	}
+	if s.InFlight*2 < int(v.limit) {
+		return int(v.limit)
+	}
+
+	queue := v.limit * (1 - float64(v.rttNoLoad)/float64(s.RTT))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case queue <= alpha:
+		v.limit = v.bounds.clamp(v.limit + step)
+	case queue >= beta:
+		v.limit = v.bounds.clamp(v.limit - step)
+	}
+	return int(v.limit)
+}
+
+// Gradient 以长期 RTT 的指数移动平均为基线，gradient = 基线 * tolerance / 当前 RTT，
+// 新上限 = limit * gradient + sqrt(limit)，再与旧上限做平滑
+type Gradient struct {
+	bounds    Bounds
+	limit     float64
+	longRTT   float64 // 长期 RTT 的指数移动平均（秒）
+	window    float64 // 长期 RTT 的平滑窗口（样本数）
+	tolerance float64 // 允许 RTT 相对基线增长的倍数
+	smoothing float64
+}
+
+// NewGradient 创建梯度算法
+func NewGradient(bounds Bounds) *Gradient {
+	bounds = bounds.normalize()
+	return &Gradient{
+		bounds:    bounds,
+		limit:     float64(bounds.Initial),
+		window:    600,
+		tolerance: 1.5,
+		smoothing: 0.2,
+	}
+}
+
+// Limit 当前并发上限
+func (g *Gradient) Limit() int {
+	return int(g.limit)
+}
+
+// Update 根据测量结果调整并发上限
+func (g *Gradient) Update(s Sample) int {
+	if s.RTT <= 0 {
+		return int(g.limit)
+	}
+
+	short := s.RTT.Seconds()
+	if g.longRTT == 0 {
+		g.longRTT = short
+	} else {
+		g.longRTT += (short - g.longRTT) / g.window
+	}
+
+	// 长期基线明显高于当前 RTT 时说明负载已经下降，加快基线回落
+	if g.longRTT/short > 2 {
+		g.longRTT *= 0.95
+	}
+
+	if s.InFlight*2 < int(g.limit) && !s.Dropped {
+		return int(g.limit)
+	}
+
+	gradient := math.Max(0.5, math.Min(1.0, g.tolerance*g.longRTT/short))
+	if s.Dropped {
+		gradient = 0.5
+	}
+
+	newLimit := g.limit*gradient + math.Sqrt(g.limit)
+	newLimit = g.limit*(1-g.smoothing) + newLimit*g.smoothing
+	g.limit = g.bounds.clamp(newLimit)
+	return int(g.limit)
+}
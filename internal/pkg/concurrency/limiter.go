@@ -0,0 +1,169 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"gin-example/internal/metrics"
+)
+
+// Option 并发限制器配置项
+type Option func(*option)
+
+type option struct {
+	maxQueue int
+	maxWait  time.Duration
+}
+
+// WithQueue 并发数达到上限时最多允许 size 个请求排队，每个请求最多等待 wait，默认不排队
+func WithQueue(size int, wait time.Duration) Option {
+	return func(opt *option) {
+		opt.maxQueue = size
+		opt.maxWait = wait
+	}
+}
+
+// Limiter 自适应并发限制器，并发上限由 Algorithm 根据请求耗时动态调整
+type Limiter struct {
+	name      string
+	algorithm Algorithm
+	option    *option
+
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	waiters  *list.List // *waiter，队头最先到达
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// Release 请求结束时调用，rtt 为请求耗时，dropped 表示请求因过载失败
+type Release func(rtt time.Duration, dropped bool)
+
+// NewLimiter 创建自适应并发限制器，name 用于监控指标
+func NewLimiter(name string, algorithm Algorithm, options ...Option) *Limiter {
+	opt := new(option)
+	for _, f := range options {
+		f(opt)
+	}
+
+	l := &Limiter{
+		name:      name,
+		algorithm: algorithm,
+		option:    opt,
+		limit:     algorithm.Limit(),
+		waiters:   list.New(),
+	}
+	metrics.SetConcurrencyLimit(name, l.limit)
+	return l
+}
+
+// Acquire 获取执行许可，并发数达到上限且排队已满或等待超时时返回 false
+// 获取成功后必须调用返回的 Release
+func (l *Limiter) Acquire(ctx context.Context) (Release, bool) {
+	l.mu.Lock()
+	if l.inFlight < l.limit {
+		l.inFlight++
+		inFlight := l.inFlight
+		l.mu.Unlock()
+
+		metrics.SetConcurrencyInFlight(l.name, inFlight)
+		return l.releaser(inFlight), true
+	}
+
+	if l.waiters.Len() >= l.option.maxQueue || l.option.maxWait <= 0 {
+		l.mu.Unlock()
+
+		metrics.RecordConcurrencyShed(l.name)
+		return nil, false
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	elem := l.waiters.PushBack(w)
+	metrics.SetConcurrencyQueueDepth(l.name, l.waiters.Len())
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.option.maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	if !w.granted {
+		l.waiters.Remove(elem)
+		depth := l.waiters.Len()
+		l.mu.Unlock()
+
+		metrics.SetConcurrencyQueueDepth(l.name, depth)
+		metrics.RecordConcurrencyShed(l.name)
+		return nil, false
+	}
+	inFlight := l.inFlight
+	l.mu.Unlock()
+
+	return l.releaser(inFlight), true
+}
+
+// releaser 返回只生效一次的 Release
+func (l *Limiter) releaser(inFlight int) Release {
+	var once sync.Once
+	return func(rtt time.Duration, dropped bool) {
+		once.Do(func() {
+			l.release(Sample{RTT: rtt, InFlight: inFlight, Dropped: dropped})
+		})
+	}
+}
+
+func (l *Limiter) release(sample Sample) {
+	l.mu.Lock()
+	l.limit = l.algorithm.Update(sample)
+	l.inFlight--
+
+	// 上限调整后可能空出多个位置，按到达顺序交给排队的请求
+	for l.inFlight < l.limit && l.waiters.Len() > 0 {
+		w := l.waiters.Remove(l.waiters.Front()).(*waiter)
+		w.granted = true
+		close(w.ready)
+		l.inFlight++
+	}
+
+	limit, inFlight, depth := l.limit, l.inFlight, l.waiters.Len()
+	l.mu.Unlock()
+
+	metrics.SetConcurrencyLimit(l.name, limit)
+	metrics.SetConcurrencyInFlight(l.name, inFlight)
+	metrics.SetConcurrencyQueueDepth(l.name, depth)
+}
+
+// Limit 当前并发上限
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+// InFlight 当前正在执行的请求数
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// QueueDepth 当前排队等待的请求数
+func (l *Limiter) QueueDepth() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.waiters.Len()
+}
@@ -0,0 +1,82 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAIMD(t *testing.T) {
+	a := NewAIMD(Bounds{Initial: 10, Min: 2, Max: 12}, 100*time.Millisecond)
+
+	if limit := a.Update(Sample{RTT: time.Millisecond, InFlight: 10}); limit != 11 {
+		t.Fatalf("busy sample limit = %d, want 11", limit)
+	}
+	if limit := a.Update(Sample{RTT: time.Millisecond, InFlight: 1}); limit != 11 {
+		t.Fatalf("idle sample limit = %d, want 11", limit)
+	}
+	if limit := a.Update(Sample{RTT: time.Second, InFlight: 10}); limit != 9 {
+		t.Fatalf("timeout sample limit = %d, want 9", limit)
+	}
+	for i := 0; i < 50; i++ {
+		a.Update(Sample{Dropped: true})
+	}
+	if a.Limit() != 2 {
+		t.Fatalf("limit = %d, want min 2", a.Limit())
+	}
+}
+
+func TestGradientShrinksOnLatency(t *testing.T) {
+	g := NewGradient(Bounds{Initial: 100, Min: 1, Max: 200})
+	for i := 0; i < 100; i++ {
+		g.Update(Sample{RTT: 10 * time.Millisecond, InFlight: 100})
+	}
+	healthy := g.Limit()
+
+	for i := 0; i < 100; i++ {
+		g.Update(Sample{RTT: 100 * time.Millisecond, InFlight: healthy})
+	}
+	if g.Limit() >= healthy {
+		t.Fatalf("limit %d should shrink below %d when latency rises", g.Limit(), healthy)
+	}
+}
+
+func TestLimiterShedAndQueue(t *testing.T) {
+	l := NewLimiter("test", NewAIMD(Bounds{Initial: 1, Min: 1, Max: 1}, 0), WithQueue(1, time.Second))
+
+	release, ok := l.Acquire(context.Background())
+	if !ok {
+		t.Fatal("first acquire should succeed")
+	}
+
+	queued := make(chan bool)
+	go func() {
+		r, ok := l.Acquire(context.Background())
+		if ok {
+			r(time.Millisecond, false)
+		}
+		queued <- ok
+	}()
+
+	// 等待第二个请求进入队列，第三个请求排队已满被拒绝
+	for {
+		l.mu.Lock()
+		n := l.waiters.Len()
+		l.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := l.Acquire(context.Background()); ok {
+		t.Fatal("acquire should be shed when queue is full")
+	}
+
+	release(time.Millisecond, false)
+	if !<-queued {
+		t.Fatal("queued request should be granted after release")
+	}
+	if l.InFlight() != 0 {
+		t.Fatalf("in flight = %d, want 0", l.InFlight())
+	}
+}
@@ -97,6 +97,8 @@ type Context interface {
 	// Payload 正确返回
 	Payload(payload interface{})
 	getPayload() interface{}
+	// GetPayload 获取已设置的返回数据，供中间件在 Next 之后读取
+	GetPayload() interface{}
 
 	// File 文件下载
 	File(filePath string)
@@ -113,6 +115,8 @@ type Context interface {
 	// AbortWithError 错误返回
 	AbortWithError(err BusinessError)
 	abortError() BusinessError
+	// Abort 不再执行后续处理器，已设置的 Payload 正常返回（如降级响应）
+	Abort()
 
 	// Header 获取 Header 对象
 	Header() http.Header
@@ -268,6 +272,10 @@ func (c *context) Payload(payload interface{}) {
 	c.ctx.Set(_PayloadName, payload)
 }
 
+func (c *context) GetPayload() interface{} {
+	return c.getPayload()
+}
+
 func (c *context) File(filePath string) {
 	c.ctx.Writer.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%s", path.Base(filePath)))
 	c.ctx.Writer.Header().Add("Content-Type", "application/octet-stream")
@@ -334,7 +342,12 @@ func (c *context) AbortWithError(err BusinessError) {
 
 func (c *context) abortError() BusinessError {
 	err, _ := c.ctx.Get(_AbortErrorName)
-	return err.(BusinessError)
+	businessError, _ := err.(BusinessError)
+	return businessError
+}
+
+func (c *context) Abort() {
+	c.ctx.Abort()
 }
 
 func (c *context) Alias() string {
@@ -11,6 +11,7 @@ import (
 	_ "gin-example/docs"
 	"gin-example/internal/code"
 	"gin-example/internal/pkg/color"
+	"gin-example/internal/pkg/concurrency"
 	"gin-example/internal/pkg/cors"
 	"gin-example/internal/pkg/env"
 	"gin-example/internal/pkg/errors"
@@ -38,6 +39,14 @@ type option struct {
 	enableCors       bool
 	alertNotify      proposal.AlertHandler
 	recordHandler    proposal.RecordHandler
+	concurrency      *concurrency.Limiter
+	shedder          Shedder
+}
+
+// Shedder 负载保护，过载时决定是否拒绝请求
+type Shedder interface {
+	// Shed 返回 true 表示拒绝请求
+	Shed(ctx Context) bool
 }
 
 // WithEnablePProf 启用 pprof
@@ -76,6 +85,20 @@ func WithEnableCors() Option {
 	}
 }
 
+// WithAdaptiveConcurrency 启用自适应并发限制，超出并发上限的请求返回 503
+func WithAdaptiveConcurrency(limiter *concurrency.Limiter) Option {
+	return func(opt *option) {
+		opt.concurrency = limiter
+	}
+}
+
+// WithLoadShedder 启用负载保护，过载时被拒绝的请求返回 503，在并发限制之前执行
+func WithLoadShedder(shedder Shedder) Option {
+	return func(opt *option) {
+		opt.shedder = shedder
+	}
+}
+
 // DisableTraceLog 禁止记录日志
 func DisableTraceLog(ctx Context) {
 	ctx.disableTrace()
@@ -460,6 +483,38 @@ func New(logger *zap.Logger, options ...Option) (Mux, error) {
 			// endregion
 		}()
 
+		// region 负载保护，过载时优先拒绝低优先级的请求
+		if shedder := opt.shedder; shedder != nil && shedder.Shed(context) {
+			context.AbortWithError(Error(
+				http.StatusServiceUnavailable,
+				code.ServiceUnavailable,
+				code.Text(code.ServiceUnavailable)),
+			)
+			return
+		}
+		// endregion
+
+		// region 自适应并发限制，健康检查等不记录日志的路径不受限制
+		if limiter := opt.concurrency; limiter != nil && !withoutTracePaths[ctx.Request.URL.Path] {
+			release, ok := limiter.Acquire(ctx.Request.Context())
+			if !ok {
+				context.AbortWithError(Error(
+					http.StatusServiceUnavailable,
+					code.ServiceUnavailable,
+					code.Text(code.ServiceUnavailable)),
+				)
+				return
+			}
+
+			// 耗时从获取配额后开始计算，排队等待的时间不计入，否则算法会把排队误判为处理变慢而不断降低限制
+			start := time.Now()
+			defer func() {
+				status := ctx.Writer.Status()
+				release(time.Since(start), status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout)
+			}()
+		}
+		// endregion
+
 		ctx.Next()
 	})
 
@@ -5,9 +5,17 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"sync"
+
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/resilience"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // Server 封装了gRPC服务器
@@ -68,10 +76,59 @@ type Client struct {
 	logger *zap.Logger
 }
 
-// NewClient 创建一个新的gRPC客户端
-func NewClient(logger *zap.Logger, target string) (*Client, error) {
+// Fallback 调用被熔断器、隔离舱或限流拒绝时的降级处理，可以向 reply 写入缓存或默认的结果，返回 nil 表示降级成功
+type Fallback func(ctx context.Context, method string, req, reply interface{}, err error) error
+
+// ClientOption 客户端配置项
+type ClientOption func(*clientOption)
+
+type clientOption struct {
+	pipelines *resilience.Registry
+	registry  *circuitbreaker.Registry
+	fallback  Fallback
+}
+
+// WithPipelines 使用指定的容错策略注册表，默认使用 resilience.Default()
+func WithPipelines(registry *resilience.Registry) ClientOption {
+	return func(opt *clientOption) {
+		opt.pipelines = registry
+	}
+}
+
+// WithBreakerRegistry 使用指定的熔断器注册表，默认使用 circuitbreaker.Default()
+func WithBreakerRegistry(registry *circuitbreaker.Registry) ClientOption {
+	return func(opt *clientOption) {
+		opt.registry = registry
+	}
+}
+
+// WithFallback 设置调用被熔断器、隔离舱或限流拒绝时的降级处理
+func WithFallback(fallback Fallback) ClientOption {
+	return func(opt *clientOption) {
+		opt.fallback = fallback
+	}
+}
+
+// NewClient 创建一个新的gRPC客户端，一元调用使用 target 对应的容错策略
+// ctx 为 core.StdContext 或携带 Trace（trace.NewContext）时容错决策会记录到链路中
+func NewClient(logger *zap.Logger, target string, options ...ClientOption) (*Client, error) {
+	opt := new(clientOption)
+	for _, f := range options {
+		f(opt)
+	}
+	if opt.pipelines == nil {
+		if opt.registry != nil {
+			opt.pipelines = resilience.NewRegistry(opt.registry, nil)
+		} else {
+			opt.pipelines = resilience.Default()
+		}
+	}
+
 	// 建立连接
-	conn, err := grpc.Dial(target, grpc.WithInsecure())
+	conn, err := grpc.Dial(target,
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(pipelineInterceptor(opt.pipelines.ForHost(target), opt.fallback)),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial gRPC server %s: %w", target, err)
 	}
@@ -95,6 +152,78 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// pipelineInterceptor 容错策略拦截器，调用方错误（如参数错误、未找到）不会触发熔断和重试
+// 对冲请求各自写入 reply 的副本，成功后合并到 reply，reply 不是 proto.Message 时不对冲
+func pipelineInterceptor(pipeline *resilience.Pipeline, fallback Fallback) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := pipeline
+		msg, ok := reply.(proto.Message)
+		if !ok {
+			p = pipeline.With(resilience.WithHedge(nil))
+		}
+
+		var once sync.Once
+		err := p.Execute(ctx, func(ctx context.Context) error {
+			out := reply
+			if ok {
+				out = proto.Clone(msg)
+			}
+
+			err := invoker(ctx, method, req, out, cc, opts...)
+			if isClientError(err) {
+				return &clientError{err: err}
+			}
+			if err == nil && ok {
+				once.Do(func() {
+					proto.Reset(msg)
+					proto.Merge(msg, out.(proto.Message))
+				})
+			}
+			return err
+		})
+
+		if resilience.IsRejected(err) {
+			if fallback != nil {
+				return fallback(ctx, method, req, reply, err)
+			}
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		if e, ok := err.(*clientError); ok {
+			return e.err
+		}
+		return err
+	}
+}
+
+// clientError 调用方错误，HTTPCode 返回 400 使熔断器不计为失败
+type clientError struct {
+	err error
+}
+
+func (e *clientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *clientError) HTTPCode() int {
+	return http.StatusBadRequest
+}
+
+// isClientError 由调用方引起的错误
+func isClientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.FailedPrecondition, codes.OutOfRange,
+		codes.Unauthenticated, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
 // HealthCheckService 健康检查服务接口
 type HealthCheckService interface {
 	Check(ctx context.Context) error
@@ -0,0 +1,56 @@
+package hostmap
+
+import (
+	"net"
+	"sync"
+)
+
+// Map host 与下游依赖名称的对应关系，供熔断器、隔离舱和容错策略的注册表共用，零值可以直接使用
+type Map struct {
+	mu    sync.RWMutex
+	hosts map[string]string // host -> 依赖名称
+}
+
+// Set 将 hosts 映射到依赖 name
+func (m *Map) Set(name string, hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.hosts == nil {
+		m.hosts = make(map[string]string, len(hosts))
+	}
+	for _, host := range hosts {
+		m.hosts[host] = name
+	}
+}
+
+// Lookup 查找 host 所属的依赖，host 带有 80、443 端口时同时按去掉端口的 host 查找
+func (m *Map) Lookup(host string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if name, ok := m.hosts[host]; ok {
+		return name, true
+	}
+	if hostname := TrimDefaultPort(host); hostname != host {
+		name, ok := m.hosts[hostname]
+		return name, ok
+	}
+	return "", false
+}
+
+// ServiceName 返回 host 所属的依赖名称，未配置时为去掉默认端口的 host
+func (m *Map) ServiceName(host string) string {
+	if name, ok := m.Lookup(host); ok {
+		return name
+	}
+	return TrimDefaultPort(host)
+}
+
+// TrimDefaultPort 去掉 host 中的 80、443 端口
+func TrimDefaultPort(host string) string {
+	if hostname, port, err := net.SplitHostPort(host); err == nil && (port == "80" || port == "443") {
+		return hostname
+	}
+	return host
+}
@@ -1,7 +1,12 @@
 package httpclient
 
 import (
+	"net/http"
+
+	"gin-example/internal/pkg/bulkhead"
+	"gin-example/internal/pkg/circuitbreaker"
 	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/resilience"
 	"gin-example/internal/pkg/trace"
 
 	"github.com/go-resty/resty/v2"
@@ -11,6 +16,14 @@ type customInterceptor struct {
 	ctx core.StdContext
 }
 
+// OnRequest 请求携带 Trace，容错策略的决策会记录到链路中
+func (i *customInterceptor) OnRequest(client *resty.Client, request *resty.Request) error {
+	if i.ctx.Trace != nil {
+		request.SetContext(trace.NewContext(request.Context(), i.ctx.Trace))
+	}
+	return nil
+}
+
 func (i *customInterceptor) OnResponse(client *resty.Client, response *resty.Response) error {
 	requestInfo := map[string]interface{}{
 		"request-id":   i.ctx.Trace.ID(),
@@ -53,18 +66,78 @@ func (i *customInterceptor) OnResponse(client *resty.Client, response *resty.Res
 	return nil
 }
 
-func GetHttpClientWithContext(ctx core.StdContext) *resty.Client {
-	client := resty.New().EnableTrace()
+// Option 客户端配置项
+type Option func(*option)
+
+type option struct {
+	pipelines *resilience.Registry
+	registry  *circuitbreaker.Registry
+	bulkheads *bulkhead.Registry
+	fallback  circuitbreaker.HTTPFallback
+}
+
+// WithPipelines 使用指定的容错策略注册表，默认使用 resilience.Default()
+func WithPipelines(registry *resilience.Registry) Option {
+	return func(opt *option) {
+		opt.pipelines = registry
+	}
+}
+
+// WithBreakerRegistry 使用指定的熔断器注册表，默认使用 circuitbreaker.Default()
+func WithBreakerRegistry(registry *circuitbreaker.Registry) Option {
+	return func(opt *option) {
+		opt.registry = registry
+	}
+}
+
+// WithBulkheadRegistry 使用指定的隔离舱注册表，默认使用 bulkhead.Default()
+func WithBulkheadRegistry(registry *bulkhead.Registry) Option {
+	return func(opt *option) {
+		opt.bulkheads = registry
+	}
+}
+
+// WithFallback 设置请求被熔断、隔离舱或限流拒绝时的降级处理，可以返回缓存或默认的响应
+func WithFallback(fallback circuitbreaker.HTTPFallback) Option {
+	return func(opt *option) {
+		opt.fallback = fallback
+	}
+}
+
+// newClient 创建按 host 使用容错策略的客户端
+// 只指定熔断器或隔离舱注册表时，使用由它们组成的默认策略
+func newClient(options ...Option) *resty.Client {
+	opt := new(option)
+	for _, f := range options {
+		f(opt)
+	}
+
+	pipelines := opt.pipelines
+	if pipelines == nil {
+		if opt.registry != nil || opt.bulkheads != nil {
+			pipelines = resilience.NewRegistry(opt.registry, opt.bulkheads)
+		} else {
+			pipelines = resilience.Default()
+		}
+	}
+
+	transport := resilience.NewTransport(http.DefaultTransport, pipelines, opt.fallback)
+	return resty.New().SetTransport(transport).EnableTrace()
+}
+
+func GetHttpClientWithContext(ctx core.StdContext, options ...Option) *resty.Client {
+	client := newClient(options...)
 
 	interceptor := &customInterceptor{
 		ctx: ctx,
 	}
 
+	client.OnBeforeRequest(interceptor.OnRequest)
 	client.OnAfterResponse(interceptor.OnResponse)
 
 	return client
 }
 
-func GetHttpClient() *resty.Client {
-	return resty.New().EnableTrace()
+func GetHttpClient(options ...Option) *resty.Client {
+	return newClient(options...)
 }
@@ -0,0 +1,431 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/repository/redis"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// keyPrefix 锁在 Redis 中的键前缀
+	keyPrefix = "lock:"
+
+	// defaultTTL 默认租约时长
+	defaultTTL = 30 * time.Second
+	// defaultRetryInterval 默认重试间隔（实际间隔带随机抖动）
+	defaultRetryInterval = 50 * time.Millisecond
+	// clockDriftFactor Redlock 时钟漂移系数
+	clockDriftFactor = 0.01
+)
+
+var (
+	// ErrNotAcquired 等待超时仍未获得锁
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrNotHeld 锁已不属于当前持有者（租约过期或已释放）
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+var (
+	acquireScript    = redis.GetScript("lock_acquire")
+	releaseScript    = redis.GetScript("lock_release")
+	extendScript     = redis.GetScript("lock_extend")
+	raiseTokenScript = redis.GetScript("lock_raise_token")
+)
+
+// Option 加锁配置项
+type Option func(*option)
+
+type option struct {
+	ttl           time.Duration
+	waitTimeout   time.Duration
+	retryInterval time.Duration
+	autoRenew     bool
+}
+
+// WithTTL 设置租约时长，默认 30s
+func WithTTL(ttl time.Duration) Option {
+	return func(opt *option) {
+		opt.ttl = ttl
+	}
+}
+
+// WithWaitTimeout 设置获取锁的最长等待时间，默认 0 表示只尝试一次
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(opt *option) {
+		opt.waitTimeout = timeout
+	}
+}
+
+// WithRetryInterval 设置等待期间的重试间隔，默认 50ms
+func WithRetryInterval(interval time.Duration) Option {
+	return func(opt *option) {
+		opt.retryInterval = interval
+	}
+}
+
+// WithoutAutoRenew 关闭自动续约，租约到期后锁自动失效
+func WithoutAutoRenew() Option {
+	return func(opt *option) {
+		opt.autoRenew = false
+	}
+}
+
+// Locker 基于 Redis 的分布式锁
+//
+// 只有一个客户端时为单节点模式；多个相互独立的节点时为 Redlock 模式，需要多数节点加锁成功
+type Locker struct {
+	clients []redisV8.UniversalClient
+	quorum  int
+	logger  *zap.Logger
+}
+
+// New 创建单节点模式的分布式锁
+func New(client redisV8.UniversalClient, logger *zap.Logger) *Locker {
+	return NewRedlock([]redisV8.UniversalClient{client}, logger)
+}
+
+// NewRedlock 创建 Redlock 模式的分布式锁，clients 应为相互独立的 Redis 主节点
+func NewRedlock(clients []redisV8.UniversalClient, logger *zap.Logger) *Locker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Locker{
+		clients: clients,
+		quorum:  len(clients)/2 + 1,
+		logger:  logger,
+	}
+}
+
+// Acquire 获取锁，等待超过 WithWaitTimeout 设置的时间后返回 ErrNotAcquired
+//
+// 获取成功后默认在后台自动续约，使用完毕必须调用 Release
+func (l *Locker) Acquire(ctx context.Context, name string, options ...Option) (*Lock, error) {
+	opt := &option{
+		ttl:           defaultTTL,
+		retryInterval: defaultRetryInterval,
+		autoRenew:     true,
+	}
+	for _, f := range options {
+		f(opt)
+	}
+	if opt.ttl <= 0 {
+		opt.ttl = defaultTTL
+	}
+
+	value, err := randomValue()
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用 hash tag 保证集群模式下锁键与 token 计数键位于同一 slot
+	key := keyPrefix + "{" + name + "}"
+	start := time.Now()
+	deadline := start.Add(opt.waitTimeout)
+
+	for attempt := 1; ; attempt++ {
+		token, validity, err := l.tryAcquire(ctx, key, value, opt.ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		if token > 0 {
+			if attempt > 1 {
+				l.logger.Info("lock acquired after contention",
+					zap.String("lock", name),
+					zap.String("trace_id", traceID(ctx)),
+					zap.Int("attempts", attempt),
+					zap.Duration("waited", time.Since(start)),
+				)
+			}
+			return l.newLock(ctx, name, key, value, token, validity, opt), nil
+		}
+
+		wait := jitter(opt.retryInterval)
+		if time.Now().Add(wait).After(deadline) {
+			l.logger.Warn("lock contention, acquire timed out",
+				zap.String("lock", name),
+				zap.String("trace_id", traceID(ctx)),
+				zap.Int("attempts", attempt),
+				zap.Duration("waited", time.Since(start)),
+			)
+			return nil, ErrNotAcquired
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// tryAcquire 尝试在多数节点上加锁，返回 fencing token（0 表示未获得锁）和锁的有效期
+func (l *Locker) tryAcquire(ctx context.Context, key, value string, ttl time.Duration) (int64, time.Duration, error) {
+	start := time.Now()
+	tokens, errs := l.eval(ctx, acquireScript, []string{key, key + ":token"}, value, ttl.Milliseconds())
+
+	var (
+		acquired int
+		token    int64
+		firstErr error
+	)
+	for i := range l.clients {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if tokens[i] > 0 {
+			acquired++
+			if tokens[i] > token {
+				token = tokens[i]
+			}
+		}
+	}
+
+	// 扣除加锁耗时和时钟漂移后的剩余有效期
+	drift := time.Duration(float64(ttl)*clockDriftFactor) + 2*time.Millisecond
+	validity := ttl - time.Since(start) - drift
+
+	if acquired >= l.quorum && validity > 0 {
+		if len(l.clients) > 1 {
+			// 各节点的 token 计数相互独立，将多数节点的计数同步到本次取得的最大值，
+			// 之后任何一次成功加锁都会与这些节点相交，token 因此保持单调递增
+			_, errs := l.eval(ctx, raiseTokenScript, []string{key + ":token"}, token)
+			if countNil(errs) < l.quorum {
+				l.release(ctx, key, value)
+				return 0, 0, nil
+			}
+		}
+		return token, validity, nil
+	}
+
+	// 未达到多数时释放已加上的部分锁
+	if acquired > 0 {
+		l.release(ctx, key, value)
+	}
+
+	// 多数节点不可用时返回错误，而不是当作锁竞争
+	if len(l.clients)-countNil(errs) > len(l.clients)-l.quorum {
+		return 0, 0, firstErr
+	}
+	return 0, 0, nil
+}
+
+// release 在全部节点上安全释放锁，返回成功释放的节点数
+func (l *Locker) release(ctx context.Context, key, value string) (int, error) {
+	results, errs := l.eval(ctx, releaseScript, []string{key}, value)
+	return countPositive(results, errs), firstError(errs)
+}
+
+// extend 在全部节点上续约，返回成功续约的节点数
+func (l *Locker) extend(ctx context.Context, key, value string, ttl time.Duration) (int, error) {
+	results, errs := l.eval(ctx, extendScript, []string{key}, value, ttl.Milliseconds())
+	return countPositive(results, errs), firstError(errs)
+}
+
+// eval 在全部节点上并发执行脚本
+func (l *Locker) eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) ([]int64, []error) {
+	results := make([]int64, len(l.clients))
+	errs := make([]error, len(l.clients))
+
+	if len(l.clients) == 1 {
+		results[0], errs[0] = script.Run(ctx, l.clients[0], keys, args...).Int64()
+		return results, errs
+	}
+
+	var wg sync.WaitGroup
+	for i, client := range l.clients {
+		wg.Add(1)
+		go func(i int, client redisV8.UniversalClient) {
+			defer wg.Done()
+			results[i], errs[i] = script.Run(ctx, client, keys, args...).Int64()
+		}(i, client)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// Lock 已获得的锁
+type Lock struct {
+	locker *Locker
+	name   string
+	key    string
+	value  string
+	token  int64
+	ttl    time.Duration
+
+	traceID string
+	lost    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (l *Locker) newLock(ctx context.Context, name, key, value string, token int64, validity time.Duration, opt *option) *Lock {
+	lk := &Lock{
+		locker:  l,
+		name:    name,
+		key:     key,
+		value:   value,
+		token:   token,
+		ttl:     opt.ttl,
+		traceID: traceID(ctx),
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if opt.autoRenew {
+		go lk.renew(validity)
+	} else {
+		close(lk.done)
+	}
+	return lk
+}
+
+// Token 返回 fencing token，每次成功加锁都比之前的更大
+//
+// 写入受保护的资源时应携带该值，资源方拒绝比已见过的 token 更小的请求，
+// 以防止租约过期后仍以为自己持有锁的旧持有者写入数据
+func (lk *Lock) Token() int64 {
+	return lk.token
+}
+
+// Lost 租约丢失时关闭，持有者应立即停止临界区内的操作
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Release 停止续约并释放锁，锁已不属于当前持有者时返回 ErrNotHeld
+func (lk *Lock) Release(ctx context.Context) error {
+	lk.once.Do(func() {
+		close(lk.stop)
+	})
+	<-lk.done
+
+	released, err := lk.locker.release(ctx, lk.key, lk.value)
+	if released >= lk.locker.quorum {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrNotHeld
+}
+
+// renew 每隔租约的 1/3 续约一次，直到释放或租约丢失
+func (lk *Lock) renew(validity time.Duration) {
+	defer close(lk.done)
+
+	expiresAt := time.Now().Add(validity)
+	ticker := time.NewTicker(lk.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Until(expiresAt))
+		extended, err := lk.locker.extend(ctx, lk.key, lk.value, lk.ttl)
+		cancel()
+
+		if extended >= lk.locker.quorum {
+			drift := time.Duration(float64(lk.ttl)*clockDriftFactor) + 2*time.Millisecond
+			expiresAt = start.Add(lk.ttl - drift)
+			continue
+		}
+
+		// 网络错误时在租约到期前继续重试，锁已被其他持有者占用则立即判定丢失
+		if err != nil && time.Now().Before(expiresAt) {
+			lk.locker.logger.Warn("lock renew failed, will retry",
+				zap.String("lock", lk.name),
+				zap.String("trace_id", lk.traceID),
+				zap.Int64("token", lk.token),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		lk.locker.logger.Error("lock lease lost",
+			zap.String("lock", lk.name),
+			zap.String("trace_id", lk.traceID),
+			zap.Int64("token", lk.token),
+			zap.Error(err),
+		)
+		close(lk.lost)
+		return
+	}
+}
+
+// traceID 从请求上下文中获取 trace ID
+func traceID(ctx context.Context) string {
+	if stdCtx, ok := ctx.(core.StdContext); ok && stdCtx.Trace != nil {
+		return stdCtx.Trace.ID()
+	}
+	return ""
+}
+
+// randomValue 生成持有者标识，多实例之间不会重复
+func randomValue() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "lock: generate value")
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// jitter 在间隔基础上增加最多 50% 的随机抖动，避免多个等待者同时重试
+func jitter(interval time.Duration) time.Duration {
+	if half := int64(interval / 2); half > 0 {
+		return interval + time.Duration(mrand.Int63n(half))
+	}
+	return interval
+}
+
+func countNil(errs []error) int {
+	var n int
+	for _, err := range errs {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func countPositive(results []int64, errs []error) int {
+	var n int
+	for i, v := range results {
+		if errs[i] == nil && v > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redisV8 "github.com/go-redis/redis/v8"
+)
+
+func newUnreachableClient() redisV8.UniversalClient {
+	return redisV8.NewClient(&redisV8.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+}
+
+func newMiniredisClient(t *testing.T) (redisV8.UniversalClient, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redisV8.NewClient(&redisV8.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client, mr
+}
+
+func TestAcquireRelease(t *testing.T) {
+	client, mr := newMiniredisClient(t)
+	locker := New(client, nil)
+	ctx := context.Background()
+
+	lk, err := locker.Acquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("lock:{job}") {
+		t.Fatal("expected lock key to be set")
+	}
+
+	// 已被持有时等待超时返回 ErrNotAcquired
+	if _, err := locker.Acquire(ctx, "job", WithWaitTimeout(100*time.Millisecond), WithRetryInterval(10*time.Millisecond)); err != ErrNotAcquired {
+		t.Fatalf("expected ErrNotAcquired, got %v", err)
+	}
+
+	if err := lk.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("lock:{job}") {
+		t.Fatal("expected lock key to be deleted")
+	}
+	if err := lk.Release(ctx); err != ErrNotHeld {
+		t.Fatalf("expected ErrNotHeld on second release, got %v", err)
+	}
+
+	// 释放后其他调用方可以立即获得锁
+	next, err := locker.Acquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = next.Release(ctx)
+}
+
+func TestReleaseByNonOwner(t *testing.T) {
+	client, mr := newMiniredisClient(t)
+	locker := New(client, nil)
+	ctx := context.Background()
+
+	stale, err := locker.Acquire(ctx, "job", WithTTL(time.Second), WithoutAutoRenew())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 租约过期后锁被其他调用方获得，旧持有者释放不能删除新持有者的锁
+	mr.FastForward(2 * time.Second)
+	owner, err := locker.Acquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stale.Release(ctx); err != ErrNotHeld {
+		t.Fatalf("expected ErrNotHeld for non-owner, got %v", err)
+	}
+	if !mr.Exists("lock:{job}") {
+		t.Fatal("non-owner release deleted the lock")
+	}
+	if err := owner.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaseRenewal(t *testing.T) {
+	client, mr := newMiniredisClient(t)
+	locker := New(client, nil)
+	ctx := context.Background()
+
+	ttl := 300 * time.Millisecond
+	lk, err := locker.Acquire(ctx, "job", WithTTL(ttl))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// miniredis 的过期时间只随 FastForward 推进，每次推进到接近过期后等待续约
+	for i := 0; i < 3; i++ {
+		mr.FastForward(ttl * 2 / 3)
+		time.Sleep(ttl / 2)
+		if !mr.Exists("lock:{job}") || mr.TTL("lock:{job}") <= ttl/3 {
+			t.Fatalf("round %d: expected lease to be renewed, ttl %v", i, mr.TTL("lock:{job}"))
+		}
+	}
+	select {
+	case <-lk.Lost():
+		t.Fatal("renewed lock reported lost")
+	default:
+	}
+	if err := lk.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 锁被其他持有者占用后续约失败，Lost 关闭
+	lk, err = locker.Acquire(ctx, "job", WithTTL(ttl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = mr.Set("lock:{job}", "other")
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected lost lease to be reported")
+	}
+	if err := lk.Release(ctx); err != ErrNotHeld {
+		t.Fatalf("expected ErrNotHeld after lease lost, got %v", err)
+	}
+}
+
+func TestFencingTokensIncrease(t *testing.T) {
+	clients := make([]redisV8.UniversalClient, 3)
+	for i := range clients {
+		clients[i], _ = newMiniredisClient(t)
+	}
+
+	for _, locker := range []*Locker{New(clients[0], nil), NewRedlock(clients, nil)} {
+		var last int64
+		for i := 0; i < 3; i++ {
+			lk, err := locker.Acquire(context.Background(), "fencing", WithoutAutoRenew())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lk.Token() <= last {
+				t.Fatalf("token %d not greater than previous %d", lk.Token(), last)
+			}
+			last = lk.Token()
+			if err := lk.Release(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestAcquireRedisUnavailable(t *testing.T) {
+	clients := []redisV8.UniversalClient{newUnreachableClient(), newUnreachableClient(), newUnreachableClient()}
+	for _, locker := range []*Locker{New(clients[0], nil), NewRedlock(clients, nil)} {
+		_, err := locker.Acquire(context.Background(), "job", WithWaitTimeout(time.Second))
+		if err == nil || err == ErrNotAcquired {
+			t.Fatalf("expected connection error, got %v", err)
+		}
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 2: 2, 3: 2, 5: 3} {
+		clients := make([]redisV8.UniversalClient, n)
+		if got := NewRedlock(clients, nil).quorum; got != want {
+			t.Errorf("quorum(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := jitter(100 * time.Millisecond); d < 100*time.Millisecond || d >= 150*time.Millisecond {
+			t.Fatalf("jitter out of range: %v", d)
+		}
+	}
+}
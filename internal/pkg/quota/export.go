@@ -0,0 +1,114 @@
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-example/internal/repository/redis"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// exportBatch 每次 SCAN 和 pipeline 读取的键数量
+const exportBatch = 1000
+
+// Record 计费导出记录
+type Record struct {
+	Subject string `json:"subject"` // API Key 调用方以摘要代替原始的 Key，见 MaskSubject
+	Plan    string `json:"plan"`    // 导出时调用方绑定的套餐
+	Window  Window `json:"window"`
+	Period  string `json:"period"`
+	Used    int64  `json:"used"`
+}
+
+// Export 导出 at 所在窗口内全部调用方的用量，按调用方排序
+// 只能导出仍在保留期内的窗口，见 WithRetention
+func (m *Manager) Export(ctx context.Context, w Window, at time.Time) ([]Record, error) {
+	if _, err := ParseWindow(string(w)); err != nil {
+		return nil, err
+	}
+
+	period := w.period(at)
+	suffix := "}:" + string(w) + ":" + period
+
+	var records []Record
+	err := redis.ForEachNode(ctx, m.client, func(ctx context.Context, node redisV8.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := node.Scan(ctx, cursor, m.option.keyPrefix+"{*"+suffix, exportBatch).Result()
+			if err != nil {
+				return err
+			}
+
+			if len(keys) > 0 {
+				// 集群模式下各键不在同一个 slot，逐个 GET
+				pipe := node.Pipeline()
+				cmds := make([]*redisV8.StringCmd, len(keys))
+				for i, key := range keys {
+					cmds[i] = pipe.Get(ctx, key)
+				}
+				if _, err := pipe.Exec(ctx); err != nil && err != redisV8.Nil {
+					return err
+				}
+
+				for i, key := range keys {
+					subject, ok := m.parseSubject(key, suffix)
+					if !ok {
+						continue
+					}
+					used, err := strconv.ParseInt(cmds[i].Val(), 10, 64)
+					if err != nil {
+						continue
+					}
+
+					plan, _ := m.PlanOf(subject)
+					records = append(records, Record{
+						Subject: MaskSubject(subject),
+						Plan:    plan.Name,
+						Window:  w,
+						Period:  period,
+						Used:    used,
+					})
+				}
+			}
+
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "quota: export")
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Subject < records[j].Subject
+	})
+	return records, nil
+}
+
+// parseSubject 从计数键中解析调用方
+func (m *Manager) parseSubject(key, suffix string) (string, bool) {
+	prefix := m.option.keyPrefix + "{"
+	if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) || len(key) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	return key[len(prefix) : len(key)-len(suffix)], true
+}
+
+// MaskSubject 将 API Key 调用方替换为 Key 的 SHA-256 摘要前 16 位，如 apikey:sha256:1a2b3c4d5e6f7a8b
+// 导出的数据不包含原始的 Key，需要对账时对配置中的 Key 计算摘要后比对
+func MaskSubject(subject string) string {
+	if !strings.HasPrefix(subject, APIKeySubjectPrefix) {
+		return subject
+	}
+	sum := sha256.Sum256([]byte(strings.TrimPrefix(subject, APIKeySubjectPrefix)))
+	return APIKeySubjectPrefix + "sha256:" + hex.EncodeToString(sum[:8])
+}
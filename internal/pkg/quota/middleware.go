@@ -0,0 +1,91 @@
+package quota
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/metrics"
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/ratelimit"
+
+	"go.uber.org/zap"
+)
+
+// 调用方标识的前缀
+const (
+	// APIKeySubjectPrefix API Key 调用方，如 apikey:<key>
+	APIKeySubjectPrefix = "apikey:"
+	// UserSubjectPrefix 登录用户，如 user:<id>
+	UserSubjectPrefix = "user:"
+)
+
+// SubjectOf 返回请求的调用方，优先使用 API Key，其次使用登录用户，都没有时返回空
+// API Key 和登录用户需由之前的鉴权中间件校验，未校验的请求头可以被调用方任意指定
+func SubjectOf(ctx core.Context) string {
+	if apiKey := ctx.GetHeader(ratelimit.APIKeyHeader); apiKey != "" {
+		return APIKeySubjectPrefix + apiKey
+	}
+	if id := ctx.SessionUserInfo().Id; id != 0 {
+		return UserSubjectPrefix + strconv.Itoa(int(id))
+	}
+	return ""
+}
+
+// Middleware 配额中间件，每个请求消耗一次配额
+// 响应中带有 X-Quota-Limit / X-Quota-Remaining / X-Quota-Reset 头，反映剩余次数最少的窗口
+// 匿名请求和未绑定套餐的调用方不计量；Redis 不可用时放行，避免计量故障影响业务
+func (m *Manager) Middleware() core.HandlerFunc {
+	return func(ctx core.Context) {
+		subject := SubjectOf(ctx)
+		if subject == "" {
+			ctx.Next()
+			return
+		}
+
+		usage, err := m.Consume(ctx.RequestContext(), subject, 1)
+		if err == ErrNoPlan {
+			ctx.Next()
+			return
+		}
+		if err != nil {
+			m.option.logger.Warn("quota consume error", zap.String("subject", subject), zap.Error(err))
+			ctx.Next()
+			return
+		}
+
+		var tightest *WindowUsage
+		for i := range usage.Windows {
+			w := &usage.Windows[i]
+			if w.Window == usage.Exceeded {
+				tightest = w
+				break
+			}
+			if w.Limit > 0 && (tightest == nil || w.Remaining < tightest.Remaining) {
+				tightest = w
+			}
+		}
+
+		if tightest != nil {
+			reset := time.Until(tightest.ResetAt)
+			ctx.SetHeader("X-Quota-Limit", strconv.FormatInt(tightest.Limit, 10))
+			ctx.SetHeader("X-Quota-Remaining", strconv.FormatInt(tightest.Remaining, 10))
+			ctx.SetHeader("X-Quota-Reset", strconv.Itoa(int(reset.Seconds())+1))
+
+			if usage.Exceeded != "" {
+				ctx.SetHeader("Retry-After", strconv.Itoa(int(reset.Seconds())+1))
+
+				metrics.RecordQuotaExceeded(usage.Plan, string(usage.Exceeded))
+				ctx.AbortWithError(core.Error(
+					http.StatusTooManyRequests,
+					code.QuotaExceeded,
+					code.Text(code.QuotaExceeded)),
+				)
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
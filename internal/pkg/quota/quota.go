@@ -0,0 +1,295 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gin-example/internal/pkg/timeutil"
+	"gin-example/internal/repository/redis"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Window 配额窗口，按中国时区的自然日、自然月对齐
+type Window string
+
+const (
+	// WindowDay 自然日
+	WindowDay Window = "day"
+	// WindowMonth 自然月
+	WindowMonth Window = "month"
+)
+
+// windows 参与计数的全部窗口，从短到长
+var windows = []Window{WindowDay, WindowMonth}
+
+var (
+	// ErrNoPlan 调用方未绑定套餐且没有默认套餐
+	ErrNoPlan = errors.New("quota: no plan for subject")
+	// ErrUnknownWindow 不支持的配额窗口
+	ErrUnknownWindow = errors.New("quota: unknown window")
+
+	errUnexpectedReply = errors.New("quota: unexpected script reply")
+
+	consumeScript = redis.GetScript("quota_consume")
+)
+
+// start 返回 t 所在窗口的开始时间
+func (w Window) start(t time.Time) time.Time {
+	if w == WindowMonth {
+		return timeutil.StartOfMonth(t)
+	}
+	return timeutil.StartOfDay(t)
+}
+
+// end 返回 t 所在窗口的结束时间，即下一个窗口的开始时间
+func (w Window) end(t time.Time) time.Time {
+	if w == WindowMonth {
+		return w.start(t).AddDate(0, 1, 0)
+	}
+	return w.start(t).AddDate(0, 0, 1)
+}
+
+// period 返回 t 所在窗口的标识，日窗口为 20060102，月窗口为 200601
+func (w Window) period(t time.Time) string {
+	if w == WindowMonth {
+		return timeutil.Format(t, "200601")
+	}
+	return timeutil.Format(t, "20060102")
+}
+
+// ParseWindow 解析配额窗口名称
+func ParseWindow(name string) (Window, error) {
+	for _, w := range windows {
+		if string(w) == name {
+			return w, nil
+		}
+	}
+	return "", ErrUnknownWindow
+}
+
+// Plan 套餐，配额为 0 表示该窗口不限制
+type Plan struct {
+	Name    string
+	Daily   int64
+	Monthly int64
+}
+
+// limit 返回套餐在窗口内的配额
+func (p Plan) limit(w Window) int64 {
+	if w == WindowMonth {
+		return p.Monthly
+	}
+	return p.Daily
+}
+
+// Usage 调用方的配额使用情况
+type Usage struct {
+	Subject  string        `json:"subject"`
+	Plan     string        `json:"plan"`
+	Windows  []WindowUsage `json:"windows"`
+	Exceeded Window        `json:"exceeded,omitempty"` // 超出配额的窗口
+}
+
+// WindowUsage 单个窗口的配额使用情况
+type WindowUsage struct {
+	Window    Window    `json:"window"`
+	Period    string    `json:"period"`
+	Limit     int64     `json:"limit"`     // 0 表示不限制
+	Used      int64     `json:"used"`      // 已用次数
+	Remaining int64     `json:"remaining"` // 剩余次数，不限制时为 -1
+	ResetAt   time.Time `json:"reset_at"`  // 窗口结束时间
+}
+
+// Option 配额管理器配置项
+type Option func(*option)
+
+type option struct {
+	defaultPlan string
+	bindings    map[string]string
+	keyPrefix   string
+	retention   time.Duration
+	logger      *zap.Logger
+}
+
+// WithDefaultPlan 设置未绑定套餐的调用方使用的套餐，默认不计量
+func WithDefaultPlan(plan string) Option {
+	return func(opt *option) {
+		opt.defaultPlan = plan
+	}
+}
+
+// WithBindings 设置调用方与套餐的绑定关系，键为调用方（user:<id> / apikey:<key>），值为套餐名称
+func WithBindings(bindings map[string]string) Option {
+	return func(opt *option) {
+		opt.bindings = bindings
+	}
+}
+
+// WithKeyPrefix 设置 Redis 键前缀，默认 quota:
+func WithKeyPrefix(prefix string) Option {
+	return func(opt *option) {
+		opt.keyPrefix = prefix
+	}
+}
+
+// WithRetention 设置窗口结束后计数保留的时长，供计费导出，默认 40 天
+func WithRetention(retention time.Duration) Option {
+	return func(opt *option) {
+		opt.retention = retention
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *zap.Logger) Option {
+	return func(opt *option) {
+		opt.logger = logger
+	}
+}
+
+// Manager 调用配额管理器，计数保存在 Redis 中，所有实例共享
+//
+// 计数键为 <prefix>{<subject>}:<window>:<period>，同一调用方的各窗口通过 hash tag 落在同一个 slot
+type Manager struct {
+	client redisV8.UniversalClient
+	plans  map[string]Plan
+	option *option
+}
+
+// New 创建配额管理器
+func New(client redisV8.UniversalClient, plans []Plan, options ...Option) *Manager {
+	opt := &option{
+		keyPrefix: "quota:",
+		retention: 40 * 24 * time.Hour,
+		logger:    zap.NewNop(),
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	m := &Manager{
+		client: client,
+		plans:  make(map[string]Plan, len(plans)),
+		option: opt,
+	}
+	for _, p := range plans {
+		m.plans[p.Name] = p
+	}
+	return m
+}
+
+// PlanOf 返回调用方的套餐
+func (m *Manager) PlanOf(subject string) (Plan, bool) {
+	name, ok := m.option.bindings[subject]
+	if !ok {
+		name = m.option.defaultPlan
+	}
+
+	plan, ok := m.plans[name]
+	return plan, ok
+}
+
+// Consume 消耗 cost 次配额，所有窗口都有余量时才计数
+// 超出配额时返回的 Usage.Exceeded 为超出的窗口，计数不变
+func (m *Manager) Consume(ctx context.Context, subject string, cost int64) (*Usage, error) {
+	plan, ok := m.PlanOf(subject)
+	if !ok {
+		return nil, ErrNoPlan
+	}
+
+	now := time.Now()
+	keys := make([]string, len(windows))
+	args := make([]interface{}, 0, 2*len(windows)+1)
+	args = append(args, cost)
+	for i, w := range windows {
+		keys[i] = m.key(subject, w, now)
+		args = append(args, plan.limit(w))
+	}
+	for _, w := range windows {
+		args = append(args, w.end(now).Add(m.option.retention).Unix())
+	}
+
+	result, err := consumeScript.Run(ctx, m.client, keys, args...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "quota: consume")
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != len(windows)+1 {
+		return nil, errUnexpectedReply
+	}
+
+	used := make([]int64, len(windows))
+	for i := range windows {
+		used[i], _ = values[i+1].(int64)
+	}
+
+	usage := m.usage(subject, plan, now, used)
+	if exceeded, _ := values[0].(int64); exceeded > 0 {
+		usage.Exceeded = windows[exceeded-1]
+	}
+	return usage, nil
+}
+
+// Get 查询调用方当前窗口的配额使用情况，不消耗配额
+func (m *Manager) Get(ctx context.Context, subject string) (*Usage, error) {
+	plan, ok := m.PlanOf(subject)
+	if !ok {
+		return nil, ErrNoPlan
+	}
+
+	now := time.Now()
+	keys := make([]string, len(windows))
+	for i, w := range windows {
+		keys[i] = m.key(subject, w, now)
+	}
+
+	values, err := m.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "quota: get usage")
+	}
+
+	used := make([]int64, len(windows))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			used[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return m.usage(subject, plan, now, used), nil
+}
+
+func (m *Manager) usage(subject string, plan Plan, now time.Time, used []int64) *Usage {
+	usage := &Usage{
+		Subject: subject,
+		Plan:    plan.Name,
+		Windows: make([]WindowUsage, len(windows)),
+	}
+	for i, w := range windows {
+		limit := plan.limit(w)
+		remaining := int64(-1)
+		if limit > 0 {
+			remaining = limit - used[i]
+			if remaining < 0 {
+				remaining = 0
+			}
+		}
+
+		usage.Windows[i] = WindowUsage{
+			Window:    w,
+			Period:    w.period(now),
+			Limit:     limit,
+			Used:      used[i],
+			Remaining: remaining,
+			ResetAt:   w.end(now),
+		}
+	}
+	return usage
+}
+
+// key 返回调用方在 t 所在窗口的计数键
+func (m *Manager) key(subject string, w Window, t time.Time) string {
+	return m.option.keyPrefix + "{" + subject + "}:" + string(w) + ":" + w.period(t)
+}
@@ -0,0 +1,67 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"gin-example/internal/pkg/timeutil"
+)
+
+func TestWindowBoundaries(t *testing.T) {
+	// UTC 1 月 31 日 16:30 是中国时区 2 月 1 日 00:30
+	ts := time.Date(2020, 1, 31, 16, 30, 0, 0, time.UTC)
+
+	if p := WindowDay.period(ts); p != "20200201" {
+		t.Fatalf("day period = %s", p)
+	}
+	if p := WindowMonth.period(ts); p != "202002" {
+		t.Fatalf("month period = %s", p)
+	}
+	if end := WindowDay.end(ts); end.Format(timeutil.CSTLayout) != "2020-02-02 00:00:00" {
+		t.Fatalf("day end = %s", end.Format(timeutil.CSTLayout))
+	}
+	if end := WindowMonth.end(ts); end.Format(timeutil.CSTLayout) != "2020-03-01 00:00:00" {
+		t.Fatalf("month end = %s", end.Format(timeutil.CSTLayout))
+	}
+}
+
+func TestKeyAndPlan(t *testing.T) {
+	m := New(nil, []Plan{{Name: "free", Daily: 10}, {Name: "partner", Monthly: 1000}},
+		WithDefaultPlan("free"),
+		WithBindings(map[string]string{"apikey:abc": "partner"}),
+	)
+
+	ts := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+	key := m.key("apikey:abc", WindowDay, ts)
+	if key != "quota:{apikey:abc}:day:20200201" {
+		t.Fatalf("unexpected key %q", key)
+	}
+	if subject, ok := m.parseSubject(key, "}:day:20200201"); !ok || subject != "apikey:abc" {
+		t.Fatalf("parseSubject = %q, %v", subject, ok)
+	}
+
+	if plan, ok := m.PlanOf("apikey:abc"); !ok || plan.Name != "partner" {
+		t.Fatalf("bound plan = %+v, %v", plan, ok)
+	}
+	if plan, ok := m.PlanOf("user:2"); !ok || plan.Name != "free" {
+		t.Fatalf("default plan = %+v, %v", plan, ok)
+	}
+
+	usage := m.usage("user:2", Plan{Name: "free", Daily: 10}, ts, []int64{12, 12})
+	if usage.Windows[0].Remaining != 0 || usage.Windows[1].Remaining != -1 {
+		t.Fatalf("unexpected usage %+v", usage.Windows)
+	}
+}
+
+func TestMaskSubject(t *testing.T) {
+	if got := MaskSubject("user:1"); got != "user:1" {
+		t.Fatalf("user subject should not be masked, got %q", got)
+	}
+	got := MaskSubject("apikey:secret-key")
+	if got == "apikey:secret-key" || len(got) != len("apikey:sha256:")+16 {
+		t.Fatalf("unexpected masked subject %q", got)
+	}
+	if MaskSubject("apikey:secret-key") != got {
+		t.Fatal("masked subject should be stable")
+	}
+}
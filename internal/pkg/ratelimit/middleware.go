@@ -31,6 +31,10 @@ type RateLimitConfig struct {
 	UserLimitBurst  int  // 每个用户突发请求数
 	EnableUserLimit bool // 是否启用用户限流
 
+	// 限流器回收配置
+	MaxKeys int           // 每种限流最多保留的限流器数量，超出时淘汰最久未使用的
+	IdleTTL time.Duration // 限流器闲置超过该时间后回收
+
 	// 限流响应配置
 	ErrorMessage string // 限流时的错误消息
 	ErrorCode    int    // 限流时的错误码
@@ -48,6 +52,8 @@ func DefaultRateLimitConfig() *RateLimitConfig {
 		UserLimitRPS:    50,
 		UserLimitBurst:  5,
 		EnableUserLimit: false,
+		MaxKeys:         defaultMaxKeys,
+		IdleTTL:         defaultIdleTTL,
 		ErrorMessage:    "请求过于频繁，请稍后再试",
 		ErrorCode:       http.StatusTooManyRequests,
 	}
@@ -57,10 +63,9 @@ func DefaultRateLimitConfig() *RateLimitConfig {
 type RateLimitMiddleware struct {
 	config        *RateLimitConfig
 	globalLimiter rate.Limiter
-	ipLimiters    map[string]*rate.Limiter
-	userLimiters  map[string]*rate.Limiter
+	ipLimiters    *limiterStore
+	userLimiters  *limiterStore
 	limiterPool   sync.Pool
-	mu            sync.RWMutex
 }
 
 // NewRateLimitMiddleware 创建新的限流中间件
@@ -71,8 +76,8 @@ func NewRateLimitMiddleware(config *RateLimitConfig) *RateLimitMiddleware {
 
 	rl := &RateLimitMiddleware{
 		config:       config,
-		ipLimiters:   make(map[string]*rate.Limiter),
-		userLimiters: make(map[string]*rate.Limiter),
+		ipLimiters:   newLimiterStore(rate.Limit(config.IPLimitRPS), config.IPLimitBurst, config.MaxKeys, config.IdleTTL),
+		userLimiters: newLimiterStore(rate.Limit(config.UserLimitRPS), config.UserLimitBurst, config.MaxKeys, config.IdleTTL),
 	}
 
 	// 初始化全局限流器
@@ -87,9 +92,6 @@ func NewRateLimitMiddleware(config *RateLimitConfig) *RateLimitMiddleware {
 		},
 	}
 
-	// 启动定期清理过期限流器的goroutine
-	go rl.cleanupExpiredLimiters()
-
 	return rl
 }
 
@@ -188,68 +190,10 @@ func (rl *RateLimitMiddleware) extractIP(addr string) string {
 
 // getIPLimiter 获取IP对应的限流器
 func (rl *RateLimitMiddleware) getIPLimiter(ip string) *rate.Limiter {
-	rl.mu.RLock()
-	limiter, exists := rl.ipLimiters[ip]
-	rl.mu.RUnlock()
-
-	if !exists {
-		rl.mu.Lock()
-		// 双重检查
-		limiter, exists = rl.ipLimiters[ip]
-		if !exists {
-			limiter = rate.NewLimiter(rate.Limit(rl.config.IPLimitRPS), rl.config.IPLimitBurst)
-			rl.ipLimiters[ip] = limiter
-		}
-		rl.mu.Unlock()
-	}
-
-	return limiter
+	return rl.ipLimiters.get(ip)
 }
 
 // getUserLimiter 获取用户对应的限流器
 func (rl *RateLimitMiddleware) getUserLimiter(userID string) *rate.Limiter {
-	rl.mu.RLock()
-	limiter, exists := rl.userLimiters[userID]
-	rl.mu.RUnlock()
-
-	if !exists {
-		rl.mu.Lock()
-		// 双重检查
-		limiter, exists = rl.userLimiters[userID]
-		if !exists {
-			limiter = rate.NewLimiter(rate.Limit(rl.config.UserLimitRPS), rl.config.UserLimitBurst)
-			rl.userLimiters[userID] = limiter
-		}
-		rl.mu.Unlock()
-	}
-
-	return limiter
-}
-
-// cleanupExpiredLimiters 定期清理过期的限流器
-func (rl *RateLimitMiddleware) cleanupExpiredLimiters() {
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			rl.cleanupLimiters()
-		}
-	}
-}
-
-// cleanupLimiters 清理限流器
-func (rl *RateLimitMiddleware) cleanupLimiters() {
-	// 简化实现：实际项目中可以根据最后使用时间来清理
-	// 这里仅记录日志
-	rl.mu.RLock()
-	ipCount := len(rl.ipLimiters)
-	userCount := len(rl.userLimiters)
-	rl.mu.RUnlock()
-
-	// 记录指标
-	// 注意：这里只是示例，实际项目中应该根据需要记录
-	_ = ipCount
-	_ = userCount
+	return rl.userLimiters.get(userID)
 }
@@ -0,0 +1,239 @@
+package ratelimit
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gin-example/internal/metrics"
+	"gin-example/internal/repository/redis"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// AlgorithmGCRA 通用信元速率算法，只存一个时间戳，支持突发
+	AlgorithmGCRA = "gcra"
+	// AlgorithmSlidingLog 滑动日志算法，窗口内精确计数，内存占用与请求数成正比
+	AlgorithmSlidingLog = "sliding_log"
+
+	// FailLocal Redis 不可用时使用本地限流器（默认）
+	FailLocal = "local"
+	// FailOpen Redis 不可用时全部放行
+	FailOpen = "open"
+	// FailClosed Redis 不可用时全部拒绝
+	FailClosed = "closed"
+)
+
+var (
+	errRedisUnavailable = errors.New("ratelimit: redis unavailable")
+	errUnexpectedReply  = errors.New("ratelimit: unexpected script reply")
+
+	gcraScript       = redis.GetScript("ratelimit_gcra")
+	slidingLogScript = redis.GetScript("ratelimit_sliding_log")
+)
+
+// RedisLimiterOption Redis 限流器配置项
+type RedisLimiterOption func(*redisLimiterOption)
+
+type redisLimiterOption struct {
+	algorithm     string
+	burst         int
+	keyPrefix     string
+	failMode      string
+	fallback      Limiter
+	timeout       time.Duration
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+// WithAlgorithm 设置限流算法，默认 AlgorithmGCRA
+func WithAlgorithm(algorithm string) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.algorithm = algorithm
+	}
+}
+
+// WithBurst 设置 GCRA 允许的突发请求数，默认等于 limit
+func WithBurst(burst int) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.burst = burst
+	}
+}
+
+// WithKeyPrefix 设置 Redis 键前缀，默认 ratelimit:
+func WithKeyPrefix(prefix string) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.keyPrefix = prefix
+	}
+}
+
+// WithFailMode 设置 Redis 不可用时的处理方式：FailLocal / FailOpen / FailClosed
+func WithFailMode(mode string) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.failMode = mode
+	}
+}
+
+// WithFallback 设置 FailLocal 模式下使用的本地限流器，默认按相同速率创建漏桶限流器
+func WithFallback(limiter Limiter) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.fallback = limiter
+	}
+}
+
+// WithRedisTimeout 设置单次 Redis 调用的超时时间，默认 50ms
+func WithRedisTimeout(timeout time.Duration) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.timeout = timeout
+	}
+}
+
+// WithLimiterLogger 设置日志记录器
+func WithLimiterLogger(logger *zap.Logger) RedisLimiterOption {
+	return func(opt *redisLimiterOption) {
+		opt.logger = logger
+	}
+}
+
+// RedisLimiter 基于 Redis 的分布式限流器，所有实例共享同一份配额
+//
+// Redis 调用失败后在 retryInterval 内不再访问 Redis，直接按 failMode 处理，避免每个请求都等待超时
+type RedisLimiter struct {
+	client redisV8.UniversalClient
+	limit  int
+	period time.Duration
+	option *redisLimiterOption
+
+	unavailableUntil int64 // UnixNano，Redis 不可用期间跳过 Redis
+}
+
+// NewRedisLimiter 创建分布式限流器，每个键在 period 内最多允许 limit 个请求
+func NewRedisLimiter(client redisV8.UniversalClient, limit int, period time.Duration, options ...RedisLimiterOption) *RedisLimiter {
+	opt := &redisLimiterOption{
+		algorithm:     AlgorithmGCRA,
+		burst:         limit,
+		keyPrefix:     "ratelimit:",
+		failMode:      FailLocal,
+		timeout:       50 * time.Millisecond,
+		retryInterval: time.Second,
+		logger:        zap.NewNop(),
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	if limit <= 0 {
+		limit = 1
+	}
+	if period <= 0 {
+		period = time.Second
+	}
+	if opt.burst <= 0 {
+		opt.burst = 1
+	}
+	if opt.fallback == nil {
+		opt.fallback = NewLeakyBucketLimiter(float64(limit)/period.Seconds(), opt.burst)
+	}
+
+	return &RedisLimiter{
+		client: client,
+		limit:  limit,
+		period: period,
+		option: opt,
+	}
+}
+
+// Allow 是否允许通过（使用默认键）
+func (l *RedisLimiter) Allow() bool {
+	return l.AllowWithKey("default")
+}
+
+// AllowWithKey 基于键值的限流
+func (l *RedisLimiter) AllowWithKey(key string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), l.option.timeout)
+	defer cancel()
+
+	allowed, _, err := l.take(ctx, key)
+	if err != nil {
+		return l.fail(key, err)
+	}
+	return allowed
+}
+
+// Reserve 检查是否允许通过，不允许时返回需要等待的时间，可用于设置 Retry-After
+func (l *RedisLimiter) Reserve(ctx context.Context, key string) (bool, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, l.option.timeout)
+	defer cancel()
+
+	allowed, wait, err := l.take(ctx, key)
+	if err != nil {
+		return l.fail(key, err), 0
+	}
+	return allowed, wait
+}
+
+// take 在 Redis 中执行限流脚本
+func (l *RedisLimiter) take(ctx context.Context, key string) (bool, time.Duration, error) {
+	if l.client == nil {
+		return false, 0, errRedisUnavailable
+	}
+	if time.Now().UnixNano() < atomic.LoadInt64(&l.unavailableUntil) {
+		return false, 0, errRedisUnavailable
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+	redisKey := l.option.keyPrefix + key
+	switch l.option.algorithm {
+	case AlgorithmSlidingLog:
+		result, err = slidingLogScript.Run(ctx, l.client, []string{redisKey},
+			l.period.Microseconds(), l.limit, strconv.FormatInt(time.Now().UnixNano(), 36)+":"+strconv.FormatInt(rand.Int63(), 36)).Result()
+	default:
+		interval := l.period.Microseconds() / int64(l.limit)
+		if interval < 1 {
+			interval = 1
+		}
+		result, err = gcraScript.Run(ctx, l.client, []string{redisKey},
+			interval, interval*int64(l.option.burst-1)).Result()
+	}
+	if err != nil {
+		atomic.StoreInt64(&l.unavailableUntil, time.Now().Add(l.option.retryInterval).UnixNano())
+		return false, 0, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errUnexpectedReply
+	}
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Microsecond, nil
+}
+
+// fail Redis 不可用时按 failMode 处理
+func (l *RedisLimiter) fail(key string, err error) bool {
+	if err != errRedisUnavailable {
+		l.option.logger.Warn("distributed rate limiter unavailable",
+			zap.String("key", key),
+			zap.String("fail_mode", l.option.failMode),
+			zap.Error(err),
+		)
+	}
+	metrics.RecordRateLimitFallback(l.option.failMode)
+
+	switch l.option.failMode {
+	case FailOpen:
+		return true
+	case FailClosed:
+		return false
+	default:
+		return l.option.fallback.AllowWithKey(key)
+	}
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	redisV8 "github.com/go-redis/redis/v8"
+)
+
+func TestRedisLimiterFailMode(t *testing.T) {
+	client := redisV8.NewClient(&redisV8.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 20 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+
+	open := NewRedisLimiter(client, 1, time.Second, WithFailMode(FailOpen))
+	closed := NewRedisLimiter(client, 1, time.Second, WithFailMode(FailClosed))
+	local := NewRedisLimiter(client, 1, time.Second, WithBurst(2))
+
+	for i := 0; i < 3; i++ {
+		if !open.AllowWithKey("k") {
+			t.Fatal("fail-open limiter should allow")
+		}
+		if closed.AllowWithKey("k") {
+			t.Fatal("fail-closed limiter should reject")
+		}
+	}
+
+	// 本地降级限流器按相同速率限流：突发 2 个之后拒绝
+	if !local.AllowWithKey("k") || !local.AllowWithKey("k") || local.AllowWithKey("k") {
+		t.Fatal("local fallback should allow burst then reject")
+	}
+}
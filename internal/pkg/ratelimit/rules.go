@@ -0,0 +1,470 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gin-example/internal/code"
+	"gin-example/internal/metrics"
+	"gin-example/internal/pkg/core"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// KeyGlobal 所有请求共用一个限流器
+	KeyGlobal = "global"
+	// KeyIP 按客户端 IP 限流（默认）
+	KeyIP = "ip"
+	// KeyUser 按登录用户限流，未登录时按客户端 IP
+	KeyUser = "user"
+	// KeyAPIKey 按 API Key 限流
+	KeyAPIKey = "apikey"
+	// keyHeaderPrefix 按指定请求头限流，如 header:X-Tenant-ID，只信任可信代理转发的请求头，否则按客户端 IP
+	keyHeaderPrefix = "header:"
+
+	// BackendLocal 限流状态保存在本实例内存中（默认），多实例部署时总配额是规则的 N 倍
+	BackendLocal = "local"
+	// BackendRedis 限流状态保存在 Redis 中，所有实例共享同一份配额
+	BackendRedis = "redis"
+
+	// APIKeyHeader 携带 API Key 的请求头
+	APIKeyHeader = "X-API-Key"
+)
+
+// Rule 限流规则，请求匹配的所有规则都会生效
+type Rule struct {
+	Name    string   // 规则名称，用于监控指标和热更新时保留限流状态
+	Path    string   // 路由模式，:param 和 * 匹配一段路径，末尾的 ** 匹配剩余部分
+	Methods []string // 为空时匹配全部方法
+	Roles   []string // 为空时不限制用户角色，否则用户具有任一角色时匹配
+	APIKeys []string // 为空时不限制 API Key，否则请求携带任一 API Key 时匹配
+	RPS     float64  // 每秒请求数
+	Burst   int      // 突发请求数
+	Key     string   // 限流维度：global / ip / user / apikey / header:<name>，默认 ip
+	Backend string   // 限流状态保存位置：local / redis，默认 local
+}
+
+// compiledRule 预处理后的规则
+type compiledRule struct {
+	Rule
+	segments []string
+	methods  map[string]struct{}
+	roles    map[string]struct{}
+	apiKeys  map[string]struct{}
+	limiters *limiterStore
+	redis    *RedisLimiter // Backend 为 redis 时使用，Redis 不可用时降级到 limiters
+}
+
+// RouteLimiter 按规则限流，规则可以在运行时整体替换
+type RouteLimiter struct {
+	maxKeys        int
+	idleTTL        time.Duration
+	trustedProxies []*net.IPNet // 可信代理，只有来自这些地址的请求才使用转发头中的客户端 IP
+	redisClient    redisV8.UniversalClient
+	logger         *zap.Logger
+
+	mu    sync.Mutex   // 串行化规则更新
+	rules atomic.Value // []*compiledRule
+}
+
+// RouteLimiterOption 按规则限流配置项
+type RouteLimiterOption func(*RouteLimiter)
+
+// WithTrustedProxies 设置可信代理，直连地址属于可信代理时才使用 X-Forwarded-For / X-Real-IP 中的客户端 IP
+// 默认不信任任何代理，按直连地址限流，避免调用方通过转发头自行选择限流桶
+func WithTrustedProxies(proxies []*net.IPNet) RouteLimiterOption {
+	return func(l *RouteLimiter) {
+		l.trustedProxies = proxies
+	}
+}
+
+// WithRedisBackend 设置 Backend 为 redis 的规则使用的 Redis 客户端，未设置时这些规则按本地限流
+func WithRedisBackend(client redisV8.UniversalClient) RouteLimiterOption {
+	return func(l *RouteLimiter) {
+		l.redisClient = client
+	}
+}
+
+// WithRouteLogger 设置日志记录器
+func WithRouteLogger(logger *zap.Logger) RouteLimiterOption {
+	return func(l *RouteLimiter) {
+		l.logger = logger
+	}
+}
+
+// ParseTrustedProxies 解析可信代理列表，每项为 IP 或 CIDR
+func ParseTrustedProxies(items []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(items))
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: item}
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// NewRouteLimiter 创建按规则限流的中间件
+// maxKeys 和 idleTTL 控制每条规则保留的限流器数量和闲置回收时间，0 表示使用默认值
+func NewRouteLimiter(rules []Rule, maxKeys int, idleTTL time.Duration, options ...RouteLimiterOption) *RouteLimiter {
+	l := &RouteLimiter{
+		maxKeys: maxKeys,
+		idleTTL: idleTTL,
+		logger:  zap.NewNop(),
+	}
+	for _, f := range options {
+		f(l)
+	}
+	l.rules.Store([]*compiledRule(nil))
+	l.UpdateRules(rules)
+	return l
+}
+
+// UpdateRules 替换全部规则，名称、速率、突发数、限流维度和后端都不变的规则保留已有的限流状态
+func (l *RouteLimiter) UpdateRules(rules []Rule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old := make(map[string]*compiledRule)
+	for _, r := range l.rules.Load().([]*compiledRule) {
+		old[r.Name] = r
+	}
+
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Key == "" {
+			rule.Key = KeyIP
+		}
+		if rule.Backend == "" {
+			rule.Backend = BackendLocal
+		}
+		if strings.HasPrefix(rule.Key, keyHeaderPrefix) && len(l.trustedProxies) == 0 {
+			l.logger.Warn("rate limit rule keyed by header without trusted proxies, client ip is used instead",
+				zap.String("rule", rule.Name), zap.String("key", rule.Key))
+		}
+
+		c := &compiledRule{
+			Rule:     rule,
+			segments: splitPath(rule.Path),
+			methods:  toSet(rule.Methods, strings.ToUpper),
+			roles:    toSet(rule.Roles, nil),
+			apiKeys:  toSet(rule.APIKeys, nil),
+		}
+
+		if prev, ok := old[rule.Name]; ok && prev.RPS == rule.RPS && prev.Burst == rule.Burst && prev.Key == rule.Key && prev.Backend == rule.Backend {
+			c.limiters = prev.limiters
+			c.redis = prev.redis
+		} else {
+			c.limiters = newLimiterStore(rate.Limit(rule.RPS), rule.Burst, l.maxKeys, l.idleTTL)
+			if rule.Backend == BackendRedis {
+				c.redis = l.newRedisLimiter(rule, c.limiters)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+
+	l.rules.Store(compiled)
+}
+
+// newRedisLimiter 创建规则的分布式限流器，Redis 不可用时按规则自身的本地限流器降级
+func (l *RouteLimiter) newRedisLimiter(rule Rule, fallback *limiterStore) *RedisLimiter {
+	if l.redisClient == nil {
+		l.logger.Warn("rate limit rule uses redis backend without redis client, limited locally",
+			zap.String("rule", rule.Name))
+		return nil
+	}
+
+	// GCRA 的发放间隔为 period / limit，limit 取 1 使小数速率也不损失精度
+	period := time.Second
+	if rule.RPS > 0 {
+		period = time.Duration(float64(time.Second) / rule.RPS)
+	}
+	return NewRedisLimiter(l.redisClient, 1, period,
+		WithBurst(rule.Burst),
+		WithKeyPrefix("ratelimit:rule:"+rule.Name+":"),
+		WithFallback(fallback),
+		WithLimiterLogger(l.logger),
+	)
+}
+
+// Rules 返回当前生效的规则
+func (l *RouteLimiter) Rules() []Rule {
+	compiled := l.rules.Load().([]*compiledRule)
+	rules := make([]Rule, len(compiled))
+	for i, c := range compiled {
+		rules[i] = c.Rule
+	}
+	return rules
+}
+
+// Middleware 限流中间件，响应中带有 RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset 头，
+// 被拒绝时额外带有 Retry-After 头。匹配多条规则时响应头反映剩余配额最少的那条本地规则
+func (l *RouteLimiter) Middleware() core.HandlerFunc {
+	return func(ctx core.Context) {
+		var (
+			reservations []*rate.Reservation
+			distributed  []*compiledRule
+			tightest     *quotaState
+			matched      bool
+		)
+
+		now := time.Now()
+		for _, rule := range l.rules.Load().([]*compiledRule) {
+			if !rule.match(ctx) {
+				continue
+			}
+			matched = true
+
+			if rule.redis != nil {
+				distributed = append(distributed, rule)
+				continue
+			}
+
+			limiter := rule.limiters.get(rule.key(ctx, l))
+			reservation := limiter.ReserveN(now, 1)
+			delay := reservation.DelayFrom(now)
+			if !reservation.OK() || delay > 0 {
+				reservation.CancelAt(now)
+				if !reservation.OK() {
+					delay = time.Second
+				}
+				reject(ctx, rule, delay, reservations, now)
+				return
+			}
+			reservations = append(reservations, reservation)
+
+			state := newQuotaState(limiter, rule.Burst, now)
+			if tightest == nil || state.remaining < tightest.remaining {
+				tightest = state
+			}
+		}
+
+		// Redis 中取走的配额无法归还，本地规则都通过后再访问 Redis
+		for _, rule := range distributed {
+			allowed, wait := rule.redis.Reserve(ctx.RequestContext(), rule.key(ctx, l))
+			if !allowed {
+				if wait <= 0 {
+					wait = time.Second
+				}
+				reject(ctx, rule, wait, reservations, now)
+				return
+			}
+		}
+
+		if tightest != nil {
+			setRateLimitHeaders(ctx, tightest)
+		}
+		if matched {
+			metrics.RecordRateLimitAllowed()
+		}
+
+		ctx.Next()
+	}
+}
+
+// reject 拒绝请求，已经从其他规则取得的本地令牌一并归还
+func reject(ctx core.Context, rule *compiledRule, delay time.Duration, reservations []*rate.Reservation, now time.Time) {
+	for _, r := range reservations {
+		r.CancelAt(now)
+	}
+
+	setRateLimitHeaders(ctx, &quotaState{limit: rule.Burst, reset: delay})
+	ctx.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(delay)))
+
+	metrics.RecordRateLimitExceeded("rule:" + rule.Name)
+	ctx.AbortWithError(core.Error(
+		http.StatusTooManyRequests,
+		code.TooManyRequests,
+		code.Text(code.TooManyRequests)),
+	)
+}
+
+// quotaState 限流器当前的配额状态
+type quotaState struct {
+	limit     int
+	remaining int
+	reset     time.Duration // 令牌补满所需的时间
+}
+
+func newQuotaState(limiter *rate.Limiter, burst int, now time.Time) *quotaState {
+	tokens := limiter.TokensAt(now)
+	if tokens < 0 {
+		tokens = 0
+	}
+
+	state := &quotaState{limit: burst, remaining: int(tokens)}
+	if rps := float64(limiter.Limit()); rps > 0 {
+		state.reset = time.Duration((float64(burst) - tokens) / rps * float64(time.Second))
+	}
+	return state
+}
+
+func setRateLimitHeaders(ctx core.Context, state *quotaState) {
+	ctx.SetHeader("RateLimit-Limit", strconv.Itoa(state.limit))
+	ctx.SetHeader("RateLimit-Remaining", strconv.Itoa(state.remaining))
+	ctx.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(state.reset)))
+}
+
+// match 请求是否匹配规则
+func (r *compiledRule) match(ctx core.Context) bool {
+	if len(r.methods) > 0 {
+		if _, ok := r.methods[ctx.Method()]; !ok {
+			return false
+		}
+	}
+
+	if !matchPath(r.segments, splitPath(ctx.Path())) {
+		return false
+	}
+
+	if len(r.roles) > 0 {
+		matched := false
+		for _, role := range ctx.SessionUserInfo().Roles {
+			if _, ok := r.roles[role]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.apiKeys) > 0 {
+		if _, ok := r.apiKeys[ctx.GetHeader(APIKeyHeader)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key 按限流维度提取键
+func (r *compiledRule) key(ctx core.Context, l *RouteLimiter) string {
+	switch {
+	case r.Key == KeyGlobal:
+		return KeyGlobal
+	case r.Key == KeyUser:
+		// 只使用鉴权中间件校验后的用户，未登录时按客户端 IP
+		if id := ctx.SessionUserInfo().Id; id != 0 {
+			return strconv.Itoa(int(id))
+		}
+		return "ip:" + l.clientIP(ctx)
+	case r.Key == KeyAPIKey:
+		return ctx.GetHeader(APIKeyHeader)
+	case strings.HasPrefix(r.Key, keyHeaderPrefix):
+		// 请求头由调用方设置时可以任意更换取值得到新的限流桶，只信任可信代理转发的请求头，缺失时按客户端 IP
+		if l.trusted(extractIP(ctx.RemoteAddr())) {
+			if value := ctx.GetHeader(strings.TrimPrefix(r.Key, keyHeaderPrefix)); value != "" {
+				return "header:" + value
+			}
+		}
+		return "ip:" + l.clientIP(ctx)
+	default:
+		return l.clientIP(ctx)
+	}
+}
+
+// clientIP 获取客户端 IP，直连地址是可信代理时才使用代理设置的请求头
+func (l *RouteLimiter) clientIP(ctx core.Context) string {
+	remote := extractIP(ctx.RemoteAddr())
+	if !l.trusted(remote) {
+		return remote
+	}
+
+	if forwarded := ctx.GetHeader("X-Forwarded-For"); forwarded != "" {
+		// 从右向左跳过可信代理，第一个不可信的地址是最后一个可信代理看到的客户端，左侧的部分可以被调用方伪造
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			if ip := strings.TrimSpace(hops[i]); i == 0 || !l.trusted(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := ctx.GetHeader("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return remote
+}
+
+// trusted 地址是否属于可信代理
+func (l *RouteLimiter) trusted(addr string) bool {
+	if len(l.trustedProxies) == 0 {
+		return false
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 按段匹配路径，:param 和 * 匹配一段，末尾的 ** 匹配剩余的零段或多段
+func matchPath(pattern, path []string) bool {
+	for i, seg := range pattern {
+		if seg == "**" && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(path) {
+			return false
+		}
+		if seg != "*" && !strings.HasPrefix(seg, ":") && seg != path[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(path)
+}
+
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/'
+	})
+}
+
+func toSet(items []string, normalize func(string) string) map[string]struct{} {
+	if len(items) == 0 {
+		return nil
+	}
+
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if normalize != nil {
+			item = normalize(item)
+		}
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
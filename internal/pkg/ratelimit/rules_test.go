@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-example/internal/pkg/core"
+
+	"github.com/alicebob/miniredis/v2"
+	redisV8 "github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/api/**", "/api", true},
+		{"/api/**", "/api/admin/1", true},
+		{"/api/admin/:id", "/api/admin/1", true},
+		{"/api/admin/:id", "/api/admin", false},
+		{"/api/*/list", "/api/user/list", true},
+		{"/api/*/list", "/api/user/list/1", false},
+		{"/api/admin", "/api/admins", false},
+	}
+	for _, c := range cases {
+		if got := matchPath(splitPath(c.pattern), splitPath(c.path)); got != c.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestLimiterStoreEviction(t *testing.T) {
+	s := newLimiterStore(1, 1, 2, time.Hour)
+	a := s.get("a")
+	s.get("b")
+	s.get("a")
+	s.get("c") // 淘汰最久未使用的 b
+
+	if s.len() != 2 {
+		t.Fatalf("len = %d, want 2", s.len())
+	}
+	if s.get("a") != a {
+		t.Fatal("recently used limiter evicted")
+	}
+
+	s = newLimiterStore(1, 1, 100, time.Nanosecond)
+	s.get("a")
+	time.Sleep(time.Millisecond)
+	s.get("b") // 闲置超时的 a 被回收
+	if s.len() != 1 {
+		t.Fatalf("len = %d, want 1", s.len())
+	}
+}
+
+func TestUpdateRulesKeepsState(t *testing.T) {
+	l := NewRouteLimiter([]Rule{{Name: "ip", Path: "/api/**", RPS: 1, Burst: 1}}, 0, 0)
+	store := l.rules.Load().([]*compiledRule)[0].limiters
+
+	l.UpdateRules([]Rule{{Name: "ip", Path: "/api/v2/**", RPS: 1, Burst: 1}})
+	if l.rules.Load().([]*compiledRule)[0].limiters != store {
+		t.Fatal("unchanged rate should keep limiters")
+	}
+
+	l.UpdateRules([]Rule{{Name: "ip", Path: "/api/**", RPS: 2, Burst: 1}})
+	if l.rules.Load().([]*compiledRule)[0].limiters == store {
+		t.Fatal("changed rate should reset limiters")
+	}
+	if rules := l.Rules(); rules[0].Key != KeyIP {
+		t.Fatalf("default key = %q", rules[0].Key)
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expected invalid proxy to be rejected")
+	}
+
+	mux, err := core.New(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewRouteLimiter([]Rule{{Name: "ip", Path: "/api/**", RPS: 0.001, Burst: 1}}, 0, 0, WithTrustedProxies(proxies))
+	mux.Group("/api", l.Middleware()).GET("/item", func(ctx core.Context) {})
+
+	serve := func(remote, forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/item", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 直连地址不可信，伪造的转发头不能换到新的限流桶
+	if serve("198.51.100.7:1234", "1.1.1.1") != http.StatusOK || serve("198.51.100.7:1234", "2.2.2.2") != http.StatusTooManyRequests {
+		t.Fatal("expected forwarding headers from untrusted peers to be ignored")
+	}
+
+	// 经过可信代理时按代理看到的客户端限流，调用方在最左侧伪造的地址被忽略
+	if serve("192.0.2.1:1234", "9.9.9.9, 203.0.113.5, 10.1.1.1") != http.StatusOK {
+		t.Fatal("expected first request from client behind proxies to pass")
+	}
+	if serve("192.0.2.1:1234", "8.8.8.8, 203.0.113.5, 10.1.1.1") != http.StatusTooManyRequests {
+		t.Fatal("expected spoofed leftmost address to share the client bucket")
+	}
+	if serve("192.0.2.1:1234", "203.0.113.6") != http.StatusOK {
+		t.Fatal("expected a different client behind a trusted proxy to get its own bucket")
+	}
+}
+
+func TestHeaderKeyRequiresTrustedProxy(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux, err := core.New(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewRouteLimiter([]Rule{{Name: "tenant", Path: "/api/**", RPS: 0.001, Burst: 1, Key: "header:X-Tenant-ID"}}, 0, 0, WithTrustedProxies(proxies))
+	mux.Group("/api", l.Middleware()).GET("/item", func(ctx core.Context) {})
+
+	serve := func(remote, tenant string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/item", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Tenant-ID", tenant)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 直连调用方更换请求头不能得到新的限流桶
+	if serve("198.51.100.7:1234", "a") != http.StatusOK || serve("198.51.100.7:1234", "b") != http.StatusTooManyRequests {
+		t.Fatal("expected header from untrusted peer to be ignored")
+	}
+
+	// 可信代理转发的请求头按租户限流，缺少请求头时不共用同一个桶
+	if serve("192.0.2.1:1234", "a") != http.StatusOK || serve("192.0.2.1:1234", "a") != http.StatusTooManyRequests {
+		t.Fatal("expected tenant header from trusted proxy to select the bucket")
+	}
+	if serve("192.0.2.1:1234", "b") != http.StatusOK {
+		t.Fatal("expected another tenant to get its own bucket")
+	}
+	if serve("192.0.2.1:1234", "") != http.StatusOK {
+		t.Fatal("expected request without header to be limited by client ip")
+	}
+}
+
+func TestRedisBackendSharedAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redisV8.NewClient(&redisV8.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	rules := []Rule{{Name: "global", Path: "/api/**", RPS: 0.001, Burst: 2, Key: KeyGlobal, Backend: BackendRedis}}
+	serve := func(mux core.Mux) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/item", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 两个实例共享 Redis 中的配额
+	muxes := make([]core.Mux, 2)
+	for i := range muxes {
+		mux, err := core.New(zap.NewNop())
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := NewRouteLimiter(rules, 0, 0, WithRedisBackend(client))
+		mux.Group("/api", l.Middleware()).GET("/item", func(ctx core.Context) {})
+		muxes[i] = mux
+	}
+
+	if serve(muxes[0]) != http.StatusOK || serve(muxes[1]) != http.StatusOK {
+		t.Fatal("expected burst to be allowed")
+	}
+	if serve(muxes[0]) != http.StatusTooManyRequests || serve(muxes[1]) != http.StatusTooManyRequests {
+		t.Fatal("expected shared quota to be exhausted on both instances")
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultMaxKeys 默认最多保留的限流器数量
+	defaultMaxKeys = 10000
+	// defaultIdleTTL 默认的限流器闲置回收时间
+	defaultIdleTTL = 10 * time.Minute
+)
+
+// limiterStore 按键保存限流器，数量超过上限时淘汰最久未使用的，闲置超时的在访问时顺带回收
+//
+// 被回收的限流器令牌桶是满的（闲置时间足够长）或很快会被补满，重新创建不会放过额外的请求
+type limiterStore struct {
+	rate    rate.Limit
+	burst   int
+	maxKeys int
+	idleTTL time.Duration
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // 队头为最近使用
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+func newLimiterStore(r rate.Limit, burst, maxKeys int, idleTTL time.Duration) *limiterStore {
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	if idleTTL <= 0 {
+		idleTTL = defaultIdleTTL
+	}
+
+	return &limiterStore{
+		rate:    r,
+		burst:   burst,
+		maxKeys: maxKeys,
+		idleTTL: idleTTL,
+		items:   make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get 获取键对应的限流器，不存在时创建
+func (s *limiterStore) get(key string) *rate.Limiter {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastUsed = now
+		s.order.MoveToFront(elem)
+		return entry.limiter
+	}
+
+	s.evictLocked(now)
+
+	entry := &limiterEntry{
+		key:      key,
+		limiter:  rate.NewLimiter(s.rate, s.burst),
+		lastUsed: now,
+	}
+	s.items[key] = s.order.PushFront(entry)
+	return entry.limiter
+}
+
+// evictLocked 从队尾回收闲置超时的限流器，仍超过上限时淘汰最久未使用的
+func (s *limiterStore) evictLocked(now time.Time) {
+	for {
+		elem := s.order.Back()
+		if elem == nil {
+			return
+		}
+
+		entry := elem.Value.(*limiterEntry)
+		if len(s.items) < s.maxKeys && now.Sub(entry.lastUsed) < s.idleTTL {
+			return
+		}
+
+		s.order.Remove(elem)
+		delete(s.items, entry.key)
+	}
+}
+
+// Allow 实现 Limiter，作为 Redis 后端不可用时的降级限流器
+func (s *limiterStore) Allow() bool {
+	return s.AllowWithKey(KeyGlobal)
+}
+
+// AllowWithKey 实现 Limiter，按键取本地限流器
+func (s *limiterStore) AllowWithKey(key string) bool {
+	return s.get(key).Allow()
+}
+
+// len 当前保存的限流器数量
+func (s *limiterStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.items)
+}
@@ -0,0 +1,377 @@
+// Package resilience 将超时、重试、限流、熔断、隔离舱、对冲和降级组合为下游调用的容错策略
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"gin-example/internal/metrics"
+	"gin-example/internal/pkg/bulkhead"
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/retry"
+	"gin-example/internal/pkg/timeutil"
+	"gin-example/internal/pkg/trace"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// 策略名称，用于链路和监控指标
+const (
+	StageRetry     = "retry"
+	StageTimeout   = "timeout"
+	StageRateLimit = "ratelimit"
+	StageBreaker   = "breaker"
+	StageHedge     = "hedge"
+	StageBulkhead  = "bulkhead"
+	StageFallback  = "fallback"
+)
+
+// ErrRateLimited 调用下游的请求超过限流
+var ErrRateLimited error = &RateLimitedError{}
+
+// RateLimitedError 限流错误，HTTPCode 返回 429，不会被熔断器计为失败
+type RateLimitedError struct{}
+
+func (e *RateLimitedError) Error() string {
+	return "resilience: rate limit exceeded"
+}
+
+// HTTPCode 限流不是下游的问题
+func (e *RateLimitedError) HTTPCode() int {
+	return http.StatusTooManyRequests
+}
+
+// IsRejected 调用是否在到达下游之前被熔断器、隔离舱或限流拒绝
+func IsRejected(err error) bool {
+	return circuitbreaker.IsRejected(err) || errors.Is(err, bulkhead.ErrFull) || errors.Is(err, ErrRateLimited)
+}
+
+// Func 受保护的调用，应当遵守 ctx 的超时和取消
+type Func func(ctx context.Context) error
+
+// Fallback 调用最终失败时的降级处理，返回 nil 表示降级成功
+type Fallback func(ctx context.Context, err error) error
+
+// Option 容错策略配置项
+type Option func(*Pipeline)
+
+// WithTimeout 每次尝试的超时时间，包括在限流、熔断、隔离舱中等待的时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Pipeline) {
+		p.timeout = timeout
+	}
+}
+
+// WithRetry 重试配置，只使用其中的重试次数和间隔，超时和隔离舱分别由 WithTimeout 和 WithBulkhead 设置
+func WithRetry(config *retry.Config) Option {
+	return func(p *Pipeline) {
+		p.retry = config
+	}
+}
+
+// WithRateLimit 限制调用下游的速率，超过时直接拒绝
+func WithRateLimit(rps float64, burst int) Option {
+	return func(p *Pipeline) {
+		if rps <= 0 {
+			p.limiter = nil
+			return
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		p.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// WithBreaker 使用熔断器，每次尝试（包括其中的对冲请求）计为一次调用
+func WithBreaker(breaker *circuitbreaker.CircuitBreaker) Option {
+	return func(p *Pipeline) {
+		p.breaker = breaker
+	}
+}
+
+// WithBulkhead 使用隔离舱，每个对冲请求单独占用配额
+func WithBulkhead(b bulkhead.Bulkhead) Option {
+	return func(p *Pipeline) {
+		p.bulkhead = b
+	}
+}
+
+// WithHedge 对冲请求，config 为空或 MaxAttempts 小于 2 时不对冲
+func WithHedge(config *retry.HedgeConfig) Option {
+	return func(p *Pipeline) {
+		if config == nil || config.MaxAttempts < 2 {
+			p.hedger = nil
+			return
+		}
+		p.hedger = retry.NewHedger(p.name, config)
+	}
+}
+
+// WithFallback 调用最终失败时的降级处理
+func WithFallback(fallback Fallback) Option {
+	return func(p *Pipeline) {
+		p.fallback = fallback
+	}
+}
+
+// WithLogger 记录重试和降级日志
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Pipeline) {
+		p.logger = logger
+	}
+}
+
+// Pipeline 下游依赖的容错策略，按以下顺序由外到内执行：
+//
+//	Fallback -> Retry -> Timeout -> RateLimit -> Breaker -> Hedge -> Bulkhead -> fn
+//
+// 重试在最外层，每次尝试都重新经过限流和熔断；熔断器打开时不再重试。
+// 对冲在熔断器内部，一次尝试中的多个对冲请求只计为一次熔断器调用，但各自占用隔离舱配额
+type Pipeline struct {
+	name     string
+	timeout  time.Duration
+	retry    *retry.Config
+	limiter  *rate.Limiter
+	breaker  *circuitbreaker.CircuitBreaker
+	bulkhead bulkhead.Bulkhead
+	hedger   *retry.Hedger
+	fallback Fallback
+	logger   *zap.Logger
+}
+
+// New 创建容错策略，未设置的策略不生效
+func New(name string, options ...Option) *Pipeline {
+	p := &Pipeline{name: name}
+	for _, f := range options {
+		f(p)
+	}
+	return p
+}
+
+// With 返回在当前策略基础上修改配置的副本，限流器、熔断器和隔离舱与当前策略共用
+func (p *Pipeline) With(options ...Option) *Pipeline {
+	q := *p
+	for _, f := range options {
+		f(&q)
+	}
+	return &q
+}
+
+// Name 下游依赖名称
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// Breaker 使用的熔断器，未设置时为 nil
+func (p *Pipeline) Breaker() *circuitbreaker.CircuitBreaker {
+	return p.breaker
+}
+
+// Bulkhead 使用的隔离舱，未设置时为 nil
+func (p *Pipeline) Bulkhead() bulkhead.Bulkhead {
+	return p.bulkhead
+}
+
+// Execute 按容错策略执行 fn，ctx 为 core.StdContext 或携带 Trace（trace.NewContext）时每个决策都会记录到链路中
+// 超时和对冲会在 fn 返回后取消传给 fn 的 ctx，fn 的结果不能依赖返回后仍然有效的 ctx
+func (p *Pipeline) Execute(ctx context.Context, fn Func) error {
+	r := &recorder{pipeline: p.name, trace: traceOf(ctx)}
+
+	// 重试和对冲共用预算时只记录一次请求
+	if p.retry != nil && p.retry.Budget != nil {
+		p.retry.Budget.Deposit()
+	} else if p.hedger != nil && p.hedger.Budget() != nil {
+		p.hedger.Budget().Deposit()
+	}
+
+	err := p.withRetry(ctx, r, func(ctx context.Context, attempt int) error {
+		return p.attempt(ctx, r, attempt, fn)
+	})
+	if err == nil || p.fallback == nil {
+		return err
+	}
+
+	r.record(StageFallback, "fallback", 0, err)
+	if p.logger != nil {
+		p.logger.Warn("resilience fallback", zap.String("pipeline", p.name), zap.Error(err))
+	}
+	return p.fallback(ctx, err)
+}
+
+// withoutReplay 不会重复发出请求的副本，用于不可重放的调用
+func (p *Pipeline) withoutReplay() *Pipeline {
+	if p.retry == nil && p.hedger == nil {
+		return p
+	}
+	return p.With(WithRetry(nil), WithHedge(nil))
+}
+
+// buffered 是否需要在尝试内读完响应体：重试和对冲需要丢弃多余的响应，超时在尝试结束后取消请求的 ctx
+func (p *Pipeline) buffered() bool {
+	return p.retry != nil || p.hedger != nil || p.timeout > 0
+}
+
+func (p *Pipeline) withRetry(ctx context.Context, r *recorder, fn func(ctx context.Context, attempt int) error) error {
+	if p.retry == nil {
+		return fn(ctx, 1)
+	}
+
+	for i := 0; ; i++ {
+		err := fn(ctx, i+1)
+		if err == nil {
+			return nil
+		}
+		if IsRejected(err) || !p.retry.Retryable(err) {
+			if i > 0 {
+				r.record(StageRetry, "abort", i+1, err)
+			}
+			return err
+		}
+		if i >= p.retry.MaxRetries {
+			if i > 0 {
+				r.record(StageRetry, "exhausted", i+1, err)
+			}
+			return err
+		}
+
+		interval := retry.Interval(p.retry, i, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < interval {
+			r.record(StageRetry, "deadline", i+1, err)
+			return err
+		}
+		if p.retry.Budget != nil && !p.retry.Budget.Withdraw() {
+			r.record(StageRetry, "budget", i+1, err)
+			return err
+		}
+
+		r.record(StageRetry, "retry", i+1, err)
+		if p.logger != nil {
+			p.logger.Warn("resilience retry",
+				zap.String("pipeline", p.name),
+				zap.Int("attempt", i+1),
+				zap.Duration("interval", interval),
+				zap.Error(err))
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt 一次尝试：Timeout -> RateLimit -> Breaker -> Hedge -> Bulkhead -> fn
+func (p *Pipeline) attempt(ctx context.Context, r *recorder, attempt int, fn Func) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	if p.limiter != nil && !p.limiter.Allow() {
+		r.record(StageRateLimit, "reject", attempt, ErrRateLimited)
+		return ErrRateLimited
+	}
+
+	call := func() error {
+		return p.withHedge(ctx, r, attempt, func(ctx context.Context) error {
+			return p.withBulkhead(ctx, r, attempt, fn)
+		})
+	}
+
+	var err error
+	if p.breaker != nil {
+		err = p.breaker.Execute(call)
+		if circuitbreaker.IsRejected(err) {
+			r.record(StageBreaker, "reject", attempt, err)
+		}
+	} else {
+		err = call()
+	}
+
+	if err != nil && p.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		r.record(StageTimeout, "timeout", attempt, err)
+	}
+	return err
+}
+
+func (p *Pipeline) withHedge(ctx context.Context, r *recorder, attempt int, fn Func) error {
+	if p.hedger == nil {
+		return fn(ctx)
+	}
+
+	var launched, winner int32
+	err := p.hedger.Do(ctx, func(ctx context.Context) error {
+		n := atomic.AddInt32(&launched, 1)
+		if n > 1 {
+			r.record(StageHedge, "hedge", attempt, nil)
+		}
+
+		err := fn(ctx)
+		if err == nil {
+			atomic.CompareAndSwapInt32(&winner, 0, n)
+		}
+		return err
+	})
+	if err == nil && atomic.LoadInt32(&winner) > 1 {
+		r.record(StageHedge, "won", attempt, nil)
+	}
+	return err
+}
+
+func (p *Pipeline) withBulkhead(ctx context.Context, r *recorder, attempt int, fn Func) error {
+	if p.bulkhead == nil {
+		return fn(ctx)
+	}
+
+	err := p.bulkhead.Execute(ctx, func(ctx context.Context) error {
+		return fn(ctx)
+	})
+	if errors.Is(err, bulkhead.ErrFull) {
+		r.record(StageBulkhead, "reject", attempt, err)
+	}
+	return err
+}
+
+// traceOf 获取 ctx 对应的 Trace
+func traceOf(ctx context.Context) trace.T {
+	if stdCtx, ok := ctx.(core.StdContext); ok && stdCtx.Trace != nil {
+		return stdCtx.Trace
+	}
+	return trace.FromContext(ctx)
+}
+
+// recorder 将决策记录到监控指标和链路中
+type recorder struct {
+	pipeline string
+	trace    trace.T
+}
+
+func (r *recorder) record(stage, decision string, attempt int, err error) {
+	metrics.RecordResilienceDecision(r.pipeline, stage, decision)
+
+	if r.trace == nil {
+		return
+	}
+
+	info := &trace.Resilience{
+		Time:     timeutil.CSTLayoutString(),
+		Pipeline: r.pipeline,
+		Stage:    stage,
+		Decision: decision,
+		Attempt:  attempt,
+	}
+	if err != nil {
+		info.Error = err.Error()
+	}
+	r.trace.AppendResilience(info)
+}
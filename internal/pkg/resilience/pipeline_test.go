@@ -0,0 +1,159 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-example/internal/pkg/bulkhead"
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/retry"
+	"gin-example/internal/pkg/trace"
+)
+
+func testRetry(maxRetries int) *retry.Config {
+	return &retry.Config{MaxRetries: maxRetries, MinInterval: time.Millisecond, MaxInterval: time.Millisecond}
+}
+
+func TestPipelineRetriesAndRecordsTrace(t *testing.T) {
+	p := New("test-retry", WithRetry(testRetry(2)))
+
+	var calls int32
+	tr := trace.New("")
+	err := p.Execute(trace.NewContext(context.Background(), tr), func(context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+	if len(tr.Resiliences) != 2 || tr.Resiliences[0].Stage != StageRetry {
+		t.Fatalf("expected 2 retry decisions in trace, got %+v", tr.Resiliences)
+	}
+}
+
+func TestPipelineDoesNotRetryRejectedOrClientErrors(t *testing.T) {
+	breaker := circuitbreaker.NewCircuitBreaker(&circuitbreaker.Config{
+		ServiceName:      "test-pipeline-open",
+		FailureThreshold: 1,
+		ResetTimeout:     time.Hour,
+	})
+	_ = breaker.Execute(func() error { return errors.New("boom") })
+
+	var calls int32
+	p := New("test-open", WithRetry(testRetry(3)), WithBreaker(breaker))
+	err := p.Execute(context.Background(), func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	if !IsRejected(err) || calls != 0 {
+		t.Fatalf("expected rejection without calls, got err=%v calls=%d", err, calls)
+	}
+
+	p = New("test-4xx", WithRetry(testRetry(3)))
+	err = p.Execute(context.Background(), func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return &circuitbreaker.StatusError{StatusCode: http.StatusNotFound}
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("expected 4xx not to be retried, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestPipelineTimeoutAndFallback(t *testing.T) {
+	p := New("test-timeout",
+		WithTimeout(10*time.Millisecond),
+		WithFallback(func(ctx context.Context, err error) error {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			return nil
+		}),
+	)
+
+	err := p.Execute(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("expected fallback to recover from timeout, got %v", err)
+	}
+}
+
+func TestPipelineHedge(t *testing.T) {
+	p := New("test-hedge", WithHedge(&retry.HedgeConfig{Delay: 10 * time.Millisecond, MaxAttempts: 2}), WithBulkhead(bulkhead.NewSemaphore("test-hedge", 2, 0)))
+
+	var calls int32
+	start := time.Now()
+	err := p.Execute(context.Background(), func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// 第一个请求很慢，由对冲请求返回
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected hedged call to succeed, got %v", err)
+	}
+	if calls != 2 || time.Since(start) > time.Second {
+		t.Fatalf("expected hedged call to win, calls=%d", calls)
+	}
+}
+
+func TestTransportRetries5xxButNotPost(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	registry := NewRegistry(circuitbreaker.NewRegistry(nil), bulkhead.NewRegistry(nil))
+	registry.Register(New("test-http", WithRetry(testRetry(1))), host)
+	client := &http.Client{Transport: NewTransport(nil, registry, nil)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected GET to succeed after retry, err=%v", err)
+	}
+	_ = resp.Body.Close()
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Fatalf("expected POST not to be retried, status=%d calls=%d", resp.StatusCode, calls)
+	}
+}
+
+func TestConfiguredRegistryPassThrough(t *testing.T) {
+	breakers := circuitbreaker.NewRegistry(nil)
+	breakers.Configure("test-configured", circuitbreaker.DefaultConfig())
+	registry := NewRegistry(breakers, bulkhead.NewRegistry(nil))
+	registry.configured = true
+
+	if p := registry.Get("test-unconfigured"); p.Breaker() != nil || p.Bulkhead() != nil || p.buffered() {
+		t.Fatalf("expected unconfigured dependency to pass through")
+	}
+	if p := registry.Get("test-configured"); p.Breaker() == nil || p.Bulkhead() != nil {
+		t.Fatalf("expected only the configured breaker to be used")
+	}
+}
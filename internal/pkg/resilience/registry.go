@@ -0,0 +1,188 @@
+package resilience
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gin-example/configs"
+	"gin-example/internal/pkg/bulkhead"
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/hostmap"
+	"gin-example/internal/pkg/retry"
+)
+
+// Registry 按下游依赖名称（或 host）维护容错策略
+// 未注册的依赖使用只包含熔断器和隔离舱的默认策略
+type Registry struct {
+	mu         sync.RWMutex
+	breakers   *circuitbreaker.Registry
+	bulkheads  *bulkhead.Registry
+	hosts      hostmap.Map
+	pipelines  map[string]*Pipeline
+	configured bool // 默认策略只使用单独配置的熔断器和隔离舱，都没有配置时直接调用
+}
+
+// NewRegistry 创建容错策略注册表，breakers 和 bulkheads 为空时分别使用 circuitbreaker.Default() 和 bulkhead.Default()
+func NewRegistry(breakers *circuitbreaker.Registry, bulkheads *bulkhead.Registry) *Registry {
+	if breakers == nil {
+		breakers = circuitbreaker.Default()
+	}
+	if bulkheads == nil {
+		bulkheads = bulkhead.Default()
+	}
+
+	return &Registry{
+		breakers:  breakers,
+		bulkheads: bulkheads,
+		pipelines: make(map[string]*Pipeline),
+	}
+}
+
+// Register 注册容错策略，同名的策略会被替换，hosts 中的 host 使用该策略
+func (r *Registry) Register(p *Pipeline, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pipelines[p.name] = p
+	r.hosts.Set(p.name, hosts...)
+}
+
+// Breakers 默认策略使用的熔断器注册表
+func (r *Registry) Breakers() *circuitbreaker.Registry {
+	return r.breakers
+}
+
+// Bulkheads 默认策略使用的隔离舱注册表
+func (r *Registry) Bulkheads() *bulkhead.Registry {
+	return r.bulkheads
+}
+
+// ServiceName 返回 host 所属的依赖名称，依次查找本注册表、熔断器注册表和隔离舱注册表中配置的 host
+func (r *Registry) ServiceName(host string) string {
+	if name, ok := r.hosts.Lookup(host); ok {
+		return name
+	}
+
+	if name := r.breakers.ServiceName(host); name != hostmap.TrimDefaultPort(host) {
+		return name
+	}
+	return r.bulkheads.ServiceName(host)
+}
+
+// ForHost 返回 host 所属依赖的容错策略
+func (r *Registry) ForHost(host string) *Pipeline {
+	return r.Get(r.ServiceName(host))
+}
+
+// Get 返回依赖的容错策略，未注册时创建只包含熔断器和隔离舱的默认策略
+// 全局注册表的默认策略只包含配置文件中为该依赖单独配置的熔断器和隔离舱
+func (r *Registry) Get(name string) *Pipeline {
+	r.mu.RLock()
+	p, ok := r.pipelines[name]
+	r.mu.RUnlock()
+	if ok {
+		return p
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.pipelines[name]; ok {
+		return p
+	}
+
+	var options []Option
+	if !r.configured || r.breakers.Configured(name) {
+		options = append(options, WithBreaker(r.breakers.Get(name)))
+	}
+	if !r.configured || r.bulkheads.Configured(name) {
+		options = append(options, WithBulkhead(r.bulkheads.Get(name)))
+	}
+
+	p = New(name, options...)
+	r.pipelines[name] = p
+	return p
+}
+
+// Names 返回已注册或已创建的依赖名称，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.pipelines))
+	for name := range r.pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// Default 返回按配置文件创建的全局容错策略注册表，没有配置的依赖不使用任何策略
+func Default() *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(circuitbreaker.Default(), bulkhead.Default())
+		defaultRegistry.configured = true
+		for _, cfg := range configs.Get().Resilience.Pipelines {
+			pipeline, err := defaultRegistry.fromConfig(cfg)
+			if err != nil {
+				panic(fmt.Sprintf("resilience pipeline %s: %v", cfg.Name, err))
+			}
+			defaultRegistry.Register(pipeline, cfg.Hosts...)
+		}
+	})
+	return defaultRegistry
+}
+
+// fromConfig 将配置文件中的策略转换为容错策略，重试和对冲共用重试预算
+func (r *Registry) fromConfig(cfg configs.ResiliencePipeline) (*Pipeline, error) {
+	var budget *retry.Budget
+	if cfg.Retry.BudgetRatio > 0 {
+		budget = retry.NewBudget(cfg.Name, cfg.Retry.BudgetRatio, cfg.Retry.BudgetMinPerSecond)
+	}
+
+	options := []Option{
+		WithTimeout(cfg.Timeout),
+		WithRateLimit(cfg.RateLimit.RPS, cfg.RateLimit.Burst),
+		WithHedge(&retry.HedgeConfig{
+			MaxAttempts: cfg.Hedge.MaxAttempts,
+			Delay:       cfg.Hedge.Delay,
+			Percentile:  cfg.Hedge.Percentile,
+			Budget:      budget,
+		}),
+	}
+
+	if cfg.Breaker {
+		options = append(options, WithBreaker(r.breakers.Get(cfg.Name)))
+	}
+	if cfg.Bulkhead {
+		options = append(options, WithBulkhead(r.bulkheads.Get(cfg.Name)))
+	}
+	if cfg.Retry.MaxRetries > 0 {
+		config := retry.DefaultConfig()
+		config.MaxRetries = cfg.Retry.MaxRetries
+		if cfg.Retry.MinInterval > 0 {
+			config.MinInterval = cfg.Retry.MinInterval
+		}
+		if cfg.Retry.MaxInterval > 0 {
+			config.MaxInterval = cfg.Retry.MaxInterval
+		}
+		config.MaxJitter = cfg.Retry.MaxJitter
+		config.Budget = budget
+		if len(cfg.Retry.GRPCCodes) > 0 {
+			grpcCodes, err := retry.ParseGRPCCodes(cfg.Retry.GRPCCodes)
+			if err != nil {
+				return nil, err
+			}
+			config.RetryIf = retry.RetryOn(grpcCodes...)
+		}
+		options = append(options, WithRetry(config))
+	}
+
+	return New(cfg.Name, options...), nil
+}
@@ -0,0 +1,172 @@
+package resilience
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/retry"
+)
+
+// Transport 按请求的 host 使用容错策略的 http.RoundTripper
+// 5xx 响应计为失败并可以重试，4xx 响应直接返回。策略包含重试、对冲或超时时，为了丢弃多余的响应
+// 以及在尝试的 ctx 结束前读完，响应体会在尝试内读取完毕，不适合流式响应；其他策略不读取响应体
+type Transport struct {
+	base     http.RoundTripper
+	registry *Registry
+	fallback circuitbreaker.HTTPFallback
+}
+
+// NewTransport 创建带容错策略的 Transport，base 为空时使用 http.DefaultTransport，registry 为空时使用 Default()
+// 请求被熔断器、隔离舱或限流拒绝时调用 fallback，fallback 可以为空
+func NewTransport(base http.RoundTripper, registry *Registry, fallback circuitbreaker.HTTPFallback) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if registry == nil {
+		registry = Default()
+	}
+
+	return &Transport{
+		base:     base,
+		registry: registry,
+		fallback: fallback,
+	}
+}
+
+// Registry 返回使用的容错策略注册表
+func (t *Transport) Registry() *Registry {
+	return t.registry
+}
+
+// RoundTrip 实现 http.RoundTripper，不可重放的请求（非幂等方法或请求体无法重新读取）不会重试和对冲
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	pipeline := t.registry.ForHost(req.URL.Host)
+	if !replayable(req) {
+		pipeline = pipeline.withoutReplay()
+	}
+	buffered := pipeline.buffered()
+
+	var (
+		mu       sync.Mutex
+		winner   *http.Response // 最先成功的响应
+		failure  *http.Response // 最后一个 5xx 响应
+		sent     bool           // 原始请求体已经被使用
+		returned bool           // 调用方已经返回，之后收到的响应需要关闭
+	)
+
+	err := pipeline.Execute(req.Context(), func(ctx context.Context) error {
+		r := req.Clone(ctx)
+
+		mu.Lock()
+		if req.Body != nil && req.Body != http.NoBody && sent {
+			body, err := req.GetBody()
+			if err != nil {
+				mu.Unlock()
+				return err
+			}
+			r.Body = body
+		}
+		sent = true
+		mu.Unlock()
+
+		resp, err := t.base.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+
+		if buffered {
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp.Request = req
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// 隔离舱在调用方 ctx 结束后直接返回，之后收到的响应不会再交给调用方
+		if returned {
+			_ = resp.Body.Close()
+			return nil
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			if failure != nil {
+				_ = failure.Body.Close()
+			}
+			failure = resp
+			return &circuitbreaker.StatusError{
+				StatusCode: resp.StatusCode,
+				Wait:       retry.ParseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+		if winner != nil {
+			_ = resp.Body.Close()
+			return nil
+		}
+		winner = resp
+		return nil
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	returned = true
+
+	// RoundTripper 需要关闭请求体，请求被拒绝时原始请求体没有交给 base
+	if !sent && req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	switch {
+	case err == nil && winner != nil:
+		if failure != nil {
+			_ = failure.Body.Close()
+		}
+		return winner, nil
+	case failure != nil && isStatusError(err):
+		// 5xx 响应已经返回给调用方，状态码错误不再作为 error 返回
+		return failure, nil
+	}
+
+	// 不返回给调用方的响应需要关闭
+	if winner != nil {
+		_ = winner.Body.Close()
+	}
+	if failure != nil {
+		_ = failure.Body.Close()
+	}
+
+	switch {
+	case IsRejected(err) && t.fallback != nil:
+		r := &recorder{pipeline: pipeline.name, trace: traceOf(req.Context())}
+		r.record(StageFallback, "fallback", 0, err)
+		return t.fallback(req, err)
+	default:
+		return nil, err
+	}
+}
+
+// replayable 与 net/http 的判断一致：请求体可以重新读取，并且是幂等方法或带有幂等键
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func isStatusError(err error) bool {
+	_, ok := err.(*circuitbreaker.StatusError)
+	return ok
+}
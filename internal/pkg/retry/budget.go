@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"gin-example/internal/metrics"
+)
+
+// Budget 重试预算，限制重试占请求的比例，避免下游故障时重试放大流量
+// 每个请求存入 ratio 个令牌，每次重试取出一个令牌；此外每秒固定允许 minPerSecond 次重试，保证低流量时也能重试
+type Budget struct {
+	name         string
+	ratio        float64
+	minPerSecond float64
+	maxTokens    float64
+
+	mu       sync.Mutex
+	tokens   float64
+	reserve  float64 // 每秒固定允许的重试
+	lastFill time.Time
+}
+
+// NewBudget 创建重试预算，ratio 为重试占请求的比例，如 0.1 表示最多为请求数的 10%
+// 令牌最多积累 1000 个请求的额度，空闲后的突发流量不会获得过多的重试
+func NewBudget(name string, ratio float64, minPerSecond int) *Budget {
+	if ratio < 0 {
+		ratio = 0
+	}
+	if minPerSecond < 0 {
+		minPerSecond = 0
+	}
+
+	return &Budget{
+		name:         name,
+		ratio:        ratio,
+		minPerSecond: float64(minPerSecond),
+		maxTokens:    ratio * 1000,
+		reserve:      float64(minPerSecond),
+		lastFill:     time.Now(),
+	}
+}
+
+// Deposit 记录一个请求
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// Withdraw 尝试取出一次重试的额度，预算用尽时返回 false
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.reserve += now.Sub(b.lastFill).Seconds() * b.minPerSecond
+	if b.reserve > b.minPerSecond {
+		b.reserve = b.minPerSecond
+	}
+	b.lastFill = now
+
+	switch {
+	case b.reserve >= 1:
+		b.reserve--
+	case b.tokens >= 1:
+		b.tokens--
+	default:
+		metrics.RecordRetryBudgetExhausted(b.name)
+		return false
+	}
+	return true
+}
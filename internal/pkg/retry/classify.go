@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-example/internal/pkg/circuitbreaker"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Predicate 判断错误是否可以重试
+type Predicate func(err error) bool
+
+// DefaultGRPCCodes 默认重试的 gRPC 状态码
+var DefaultGRPCCodes = []codes.Code{codes.Unavailable}
+
+// IsRetryable 默认的错误分类，等同于 RetryOn(DefaultGRPCCodes...)
+func IsRetryable(err error) bool {
+	return defaultPredicate(err)
+}
+
+var defaultPredicate = RetryOn(DefaultGRPCCodes...)
+
+// RetryOn 返回按以下规则分类的 Predicate：
+//   - 被取消、被熔断器拒绝和业务错误（core.BusinessError）不重试
+//   - gRPC 错误只重试 grpcCodes 中的状态码
+//   - 带有 HTTP 状态码的错误只重试 5xx，4xx 是调用方的问题
+//   - 其他错误（网络错误、超时等）重试
+func RetryOn(grpcCodes ...codes.Code) Predicate {
+	retryable := make(map[codes.Code]struct{}, len(grpcCodes))
+	for _, c := range grpcCodes {
+		retryable[c] = struct{}{}
+	}
+
+	return func(err error) bool {
+		if err == nil || errors.Is(err, context.Canceled) || circuitbreaker.IsRejected(err) {
+			return false
+		}
+
+		var business interface{ BusinessCode() int }
+		if errors.As(err, &business) {
+			return false
+		}
+
+		var grpcErr interface{ GRPCStatus() *status.Status }
+		if errors.As(err, &grpcErr) {
+			_, ok := retryable[grpcErr.GRPCStatus().Code()]
+			return ok
+		}
+
+		var httpErr interface{ HTTPCode() int }
+		if errors.As(err, &httpErr) {
+			return httpErr.HTTPCode() >= http.StatusInternalServerError
+		}
+
+		return true
+	}
+}
+
+// ParseGRPCCodes 解析 gRPC 状态码名称，如 UNAVAILABLE、DEADLINE_EXCEEDED
+func ParseGRPCCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		var c codes.Code
+		if err := c.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// RetryAfter 错误中服务端要求的重试等待时间，如 HTTP 响应的 Retry-After 头
+func RetryAfter(err error) time.Duration {
+	var e interface{ RetryAfter() time.Duration }
+	if errors.As(err, &e) {
+		return e.RetryAfter()
+	}
+	return 0
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gin-example/internal/metrics"
+)
+
+const (
+	// latencySamples 计算延迟分位数保留的样本数
+	latencySamples = 100
+	// minLatencySamples 样本数达到该值后才按分位数对冲，之前使用固定延迟
+	minLatencySamples = 10
+)
+
+// HedgeConfig 对冲请求配置
+type HedgeConfig struct {
+	MaxAttempts int           // 最多同时发出的请求数，小于 2 时不对冲
+	Delay       time.Duration // Percentile 为 0 时的固定延迟；按分位数对冲时为最小延迟和样本不足时的延迟
+	Percentile  float64       // 按最近成功调用耗时的分位数（如 95）决定延迟，0 表示使用固定延迟
+	Budget      *Budget       // 对冲请求与重试共用预算，预算用尽时不再对冲，可以为空
+}
+
+// Hedger 对冲请求：调用在延迟内未返回时再发出一个请求，取最先成功的结果
+type Hedger struct {
+	name   string
+	config HedgeConfig
+
+	mu        sync.Mutex
+	latencies []time.Duration // 最近成功调用的耗时，环形缓冲
+	next      int
+}
+
+// NewHedger 创建对冲请求
+func NewHedger(name string, config *HedgeConfig) *Hedger {
+	return &Hedger{
+		name:      name,
+		config:    *config,
+		latencies: make([]time.Duration, 0, latencySamples),
+	}
+}
+
+// Budget 对冲请求使用的重试预算，未设置时为 nil
+func (h *Hedger) Budget() *Budget {
+	return h.config.Budget
+}
+
+// Delay 当前发出对冲请求前的等待时间
+func (h *Hedger) Delay() time.Duration {
+	if h.config.Percentile <= 0 {
+		return h.config.Delay
+	}
+
+	h.mu.Lock()
+	if len(h.latencies) < minLatencySamples {
+		h.mu.Unlock()
+		return h.config.Delay
+	}
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	h.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(float64(len(sorted)-1) * h.config.Percentile / 100)
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	if delay := sorted[i]; delay > h.config.Delay {
+		return delay
+	}
+	return h.config.Delay
+}
+
+// Do 执行 fn，延迟内未返回时发出对冲请求，最先成功的结果返回后取消其余请求
+// 全部失败时返回最后一个错误
+func (h *Hedger) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if h.config.MaxAttempts < 2 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		err  error
+		cost time.Duration
+	}
+	results := make(chan result, h.config.MaxAttempts)
+	launch := func() {
+		go func() {
+			start := time.Now()
+			err := fn(ctx)
+			results <- result{err: err, cost: time.Since(start)}
+		}()
+	}
+
+	launch()
+	launched := 1
+
+	delay := h.Delay()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var err error
+	for received := 0; received < launched; {
+		select {
+		case r := <-results:
+			received++
+			if r.err == nil {
+				h.observe(r.cost)
+				if launched > 1 {
+					metrics.RecordHedge(h.name, "completed")
+				}
+				return nil
+			}
+			err = r.err
+		case <-timer.C:
+			if launched >= h.config.MaxAttempts {
+				continue
+			}
+			if h.config.Budget != nil && !h.config.Budget.Withdraw() {
+				continue
+			}
+			metrics.RecordHedge(h.name, "fired")
+			launch()
+			launched++
+			timer.Reset(delay)
+		}
+	}
+	return err
+}
+
+// observe 记录成功调用的耗时
+func (h *Hedger) observe(cost time.Duration) {
+	if h.config.Percentile <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < latencySamples {
+		h.latencies = append(h.latencies, cost)
+		return
+	}
+	h.latencies[h.next] = cost
+	h.next = (h.next + 1) % latencySamples
+}
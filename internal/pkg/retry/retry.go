@@ -6,6 +6,8 @@ import (
 	"math/rand"
 	"time"
 
+	"gin-example/internal/pkg/bulkhead"
+
 	"go.uber.org/zap"
 )
 
@@ -16,6 +18,22 @@ type Config struct {
 	MaxInterval time.Duration // 最大重试间隔
 	MaxJitter   time.Duration // 最大抖动时间
 	Timeout     time.Duration // 操作超时时间
+
+	// Bulkhead 下游依赖的隔离舱，每次尝试单独占用配额，等待重试期间不占用
+	Bulkhead bulkhead.Bulkhead
+
+	// RetryIf 判断错误是否可以重试，默认 IsRetryable
+	RetryIf Predicate
+	// Budget 重试预算，预算用尽时不再重试，可以为空
+	Budget *Budget
+}
+
+// Retryable 按 RetryIf 判断错误是否可以重试
+func (c *Config) Retryable(err error) bool {
+	if c.RetryIf != nil {
+		return c.RetryIf(err)
+	}
+	return IsRetryable(err)
 }
 
 // DefaultConfig 默认重试配置
@@ -38,6 +56,10 @@ func RetryWithConfig(ctx context.Context, logger *zap.Logger, config *Config, fn
 		config = DefaultConfig()
 	}
 
+	if config.Budget != nil {
+		config.Budget.Deposit()
+	}
+
 	var lastErr error
 	
 	for i := 0; i <= config.MaxRetries; i++ {
@@ -47,7 +69,13 @@ func RetryWithConfig(ctx context.Context, logger *zap.Logger, config *Config, fn
 			defer cancel() // 确保及时释放资源
 			
 			// 在超时上下文中执行函数
-			lastErr = fn()
+			if config.Bulkhead != nil {
+				lastErr = config.Bulkhead.Execute(opCtx, func(context.Context) error {
+					return fn()
+				})
+			} else {
+				lastErr = fn()
+			}
 			
 			// 检查上下文是否超时或被取消
 			select {
@@ -68,13 +96,19 @@ func RetryWithConfig(ctx context.Context, logger *zap.Logger, config *Config, fn
 			return nil
 		}
 		
-		// 如果是最后一次重试，直接返回错误
-		if i == config.MaxRetries {
+		// 如果是最后一次重试或错误不可重试，直接返回错误
+		if i == config.MaxRetries || !config.Retryable(lastErr) {
 			break
 		}
 		
-		// 计算下一次重试的间隔
-		interval := calculateBackoff(config, i)
+		// 计算下一次重试的间隔，超过剩余时间时不再重试
+		interval := Interval(config, i, lastErr)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < interval {
+			break
+		}
+		if config.Budget != nil && !config.Budget.Withdraw() {
+			break
+		}
 		
 		// 记录重试日志
 		if logger != nil {
@@ -102,6 +136,26 @@ func Retry(ctx context.Context, logger *zap.Logger, fn RetryableFunc) error {
 	return RetryWithConfig(ctx, logger, nil, fn)
 }
 
+// Backoff 第 attempt 次（从 0 开始）失败后的重试间隔
+func Backoff(config *Config, attempt int) time.Duration {
+	return calculateBackoff(config, attempt)
+}
+
+// Interval 第 attempt 次（从 0 开始）因 err 失败后的重试间隔，服务端要求的 Retry-After 更长时以其为准，
+// 但不超过 MaxInterval，避免服务端要求过长的等待时一直阻塞
+func Interval(config *Config, attempt int, err error) time.Duration {
+	interval := calculateBackoff(config, attempt)
+
+	after := RetryAfter(err)
+	if after > config.MaxInterval {
+		after = config.MaxInterval
+	}
+	if after > interval {
+		return after
+	}
+	return interval
+}
+
 // calculateBackoff 计算退避时间
 func calculateBackoff(config *Config, attempt int) time.Duration {
 	// 指数退避算法
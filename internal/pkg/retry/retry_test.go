@@ -0,0 +1,131 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-example/internal/pkg/circuitbreaker"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type businessError struct{}
+
+func (businessError) Error() string     { return "business" }
+func (businessError) BusinessCode() int { return 10001 }
+func (businessError) HTTPCode() int     { return http.StatusInternalServerError }
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection reset"), true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{circuitbreaker.ErrOpen, false},
+		{&circuitbreaker.StatusError{StatusCode: http.StatusBadGateway}, true},
+		{&circuitbreaker.StatusError{StatusCode: http.StatusNotFound}, false},
+		{businessError{}, false},
+		{status.Error(codes.Unavailable, "down"), true},
+		{status.Error(codes.InvalidArgument, "bad"), false},
+	}
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+
+	grpcCodes, err := ParseGRPCCodes([]string{"resource_exhausted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !RetryOn(grpcCodes...)(status.Error(codes.ResourceExhausted, "busy")) {
+		t.Error("expected configured gRPC code to be retryable")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := ParseRetryAfter("3"); d != 3*time.Second {
+		t.Errorf("expected 3s, got %s", d)
+	}
+	if d := ParseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d <= 50*time.Second {
+		t.Errorf("expected about 1m, got %s", d)
+	}
+
+	config := &Config{MinInterval: time.Millisecond, MaxInterval: 5 * time.Second}
+	err := &circuitbreaker.StatusError{StatusCode: http.StatusServiceUnavailable, Wait: 2 * time.Second}
+	if d := Interval(config, 0, err); d != 2*time.Second {
+		t.Errorf("expected Retry-After to override backoff, got %s", d)
+	}
+
+	err = &circuitbreaker.StatusError{StatusCode: http.StatusServiceUnavailable, Wait: 24 * time.Hour}
+	if d := Interval(config, 0, err); d != config.MaxInterval {
+		t.Errorf("expected Retry-After to be capped at MaxInterval, got %s", d)
+	}
+}
+
+func TestRetryWithConfigStopsOnNonRetryable(t *testing.T) {
+	var calls int
+	err := RetryWithConfig(context.Background(), nil, &Config{
+		MaxRetries:  3,
+		MinInterval: time.Millisecond,
+		MaxInterval: time.Millisecond,
+		Timeout:     time.Second,
+	}, func() error {
+		calls++
+		return &circuitbreaker.StatusError{StatusCode: http.StatusBadRequest}
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("expected a single call, got calls=%d err=%v", calls, err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget("test-budget", 0.5, 0)
+	if b.Withdraw() {
+		t.Fatal("expected empty budget to reject retries")
+	}
+
+	b.Deposit()
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Fatal("expected two requests at 50% to allow one retry")
+	}
+	if b.Withdraw() {
+		t.Fatal("expected budget to be exhausted")
+	}
+
+	b = NewBudget("test-budget-min", 0, 2)
+	if !b.Withdraw() || !b.Withdraw() || b.Withdraw() {
+		t.Fatal("expected minimum retries per second to be honored")
+	}
+}
+
+func TestHedgerPercentile(t *testing.T) {
+	h := NewHedger("test-hedger", &HedgeConfig{MaxAttempts: 2, Delay: time.Millisecond, Percentile: 90})
+	for i := 1; i <= 20; i++ {
+		h.observe(time.Duration(i) * 10 * time.Millisecond)
+	}
+	if d := h.Delay(); d < 170*time.Millisecond || d > 190*time.Millisecond {
+		t.Fatalf("expected p90 of observed latencies, got %s", d)
+	}
+
+	var calls int32
+	h = NewHedger("test-hedger-fixed", &HedgeConfig{MaxAttempts: 2, Delay: 5 * time.Millisecond})
+	err := h.Do(context.Background(), func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected hedged request to win, calls=%d err=%v", calls, err)
+	}
+}
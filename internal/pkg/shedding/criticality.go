@@ -0,0 +1,46 @@
+package shedding
+
+import "strings"
+
+// Criticality 请求优先级，过载时从最低的优先级开始拒绝
+type Criticality int
+
+const (
+	// Sheddable 可随时丢弃的请求，如批量导出
+	Sheddable Criticality = iota
+	// Default 普通请求
+	Default
+	// High 重要请求，如付费用户
+	High
+	// Critical 关键请求，如健康检查、登录，不会被拒绝
+	Critical
+)
+
+// Header 上游服务传递请求优先级的请求头
+const Header = "X-Criticality"
+
+var criticalityNames = map[Criticality]string{
+	Sheddable: "sheddable",
+	Default:   "default",
+	High:      "high",
+	Critical:  "critical",
+}
+
+// String 优先级名称
+func (c Criticality) String() string {
+	if name, ok := criticalityNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseCriticality 解析优先级名称，不区分大小写
+func ParseCriticality(name string) (Criticality, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for c, n := range criticalityNames {
+		if n == name {
+			return c, true
+		}
+	}
+	return Default, false
+}
@@ -0,0 +1,173 @@
+package shedding
+
+import (
+	"path"
+	"strings"
+
+	"gin-example/internal/metrics"
+	"gin-example/internal/pkg/core"
+)
+
+var _ core.Shedder = (*Shedder)(nil)
+
+// Rule 按路由指定请求优先级，优先于请求头和 JWT 声明
+type Rule struct {
+	Path        string   // 路由模式，* 匹配一段路径，末尾的 /** 匹配剩余部分
+	Methods     []string // 为空时匹配全部方法
+	Criticality Criticality
+}
+
+// Option 负载保护配置项
+type Option func(*option)
+
+type option struct {
+	signals     []Signal
+	thresholds  map[Criticality]float64
+	rules       []Rule
+	trustHeader bool
+	claim       func(ctx core.Context) string
+}
+
+// WithSignals 设置过载信号，取压力最大的信号做判断
+func WithSignals(signals ...Signal) Option {
+	return func(opt *option) {
+		opt.signals = append(opt.signals, signals...)
+	}
+}
+
+// WithThresholds 设置各优先级开始被拒绝的压力，默认 0.8 / 0.9 / 1.0，Critical 不会被拒绝
+func WithThresholds(sheddable, normal, high float64) Option {
+	return func(opt *option) {
+		opt.thresholds[Sheddable] = sheddable
+		opt.thresholds[Default] = normal
+		opt.thresholds[High] = high
+	}
+}
+
+// WithRules 设置按路由指定的优先级，按顺序匹配第一条
+func WithRules(rules []Rule) Option {
+	return func(opt *option) {
+		opt.rules = rules
+	}
+}
+
+// WithTrustHeader 信任请求头 X-Criticality 中的优先级，只应在内部服务之间启用
+func WithTrustHeader() Option {
+	return func(opt *option) {
+		opt.trustHeader = true
+	}
+}
+
+// WithClaim 设置从 JWT 声明中读取优先级的方法
+func WithClaim(claim func(ctx core.Context) string) Option {
+	return func(opt *option) {
+		opt.claim = claim
+	}
+}
+
+// Shedder 按优先级的负载保护，压力越大拒绝的优先级越高
+type Shedder struct {
+	option *option
+}
+
+// New 创建负载保护
+func New(options ...Option) *Shedder {
+	opt := &option{
+		thresholds: map[Criticality]float64{
+			Sheddable: 0.8,
+			Default:   0.9,
+			High:      1.0,
+		},
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	return &Shedder{option: opt}
+}
+
+// Shed 返回 true 表示拒绝请求
+func (s *Shedder) Shed(ctx core.Context) bool {
+	criticality := s.Classify(ctx)
+	if !s.Decide(criticality) {
+		return false
+	}
+
+	ctx.SetHeader("Retry-After", "1")
+	return true
+}
+
+// Classify 确定请求优先级：路由规则 > JWT 声明 > 请求头 > Default
+func (s *Shedder) Classify(ctx core.Context) Criticality {
+	if c, ok := s.matchRule(ctx.Method(), ctx.Path()); ok {
+		return c
+	}
+
+	if s.option.claim != nil {
+		if c, ok := ParseCriticality(s.option.claim(ctx)); ok {
+			return c
+		}
+	}
+
+	if s.option.trustHeader {
+		if c, ok := ParseCriticality(ctx.GetHeader(Header)); ok {
+			return c
+		}
+	}
+
+	return Default
+}
+
+// Decide 按当前压力判断是否拒绝该优先级的请求，并记录指标
+func (s *Shedder) Decide(criticality Criticality) bool {
+	shed := false
+	if threshold, ok := s.option.thresholds[criticality]; ok && criticality != Critical {
+		shed = s.pressure() >= threshold
+	}
+
+	metrics.RecordLoadShedDecision(criticality.String(), shed)
+	return shed
+}
+
+// pressure 返回所有信号中最大的压力
+func (s *Shedder) pressure() float64 {
+	max := 0.0
+	for _, signal := range s.option.signals {
+		p := signal.Pressure()
+		metrics.SetLoadPressure(signal.Name(), p)
+		if p > max {
+			max = p
+		}
+	}
+	return max
+}
+
+func (s *Shedder) matchRule(method, urlPath string) (Criticality, bool) {
+	for _, rule := range s.option.rules {
+		if len(rule.Methods) > 0 && !containsFold(rule.Methods, method) {
+			continue
+		}
+		if matchRoute(rule.Path, urlPath) {
+			return rule.Criticality, true
+		}
+	}
+	return Default, false
+}
+
+// matchRoute 路由模式末尾为 /** 时按前缀匹配，否则按 path.Match 逐段匹配
+func matchRoute(pattern, urlPath string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/**"); prefix != pattern {
+		return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, urlPath)
+	return ok
+}
+
+func containsFold(items []string, s string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
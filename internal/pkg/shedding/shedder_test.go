@@ -0,0 +1,58 @@
+package shedding
+
+import "testing"
+
+func TestDecideUnderSyntheticOverload(t *testing.T) {
+	signal := NewSyntheticSignal()
+	s := New(WithSignals(signal))
+
+	cases := []struct {
+		pressure float64
+		shed     map[Criticality]bool
+	}{
+		{0.5, map[Criticality]bool{}},
+		{0.85, map[Criticality]bool{Sheddable: true}},
+		{0.95, map[Criticality]bool{Sheddable: true, Default: true}},
+		{5, map[Criticality]bool{Sheddable: true, Default: true, High: true}},
+	}
+	for _, c := range cases {
+		signal.Set(c.pressure)
+		for _, criticality := range []Criticality{Sheddable, Default, High, Critical} {
+			if got := s.Decide(criticality); got != c.shed[criticality] {
+				t.Errorf("pressure %.2f %s: shed = %v, want %v", c.pressure, criticality, got, c.shed[criticality])
+			}
+		}
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	s := New(WithRules([]Rule{
+		{Path: "/system/health", Criticality: Critical},
+		{Path: "/api/admins", Methods: []string{"GET"}, Criticality: Sheddable},
+		{Path: "/api/admin/*", Criticality: High},
+		{Path: "/api/**", Criticality: Default},
+	}))
+
+	cases := []struct {
+		method, path string
+		want         Criticality
+		ok           bool
+	}{
+		{"GET", "/system/health", Critical, true},
+		{"GET", "/api/admins", Sheddable, true},
+		{"POST", "/api/admins", Default, true},
+		{"PUT", "/api/admin/1", High, true},
+		{"GET", "/api/admin/1/x", Default, true},
+		{"GET", "/auth/login", Default, false},
+	}
+	for _, c := range cases {
+		got, ok := s.matchRule(c.method, c.path)
+		if got != c.want || ok != c.ok {
+			t.Errorf("matchRule(%s %s) = %s, %v, want %s, %v", c.method, c.path, got, ok, c.want, c.ok)
+		}
+	}
+
+	if c, ok := ParseCriticality(" HIGH "); !ok || c != High {
+		t.Fatalf("ParseCriticality = %s, %v", c, ok)
+	}
+}
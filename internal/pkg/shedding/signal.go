@@ -0,0 +1,81 @@
+package shedding
+
+import (
+	"math"
+	"sync/atomic"
+
+	"gin-example/internal/metrics"
+	"gin-example/internal/pkg/concurrency"
+)
+
+// Signal 过载信号，Pressure 返回当前负载压力，1 表示达到容量
+type Signal interface {
+	Name() string
+	Pressure() float64
+}
+
+// cpuSignal 以进程 CPU 使用率作为压力
+type cpuSignal struct {
+	threshold float64
+}
+
+// CPUSignal CPU 使用率达到 threshold（0-100）时压力为 1
+func CPUSignal(threshold float64) Signal {
+	if threshold <= 0 {
+		threshold = 80
+	}
+	return &cpuSignal{threshold: threshold}
+}
+
+func (s *cpuSignal) Name() string {
+	return "cpu"
+}
+
+func (s *cpuSignal) Pressure() float64 {
+	return metrics.CPUUsage() / s.threshold
+}
+
+// concurrencySignal 以自适应并发限制器的占用作为压力
+type concurrencySignal struct {
+	limiter *concurrency.Limiter
+}
+
+// ConcurrencySignal 压力为（执行中 + 排队中的请求数）/ 当前并发上限
+func ConcurrencySignal(limiter *concurrency.Limiter) Signal {
+	return &concurrencySignal{limiter: limiter}
+}
+
+func (s *concurrencySignal) Name() string {
+	return "concurrency"
+}
+
+func (s *concurrencySignal) Pressure() float64 {
+	limit := s.limiter.Limit()
+	if limit <= 0 {
+		return 0
+	}
+	return float64(s.limiter.InFlight()+s.limiter.QueueDepth()) / float64(limit)
+}
+
+// SyntheticSignal 人工设置的压力，用于测试和演练
+type SyntheticSignal struct {
+	pressure uint64 // math.Float64bits
+}
+
+// NewSyntheticSignal 创建人工过载信号，初始压力为 0
+func NewSyntheticSignal() *SyntheticSignal {
+	return &SyntheticSignal{}
+}
+
+func (s *SyntheticSignal) Name() string {
+	return "synthetic"
+}
+
+func (s *SyntheticSignal) Pressure() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.pressure))
+}
+
+// Set 设置压力
+func (s *SyntheticSignal) Set(pressure float64) {
+	atomic.StoreUint64(&s.pressure, math.Float64bits(pressure))
+}
@@ -12,7 +12,7 @@ var (
 	hooksMu sync.Mutex
 )
 
-// RegisterHook 注册关闭钩子函数
+// RegisterHook 注册关闭钩子函数，关闭时按注册的相反顺序执行
 func RegisterHook(hook func()) {
 	hooksMu.Lock()
 	defer hooksMu.Unlock()
@@ -29,11 +29,18 @@ func Close(handler func()) {
 	signal.Stop(ctx)
 
 	// 执行所有注册的钩子函数
-	hooksMu.Lock()
-	for _, hook := range hooks {
-		hook()
-	}
-	hooksMu.Unlock()
+	RunHooks()
 
 	handler()
+}
+
+// RunHooks 按注册的相反顺序执行所有关闭钩子函数（与 defer 相同），
+// 后创建的资源先关闭，依赖的数据库、Redis 连接最后关闭
+func RunHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
 }
\ No newline at end of file
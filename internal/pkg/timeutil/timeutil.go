@@ -99,4 +99,20 @@ func Format(t time.Time, layout string) string {
 // Now 返回当前时间
 func Now() time.Time {
 	return time.Now().In(cst)
-}
\ No newline at end of file
+}
+// ParseInCST 按指定格式解析中国时区的时间
+func ParseInCST(layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, cst)
+}
+
+// StartOfDay 返回中国时区下 t 所在自然日的零点
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(cst)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, cst)
+}
+
+// StartOfMonth 返回中国时区下 t 所在自然月第一天的零点
+func StartOfMonth(t time.Time) time.Time {
+	t = t.In(cst)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, cst)
+}
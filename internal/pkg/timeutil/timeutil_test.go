@@ -1,6 +1,9 @@
 package timeutil
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestRFC3339ToCSTLayout(t *testing.T) {
 	t.Log(RFC3339ToCSTLayout("2020-11-08T08:18:46+08:00"))
@@ -17,3 +20,15 @@ func TestCSTLayoutStringToUnix(t *testing.T) {
 func TestGMTLayoutString(t *testing.T) {
 	t.Log(GMTLayoutString())
 }
+
+func TestStartOfDayAndMonth(t *testing.T) {
+	// UTC 16:30 已经是中国时区的第二天
+	ts := time.Date(2020, 1, 31, 16, 30, 0, 0, time.UTC)
+
+	if day := StartOfDay(ts); day.Format(CSTLayout) != "2020-02-01 00:00:00" {
+		t.Fatalf("StartOfDay = %s", day.Format(CSTLayout))
+	}
+	if month := StartOfMonth(ts); month.Format(CSTLayout) != "2020-02-01 00:00:00" {
+		t.Fatalf("StartOfMonth = %s", month.Format(CSTLayout))
+	}
+}
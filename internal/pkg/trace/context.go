@@ -0,0 +1,18 @@
+package trace
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext 返回携带 Trace 的 context，用于向下游调用传递链路
+func NewContext(ctx context.Context, t T) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext 获取 context 携带的 Trace，不存在时返回 nil
+func FromContext(ctx context.Context) T {
+	if t, ok := ctx.Value(contextKey{}).(T); ok && t != nil {
+		return t
+	}
+	return nil
+}
@@ -1,8 +1,9 @@
 package trace
 
 type Redis struct {
-	Time        string      `json:"time"`         // 时间，格式：2006-01-02 15:04:05
-	Stack       string      `json:"stack"`        // 文件地址和行号
-	Cmd         interface{} `json:"cmd"`          // 操作
-	CostSeconds float64     `json:"cost_seconds"` // 执行时间(单位秒)
+	Time        string      `json:"time"`             // 时间，格式：2006-01-02 15:04:05
+	Stack       string      `json:"stack"`            // 文件地址和行号
+	Cmd         interface{} `json:"cmd"`              // 操作
+	Script      string      `json:"script,omitempty"` // Lua 脚本名称，仅 EVAL / EVALSHA 执行已注册脚本时记录
+	CostSeconds float64     `json:"cost_seconds"`     // 执行时间(单位秒)
 }
@@ -0,0 +1,10 @@
+package trace
+
+type Resilience struct {
+	Time     string `json:"time"`              // 时间，格式：2006-01-02 15:04:05
+	Pipeline string `json:"pipeline"`          // 下游依赖名称
+	Stage    string `json:"stage"`             // 策略：retry / timeout / ratelimit / breaker / hedge / bulkhead / fallback
+	Decision string `json:"decision"`          // 策略做出的决策，如 retry、reject、hedge
+	Attempt  int    `json:"attempt,omitempty"` // 第几次尝试，从 1 开始
+	Error    string `json:"error,omitempty"`   // 触发决策的错误
+}
@@ -20,21 +20,23 @@ type T interface {
 	AppendDebug(debug *Debug) *Trace
 	AppendRedis(redis *Redis) *Trace
 	AppendMongo(mongo *Mongo) *Trace
+	AppendResilience(resilience *Resilience) *Trace
 }
 
 // Trace 记录的参数
 type Trace struct {
 	mux                sync.Mutex
-	Identifier         string     `json:"trace_id"`             // 链路ID
-	Request            *Request   `json:"request"`              // 请求信息
-	Response           *Response  `json:"response"`             // 返回信息
-	ThirdPartyRequests []*HttpLog `json:"third_party_requests"` // 调用第三方接口的信息
-	Debugs             []*Debug   `json:"debugs"`               // 调试信息
-	SQLs               []*SQL     `json:"sqls"`                 // 执行的 SQL 信息
-	Redis              []*Redis   `json:"redis"`                // 执行的 Redis 信息
-	Mongos             []*Mongo   `json:"mongos"`               // 执行的 Mongo 信息
-	Success            bool       `json:"success"`              // 请求结果 true or false
-	CostSeconds        float64    `json:"cost_seconds"`         // 执行时长(单位秒)
+	Identifier         string        `json:"trace_id"`             // 链路ID
+	Request            *Request      `json:"request"`              // 请求信息
+	Response           *Response     `json:"response"`             // 返回信息
+	ThirdPartyRequests []*HttpLog    `json:"third_party_requests"` // 调用第三方接口的信息
+	Debugs             []*Debug      `json:"debugs"`               // 调试信息
+	SQLs               []*SQL        `json:"sqls"`                 // 执行的 SQL 信息
+	Redis              []*Redis      `json:"redis"`                // 执行的 Redis 信息
+	Mongos             []*Mongo      `json:"mongos"`               // 执行的 Mongo 信息
+	Resiliences        []*Resilience `json:"resiliences"`          // 下游调用的容错决策
+	Success            bool          `json:"success"`              // 请求结果 true or false
+	CostSeconds        float64       `json:"cost_seconds"`         // 执行时长(单位秒)
 }
 
 // Request 请求信息
@@ -150,3 +152,16 @@ func (t *Trace) AppendMongo(mongo *Mongo) *Trace {
 	t.Mongos = append(t.Mongos, mongo)
 	return t
 }
+
+// AppendResilience 追加下游调用的容错决策
+func (t *Trace) AppendResilience(resilience *Resilience) *Trace {
+	if resilience == nil {
+		return t
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.Resiliences = append(t.Resiliences, resilience)
+	return t
+}
@@ -4,9 +4,12 @@ import "encoding/json"
 
 // SessionUserInfo 当前用户会话信息
 type SessionUserInfo struct {
-	Id       int32  `json:"id"`       // ID
-	UserName string `json:"username"` // 用户名
-	NickName string `json:"nickname"` // 昵称
+	Id       int32    `json:"id"`              // ID
+	UserName string   `json:"username"`        // 用户名
+	NickName string   `json:"nickname"`        // 昵称
+	Roles    []string `json:"roles,omitempty"` // 角色
+
+	Criticality string `json:"criticality,omitempty"` // 请求优先级，过载时低优先级的请求先被拒绝
 }
 
 // Marshal 序列化到JSON
@@ -0,0 +1,17 @@
+package redis
+
+import (
+	"context"
+
+	redisV8 "github.com/go-redis/redis/v8"
+)
+
+// ForEachNode 在需要遍历键空间（如 SCAN）的节点上执行 fn，集群模式下遍历所有主节点，其他模式直接在客户端上执行
+func ForEachNode(ctx context.Context, client redisV8.UniversalClient, fn func(ctx context.Context, node redisV8.UniversalClient) error) error {
+	if cluster, ok := client.(*redisV8.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redisV8.Client) error {
+			return fn(ctx, node)
+		})
+	}
+	return fn(ctx, client)
+}
@@ -16,26 +16,41 @@ import (
 	redisV8 "github.com/go-redis/redis/v8"
 )
 
+const (
+	// ModeStandalone 单节点模式（默认）
+	ModeStandalone = "standalone"
+	// ModeSentinel 哨兵模式，通过哨兵自动发现主节点并在故障时切换
+	ModeSentinel = "sentinel"
+	// ModeCluster 集群模式
+	ModeCluster = "cluster"
+)
+
 var (
-	client *redisV8.Client
+	client redisV8.UniversalClient
 	once   sync.Once
 )
 
 type Repo interface {
-	GetClient() *redisV8.Client
+	GetClient() redisV8.UniversalClient
 	Close() error
 }
 
 type redisRepo struct {
-	client *redisV8.Client
+	client redisV8.UniversalClient
 }
 
-type loggingHook struct {
-	ts time.Time
-}
+type loggingHook struct{}
+
+// startTimeKey 命令开始执行时间在上下文中的键
+type startTimeKey struct{}
 
 func (h *loggingHook) BeforeProcess(ctx context.Context, cmd redisV8.Cmder) (context.Context, error) {
-	h.ts = time.Now()
+	// 开始时间保存在上下文中，hook 会被并发调用，不能保存在结构体字段里
+	// 仍需返回 core.StdContext，AfterProcess 才能取到 Trace
+	if monoCtx, ok := ctx.(core.StdContext); ok {
+		monoCtx.Context = context.WithValue(monoCtx.Context, startTimeKey{}, time.Now())
+		return monoCtx, nil
+	}
 	return ctx, nil
 }
 
@@ -54,7 +69,10 @@ func (h *loggingHook) AfterProcess(ctx context.Context, cmd redisV8.Cmder) error
 		redisInfo.Time = timeutil.CSTLayoutString()
 		redisInfo.Stack = fileWithLineNum()
 		redisInfo.Cmd = cmd.String()
-		redisInfo.CostSeconds = time.Since(h.ts).Seconds()
+		redisInfo.Script = scriptName(cmd)
+		if ts, ok := monoCtx.Value(startTimeKey{}).(time.Time); ok {
+			redisInfo.CostSeconds = time.Since(ts).Seconds()
+		}
 
 		monoCtx.Trace.AppendRedis(redisInfo)
 	}
@@ -73,19 +91,7 @@ func (h *loggingHook) AfterProcessPipeline(ctx context.Context, cmds []redisV8.C
 // New 创建Redis仓库实例
 func New() Repo {
 	once.Do(func() {
-		cfg := configs.Get().Redis
-		client = redisV8.NewClient(&redisV8.Options{
-			Addr:         cfg.Addr,
-			Password:     cfg.Pass,
-			DB:           cfg.Db,
-			MaxRetries:   3,
-			DialTimeout:  time.Second * 5,
-			ReadTimeout:  time.Second * 20,
-			WriteTimeout: time.Second * 20,
-			PoolSize:     50,
-			MinIdleConns: 2,
-			PoolTimeout:  time.Minute,
-		})
+		client = newClient()
 
 		// 增加重试机制确保连接成功
 		var err error
@@ -110,7 +116,61 @@ func New() Repo {
 	}
 }
 
-func (r *redisRepo) GetClient() *redisV8.Client {
+// newClient 根据配置的模式创建客户端
+func newClient() redisV8.UniversalClient {
+	cfg := configs.Get().Redis
+
+	addrs := cfg.Addrs
+	if len(addrs) == 0 && cfg.Addr != "" {
+		addrs = []string{cfg.Addr}
+	}
+
+	switch cfg.Mode {
+	case ModeSentinel:
+		return redisV8.NewFailoverClient(&redisV8.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelPassword: cfg.SentinelPass,
+			Password:         cfg.Pass,
+			DB:               cfg.Db,
+			MaxRetries:       3,
+			DialTimeout:      time.Second * 5,
+			ReadTimeout:      time.Second * 20,
+			WriteTimeout:     time.Second * 20,
+			PoolSize:         50,
+			MinIdleConns:     2,
+			PoolTimeout:      time.Minute,
+		})
+	case ModeCluster:
+		// 集群模式不支持选择 DB，连接池按节点分配
+		return redisV8.NewClusterClient(&redisV8.ClusterOptions{
+			Addrs:        addrs,
+			Password:     cfg.Pass,
+			MaxRetries:   3,
+			DialTimeout:  time.Second * 5,
+			ReadTimeout:  time.Second * 20,
+			WriteTimeout: time.Second * 20,
+			PoolSize:     50,
+			MinIdleConns: 2,
+			PoolTimeout:  time.Minute,
+		})
+	default:
+		return redisV8.NewClient(&redisV8.Options{
+			Addr:         cfg.Addr,
+			Password:     cfg.Pass,
+			DB:           cfg.Db,
+			MaxRetries:   3,
+			DialTimeout:  time.Second * 5,
+			ReadTimeout:  time.Second * 20,
+			WriteTimeout: time.Second * 20,
+			PoolSize:     50,
+			MinIdleConns: 2,
+			PoolTimeout:  time.Minute,
+		})
+	}
+}
+
+func (r *redisRepo) GetClient() redisV8.UniversalClient {
 	return r.client
 }
 
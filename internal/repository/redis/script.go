@@ -0,0 +1,131 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"path"
+	"strings"
+
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+//go:embed scripts/*.lua
+var scriptFiles embed.FS
+
+var (
+	// scripts 按名称（文件名去掉 .lua）索引的脚本
+	scripts = mustLoadScripts()
+	// scriptsByHash 按 SHA1 索引的脚本，用于在 trace 中还原脚本名称
+	scriptsByHash = indexScriptsByHash(scripts)
+)
+
+// Script 已注册的 Lua 脚本，脚本源码以 scripts/*.lua 的形式随程序一起编译
+type Script struct {
+	name   string
+	script *redisV8.Script
+}
+
+// Name 脚本名称
+func (s *Script) Name() string {
+	return s.name
+}
+
+// Hash 脚本的 SHA1，即 EVALSHA 使用的值
+func (s *Script) Hash() string {
+	return s.script.Hash()
+}
+
+// Run 通过 EVALSHA 执行脚本，服务端未缓存该脚本（NOSCRIPT）时自动回退为 EVAL
+//
+// 在 pipeline 中执行时错误要到 Exec 才能拿到，无法自动回退，应确保启动时已调用 LoadScripts
+func (s *Script) Run(ctx context.Context, c redisV8.Scripter, keys []string, args ...interface{}) *redisV8.Cmd {
+	return s.script.Run(ctx, c, keys, args...)
+}
+
+// Eval 通过 EVAL 执行脚本，用于 pipeline 等无法处理 NOSCRIPT 的场景
+func (s *Script) Eval(ctx context.Context, c redisV8.Scripter, keys []string, args ...interface{}) *redisV8.Cmd {
+	return s.script.Eval(ctx, c, keys, args...)
+}
+
+// GetScript 按名称获取脚本，未注册时 panic
+//
+// 应在包级变量中获取，使拼写错误在启动时暴露
+func GetScript(name string) *Script {
+	s, ok := scripts[name]
+	if !ok {
+		panic("redis: script not found: " + name)
+	}
+	return s
+}
+
+// LoadScripts 通过 SCRIPT LOAD 预加载全部脚本，集群模式下会加载到每个主节点
+func LoadScripts(ctx context.Context, c redisV8.Scripter) error {
+	for _, s := range scripts {
+		if err := s.script.Load(ctx, c).Err(); err != nil {
+			return errors.Wrapf(err, "redis: load script %s", s.name)
+		}
+	}
+	return nil
+}
+
+// scriptName 根据 EVAL / EVALSHA 命令还原脚本名称，不是已注册的脚本时返回空字符串
+func scriptName(cmd redisV8.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+
+	body, ok := args[1].(string)
+	if !ok {
+		return ""
+	}
+
+	var hash string
+	switch strings.ToLower(cmd.Name()) {
+	case "evalsha":
+		hash = body
+	case "eval":
+		sum := sha1.Sum([]byte(body))
+		hash = hex.EncodeToString(sum[:])
+	default:
+		return ""
+	}
+
+	if s, ok := scriptsByHash[hash]; ok {
+		return s.name
+	}
+	return ""
+}
+
+func mustLoadScripts() map[string]*Script {
+	files, err := scriptFiles.ReadDir("scripts")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]*Script, len(files))
+	for _, file := range files {
+		src, err := scriptFiles.ReadFile(path.Join("scripts", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		name := strings.TrimSuffix(file.Name(), ".lua")
+		loaded[name] = &Script{
+			name:   name,
+			script: redisV8.NewScript(string(src)),
+		}
+	}
+	return loaded
+}
+
+func indexScriptsByHash(scripts map[string]*Script) map[string]*Script {
+	index := make(map[string]*Script, len(scripts))
+	for _, s := range scripts {
+		index[s.Hash()] = s
+	}
+	return index
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	redisV8 "github.com/go-redis/redis/v8"
+)
+
+func TestScriptName(t *testing.T) {
+	s := GetScript("lock_release")
+	ctx := context.Background()
+
+	cases := []struct {
+		cmd  redisV8.Cmder
+		want string
+	}{
+		{redisV8.NewCmd(ctx, "evalsha", s.Hash(), 1, "k", "v"), "lock_release"},
+		{redisV8.NewCmd(ctx, "eval", s.script.Eval(ctx, nopScripter{}, nil).Args()[1], 1, "k", "v"), "lock_release"},
+		{redisV8.NewCmd(ctx, "evalsha", "0000", 0), ""},
+		{redisV8.NewCmd(ctx, "get", "k"), ""},
+	}
+	for _, c := range cases {
+		if got := scriptName(c.cmd); got != c.want {
+			t.Errorf("scriptName(%v) = %q, want %q", c.cmd.Args(), got, c.want)
+		}
+	}
+}
+
+func TestGetScriptMissing(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for unknown script")
+		}
+	}()
+	GetScript("not_exists")
+}
+
+// nopScripter 只构造命令，不发送
+type nopScripter struct{}
+
+func (nopScripter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redisV8.Cmd {
+	return redisV8.NewCmd(ctx, "eval", script, len(keys))
+}
+
+func (nopScripter) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redisV8.Cmd {
+	return redisV8.NewCmd(ctx, "evalsha", sha1, len(keys))
+}
+
+func (nopScripter) ScriptExists(ctx context.Context, hashes ...string) *redisV8.BoolSliceCmd {
+	return redisV8.NewBoolSliceCmd(ctx, "script", "exists")
+}
+
+func (nopScripter) ScriptLoad(ctx context.Context, script string) *redisV8.StringCmd {
+	return redisV8.NewStringCmd(ctx, "script", "load", script)
+}
@@ -0,0 +1,11 @@
+-- 设置布隆过滤器元素对应的位，正在重建时同时写入重建键，避免重建完成后丢失期间新增的元素
+-- KEYS[1] 过滤器键  KEYS[2] 重建键（存在即表示正在重建）
+-- ARGV 元素对应的位偏移
+local rebuilding = redis.call('EXISTS', KEYS[2]) == 1
+for i = 1, #ARGV do
+	redis.call('SETBIT', KEYS[1], ARGV[i], 1)
+	if rebuilding then
+		redis.call('SETBIT', KEYS[2], ARGV[i], 1)
+	end
+end
+return 1
@@ -0,0 +1,7 @@
+-- 增加计数，键没有过期时间时（新建）设置过期时间
+-- KEYS[1] 计数键  ARGV[1] 增量  ARGV[2] 过期时间（毫秒），0 表示不过期
+local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v
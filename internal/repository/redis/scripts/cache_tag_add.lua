@@ -0,0 +1,7 @@
+-- 将缓存键加入标签集合，集合过期时间只延长不缩短
+-- KEYS[1] 标签集合键  ARGV[1] 缓存键  ARGV[2] 过期时间（毫秒）
+redis.call('SADD', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
@@ -0,0 +1,7 @@
+-- 加锁成功后递增 fencing token
+-- KEYS[1] 锁键  KEYS[2] token 计数键
+-- ARGV[1] 持有者标识  ARGV[2] 租约毫秒数
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
@@ -0,0 +1,6 @@
+-- 仅当持有者标识一致时续约
+-- KEYS[1] 锁键  ARGV[1] 持有者标识  ARGV[2] 租约毫秒数
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
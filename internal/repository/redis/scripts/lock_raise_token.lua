@@ -0,0 +1,7 @@
+-- 将 token 计数提升到不小于 ARGV[1]，用于 Redlock 模式同步各节点计数
+-- KEYS[1] token 计数键  ARGV[1] 目标值
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+if cur < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
@@ -0,0 +1,6 @@
+-- 仅当持有者标识一致时删除锁
+-- KEYS[1] 锁键  ARGV[1] 持有者标识
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
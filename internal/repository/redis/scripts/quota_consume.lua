@@ -0,0 +1,33 @@
+-- 调用配额计数，所有窗口都有余量时才计数，任一窗口超出时不修改计数
+-- KEYS[i] 第 i 个窗口的计数键
+-- ARGV[1] 本次消耗的次数
+-- ARGV[2 .. n+1] 各窗口的配额，0 表示不限制
+-- ARGV[n+2 .. 2n+1] 各窗口计数键的过期时间（Unix 秒）
+-- 返回 {超出配额的窗口序号（从 1 开始，0 表示未超出）, 各窗口已用次数...}
+local n = #KEYS
+local cost = tonumber(ARGV[1])
+
+local used = {}
+local exceeded = 0
+for i = 1, n do
+	used[i] = tonumber(redis.call('GET', KEYS[i]) or '0')
+	local limit = tonumber(ARGV[i + 1])
+	if exceeded == 0 and limit > 0 and used[i] + cost > limit then
+		exceeded = i
+	end
+end
+
+if exceeded == 0 then
+	for i = 1, n do
+		used[i] = redis.call('INCRBY', KEYS[i], cost)
+		if used[i] == cost then
+			redis.call('EXPIREAT', KEYS[i], ARGV[n + i + 1])
+		end
+	end
+end
+
+local result = {exceeded}
+for i = 1, n do
+	result[i + 1] = used[i]
+end
+return result
@@ -0,0 +1,25 @@
+-- GCRA 限流，使用 Redis 服务器时间，多实例之间不受本地时钟偏差影响
+-- KEYS[1] 理论到达时间（TAT）键
+-- ARGV[1] 发放间隔（微秒）  ARGV[2] 突发容忍度（微秒），即 发放间隔 * (突发数 - 1)
+-- 返回 {是否允许, 需要等待的微秒数}
+redis.replicate_commands()
+
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local wait = tat - now - tolerance
+if wait > 0 then
+	return {0, wait}
+end
+
+local new_tat = tat + interval
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, 0}
@@ -0,0 +1,22 @@
+-- 滑动日志限流，以有序集合记录窗口内每次请求的时间
+-- KEYS[1] 请求日志键
+-- ARGV[1] 窗口长度（微秒）  ARGV[2] 窗口内允许的请求数  ARGV[3] 本次请求的唯一标识
+-- 返回 {是否允许, 需要等待的微秒数}
+redis.replicate_commands()
+
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', string.format('%.0f', now - window))
+
+if redis.call('ZCARD', KEYS[1]) >= limit then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {0, math.max(tonumber(oldest[2]) + window - now, 0)}
+end
+
+redis.call('ZADD', KEYS[1], string.format('%.0f', now), ARGV[3])
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+return {1, 0}
@@ -0,0 +1,28 @@
+package interceptor
+
+import (
+	"net/http"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/ratelimit"
+	"gin-example/internal/proposal"
+)
+
+// APIAuthVerify 接口鉴权，携带 API Key 时校验是否为配额绑定中配置的 Key，否则校验 JWT
+// 需在限流和配额中间件之前执行，保证按用户、角色和 API Key 区分的调用方均已校验
+func (i *Interceptor) APIAuthVerify(ctx core.Context) (sessionUserInfo proposal.SessionUserInfo, err core.BusinessError) {
+	apiKey := ctx.GetHeader(ratelimit.APIKeyHeader)
+	if apiKey == "" {
+		return i.JWTokenAuthVerify(ctx)
+	}
+
+	if _, ok := i.apiKeys[apiKey]; !ok {
+		err = core.Error(
+			http.StatusUnauthorized,
+			code.APIKeyInvalid,
+			code.Text(code.APIKeyInvalid))
+	}
+
+	return
+}
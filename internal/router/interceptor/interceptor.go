@@ -1,51 +1,159 @@
 package interceptor
 
 import (
-	"time"
+	"encoding/json"
+	"strings"
+	"sync"
 
+	"gin-example/configs"
+	"gin-example/internal/pkg/cache"
 	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/quota"
 	"gin-example/internal/pkg/ratelimit"
 
+	"github.com/fsnotify/fsnotify"
+	redisV8 "github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 // Interceptor 拦截器
 type Interceptor struct {
-	logger         *zap.Logger
-	rateLimiter    ratelimit.Limiter
-	circuitBreaker *circuitbreaker.CircuitBreaker
-}
-
-// NewInterceptor 创建拦截器
-func NewInterceptor(logger *zap.Logger) *Interceptor {
-	// 创建令牌桶限流器，每秒100个请求
-	rateLimiter := ratelimit.NewTokenBucketLimiter(100)
-	
-	// 创建熔断器配置
-	config := &circuitbreaker.Config{
-		FailureThreshold: 5,
-		Timeout:          time.Second,
-		ResetTimeout:     time.Second * 10,
-		SuccessThreshold: 1,
-		ServiceName:      "http-server",
-	}
-	
-	// 创建熔断器
-	circuitBreaker := circuitbreaker.NewCircuitBreaker(config)
+	logger      *zap.Logger
+	cache       cache.Cache
+	rateLimiter *ratelimit.RouteLimiter
+	apiKeys     map[string]struct{} // 配额绑定中配置的 API Key
+
+	mu       sync.Mutex
+	breakers map[string]*circuitbreaker.CircuitBreaker // 路由组名称 -> 服务端熔断器
+}
+
+// NewInterceptor 创建拦截器，backend 为 redis 的限流规则使用 redisClient 在实例之间共享配额
+func NewInterceptor(logger *zap.Logger, cache cache.Cache, redisClient redisV8.UniversalClient) *Interceptor {
+	// 按配置的规则创建限流器
+	cfg := configs.Get().RateLimit
+	proxies, err := ratelimit.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("rate limit trusted proxies parse error, forwarding headers are ignored", zap.Error(err))
+	}
+	rateLimiter := ratelimit.NewRouteLimiter(toRules(cfg.Rules), cfg.MaxKeys, cfg.IdleTTL,
+		ratelimit.WithTrustedProxies(proxies),
+		ratelimit.WithRedisBackend(redisClient),
+		ratelimit.WithRouteLogger(logger),
+	)
+	if cfg.RulesFile != "" {
+		watchRulesFile(logger, cfg.RulesFile, rateLimiter)
+	}
+
+	// 只接受配额绑定中配置的 API Key
+	apiKeys := make(map[string]struct{})
+	for _, binding := range configs.Get().Quota.Bindings {
+		if key := strings.TrimPrefix(binding.Subject, quota.APIKeySubjectPrefix); key != binding.Subject && key != "" {
+			apiKeys[key] = struct{}{}
+		}
+	}
 
 	return &Interceptor{
-		logger:         logger,
-		rateLimiter:    rateLimiter,
-		circuitBreaker: circuitBreaker,
+		logger:      logger,
+		cache:       cache,
+		rateLimiter: rateLimiter,
+		apiKeys:     apiKeys,
+		breakers:    make(map[string]*circuitbreaker.CircuitBreaker),
 	}
 }
 
 // GetRateLimiter 获取限流器
-func (i *Interceptor) GetRateLimiter() ratelimit.Limiter {
+func (i *Interceptor) GetRateLimiter() *ratelimit.RouteLimiter {
 	return i.rateLimiter
 }
 
-// GetCircuitBreaker 获取熔断器
-func (i *Interceptor) GetCircuitBreaker() *circuitbreaker.CircuitBreaker {
-	return i.circuitBreaker
-}
\ No newline at end of file
+// RateLimit 按规则限流
+func (i *Interceptor) RateLimit() core.HandlerFunc {
+	return i.rateLimiter.Middleware()
+}
+
+// CircuitBreak 路由组熔断，每个路由组使用独立的熔断器，按 [[circuitBreaker.routes]] 中同名的配置降级
+// 未配置的路由组使用默认熔断策略，熔断时返回 503
+func (i *Interceptor) CircuitBreak(group string) core.HandlerFunc {
+	var route configs.CircuitBreakerRoute
+	for _, r := range configs.Get().CircuitBreaker.Routes {
+		if r.Name == group {
+			route = r
+			break
+		}
+	}
+
+	// 创建熔断器，加入全局目录供管理接口使用
+	cb := circuitbreaker.NewCircuitBreaker(circuitbreaker.PolicyConfig(circuitbreaker.ServerNamePrefix+group, route.Policy))
+	circuitbreaker.Register(cb)
+
+	i.mu.Lock()
+	i.breakers[group] = cb
+	i.mu.Unlock()
+
+	var options []circuitbreaker.ServerOption
+	if len(route.Methods) > 0 {
+		options = append(options, circuitbreaker.WithMethods(route.Methods...))
+	}
+	if route.Timeout > 0 {
+		options = append(options, circuitbreaker.WithHandlerTimeout(route.Timeout))
+	}
+	if route.Fallback == circuitbreaker.FallbackCache {
+		options = append(options, circuitbreaker.WithCacheFallback(i.cache, route.CacheTTL))
+	}
+	if route.Payload != "" && (route.Fallback == circuitbreaker.FallbackStatic || route.Fallback == circuitbreaker.FallbackCache) {
+		if json.Valid([]byte(route.Payload)) {
+			options = append(options, circuitbreaker.WithStaticFallback(json.RawMessage(route.Payload)))
+		} else {
+			i.logger.Error("invalid circuit breaker fallback payload", zap.String("group", group))
+		}
+	}
+
+	return circuitbreaker.Middleware(cb, options...)
+}
+
+// GetCircuitBreaker 获取路由组的熔断器
+func (i *Interceptor) GetCircuitBreaker(group string) (*circuitbreaker.CircuitBreaker, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	cb, ok := i.breakers[group]
+	return cb, ok
+}
+
+// watchRulesFile 加载外部规则文件，文件修改后重新加载规则
+func watchRulesFile(logger *zap.Logger, file string, limiter *ratelimit.RouteLimiter) {
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	load := func() {
+		var rules []configs.RateLimitRule
+		if err := v.UnmarshalKey("rules", &rules); err != nil {
+			logger.Error("rate limit rules unmarshal error", zap.String("file", file), zap.Error(err))
+			return
+		}
+
+		limiter.UpdateRules(toRules(rules))
+		logger.Info("rate limit rules loaded", zap.String("file", file), zap.Int("rules", len(rules)))
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		logger.Error("rate limit rules read error", zap.String("file", file), zap.Error(err))
+	} else {
+		load()
+	}
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		load()
+	})
+	v.WatchConfig()
+}
+
+func toRules(items []configs.RateLimitRule) []ratelimit.Rule {
+	rules := make([]ratelimit.Rule, len(items))
+	for i, item := range items {
+		rules[i] = ratelimit.Rule(item)
+	}
+	return rules
+}
@@ -3,6 +3,7 @@ package interceptor
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"gin-example/configs"
 	"gin-example/internal/code"
@@ -23,8 +24,8 @@ func (i *Interceptor) JWTokenAuthVerify(ctx core.Context) (sessionUserInfo propo
 		return
 	}
 
-	// 验证 JWT 是否合法
-	jwtClaims, jwtErr := jwtoken.New(configs.Get().JWT.Secret).Parse(headerAuthorizationString)
+	// 验证 JWT 是否合法，兼容 Bearer 前缀
+	jwtClaims, jwtErr := jwtoken.New(configs.Get().JWT.Secret).Parse(strings.TrimPrefix(headerAuthorizationString, "Bearer "))
 	if jwtErr != nil {
 		err = core.Error(
 			http.StatusUnauthorized,
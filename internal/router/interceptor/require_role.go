@@ -0,0 +1,37 @@
+package interceptor
+
+import (
+	"net/http"
+
+	"gin-example/internal/code"
+	"gin-example/internal/pkg/core"
+)
+
+// AdminRole 管理员角色，运维接口要求具有该角色
+const AdminRole = "admin"
+
+// RequireRole 要求登录用户具有指定角色，需在 JWTokenAuthVerify 之后执行
+func (i *Interceptor) RequireRole(role string) core.HandlerFunc {
+	return func(ctx core.Context) {
+		for _, r := range ctx.SessionUserInfo().Roles {
+			if r == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithError(core.Error(
+			http.StatusForbidden,
+			code.PermissionDenied,
+			code.Text(code.PermissionDenied)),
+		)
+	}
+}
+
+// AdminAuth 运维接口鉴权，校验 JWT 并要求管理员角色
+func (i *Interceptor) AdminAuth() []core.HandlerFunc {
+	return []core.HandlerFunc{
+		core.WrapAuthHandler(i.JWTokenAuthVerify),
+		i.RequireRole(AdminRole),
+	}
+}
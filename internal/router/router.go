@@ -1,13 +1,24 @@
 package router
 
 import (
+	"strings"
+
+	"gin-example/configs"
 	"gin-example/internal/api/admin"
 	"gin-example/internal/api/auth"
+	quotaAPI "gin-example/internal/api/quota"
 	"gin-example/internal/api/system"
 	"gin-example/internal/pkg/cache"
+	"gin-example/internal/pkg/circuitbreaker"
+	"gin-example/internal/pkg/concurrency"
 	"gin-example/internal/pkg/core"
+	"gin-example/internal/pkg/jwtoken"
+	"gin-example/internal/pkg/quota"
+	"gin-example/internal/pkg/shedding"
+	"gin-example/internal/pkg/shutdown"
 	"gin-example/internal/repository/mysql"
 	"gin-example/internal/repository/redis"
+	"gin-example/internal/router/interceptor"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -30,28 +41,146 @@ func NewHTTPMux(logger *zap.Logger, db mysql.Repo, cache cache.Cache, redisRepo
 		return nil, errors.New("redis required")
 	}
 
-	mux, err := core.New(logger,
+	options := []core.Option{
 		core.WithEnableCors(),
 		core.WithEnableSwagger(),
 		core.WithEnablePProf(),
-	)
+	}
+
+	var limiter *concurrency.Limiter
+	if cfg := configs.Get().Concurrency; cfg.Enable {
+		limiter = newConcurrencyLimiter()
+		options = append(options, core.WithAdaptiveConcurrency(limiter))
+	}
+	if cfg := configs.Get().Shedding; cfg.Enable {
+		options = append(options, core.WithLoadShedder(newShedder(limiter)))
+	}
+
+	mux, err := core.New(logger, options...)
 
 	if err != nil {
 		panic(err)
 	}
 
+	interceptors := interceptor.NewInterceptor(logger, cache, (*redisRepo).GetClient())
+
 	// 注册系统路由（包括健康检查）
-	system.RegisterHealthRoutes(logger, db, redisRepo, mux)
+	system.RegisterHealthRoutes(logger, db, redisRepo, cache, mux, interceptors.AdminAuth()...)
+
+	// 注册熔断器管理路由
+	var propagator *circuitbreaker.Propagator
+	if cfg := configs.Get().CircuitBreaker; cfg.Propagate {
+		propagator = circuitbreaker.NewPropagator((*redisRepo).GetClient(), logger, cfg.Channel)
+		// 在关闭 Redis 连接之前停止订阅
+		shutdown.RegisterHook(func() {
+			if err := propagator.Close(); err != nil {
+				logger.Error("Failed to close circuit breaker propagator", zap.Error(err))
+			}
+		})
+	}
+	system.RegisterBreakerRoutes(logger, propagator, mux, interceptors.AdminAuth()...)
 
 	// 注册认证路由
 	auth.RegisterAuthRoutes(logger, mux)
 
-	// 定义自动生成的路由组前缀为 /api
-	generatedRouterGroup := mux.Group("/api")
-	// 注意：由于RouterGroup接口没有Use方法，中间件需要在具体的路由中添加
+	// /api 下的请求先鉴权（API Key 或 JWT），限流和配额按校验后的调用方区分
+	apiAuth := core.WrapAuthHandler(interceptors.APIAuthVerify)
+	apiMiddlewares := []core.HandlerFunc{apiAuth, interceptors.RateLimit()}
 
-	// 注册路由
-	admin.RegisterGeneratedAdminRoutes(logger, db, generatedRouterGroup, cache)
+	// 注册配额路由，/api 下的请求按套餐计量
+	if cfg := configs.Get().Quota; cfg.Enable {
+		quotaManager := newQuotaManager(logger, redisRepo)
+		quotaAPI.RegisterQuotaRoutes(logger, quotaManager, mux, apiAuth, interceptors.AdminAuth()...)
+		apiMiddlewares = append(apiMiddlewares, quotaManager.Middleware())
+	}
+
+	// 定义自动生成的路由组前缀为 /api，鉴权后按配置的规则限流
+	generatedRouterGroup := mux.Group("/api", apiMiddlewares...)
+
+	// 注册路由，admin 路由组使用独立的熔断器，MySQL 不可用时读接口按配置降级
+	adminRouterGroup := generatedRouterGroup.Group("", interceptors.CircuitBreak("admin"))
+	admin.RegisterGeneratedAdminRoutes(logger, db, adminRouterGroup, cache)
 
 	return mux, nil
 }
+
+// newQuotaManager 按配置创建配额管理器
+func newQuotaManager(logger *zap.Logger, redisRepo *redis.Repo) *quota.Manager {
+	cfg := configs.Get().Quota
+
+	plans := make([]quota.Plan, len(cfg.Plans))
+	for i, p := range cfg.Plans {
+		plans[i] = quota.Plan(p)
+	}
+
+	bindings := make(map[string]string, len(cfg.Bindings))
+	for _, b := range cfg.Bindings {
+		bindings[b.Subject] = b.Plan
+	}
+
+	options := []quota.Option{
+		quota.WithDefaultPlan(cfg.DefaultPlan),
+		quota.WithBindings(bindings),
+		quota.WithLogger(logger),
+	}
+	if cfg.Retention > 0 {
+		options = append(options, quota.WithRetention(cfg.Retention))
+	}
+
+	return quota.New((*redisRepo).GetClient(), plans, options...)
+}
+
+// newConcurrencyLimiter 按配置创建自适应并发限制器
+func newConcurrencyLimiter() *concurrency.Limiter {
+	cfg := configs.Get().Concurrency
+
+	algorithm := concurrency.NewAlgorithm(cfg.Algorithm, concurrency.Bounds{
+		Initial: cfg.InitialLimit,
+		Min:     cfg.MinLimit,
+		Max:     cfg.MaxLimit,
+	}, cfg.Timeout)
+
+	return concurrency.NewLimiter("http-server", algorithm, concurrency.WithQueue(cfg.MaxQueue, cfg.MaxWait))
+}
+
+// newShedder 按配置创建负载保护，limiter 不为空时同时以并发占用作为过载信号
+func newShedder(limiter *concurrency.Limiter) *shedding.Shedder {
+	cfg := configs.Get().Shedding
+
+	rules := make([]shedding.Rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		criticality, _ := shedding.ParseCriticality(r.Criticality)
+		rules = append(rules, shedding.Rule{
+			Path:        r.Path,
+			Methods:     r.Methods,
+			Criticality: criticality,
+		})
+	}
+
+	signals := []shedding.Signal{shedding.CPUSignal(cfg.CPUThreshold)}
+	if limiter != nil {
+		signals = append(signals, shedding.ConcurrencySignal(limiter))
+	}
+
+	token := jwtoken.New(configs.Get().JWT.Secret)
+	options := []shedding.Option{
+		shedding.WithSignals(signals...),
+		shedding.WithRules(rules),
+		shedding.WithClaim(func(ctx core.Context) string {
+			// 负载保护在认证之前执行，从 Token 中直接读取声明，解析失败时忽略
+			claims, err := token.Parse(strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer "))
+			if err != nil {
+				return ""
+			}
+			return claims.Criticality
+		}),
+	}
+	if cfg.SheddableThreshold > 0 && cfg.DefaultThreshold > 0 && cfg.HighThreshold > 0 {
+		options = append(options, shedding.WithThresholds(cfg.SheddableThreshold, cfg.DefaultThreshold, cfg.HighThreshold))
+	}
+	if cfg.TrustHeader {
+		options = append(options, shedding.WithTrustHeader())
+	}
+
+	return shedding.New(options...)
+}
@@ -121,25 +121,85 @@ func main() {
 
 		_, err := redisClient.Ping(ctx).Result()
 		if err != nil {
-			accessLogger.Warn("Failed to ping Redis server", zap.Error(err))
-			redisClient = nil // 置空客户端，后续只使用本地缓存
+			// 不再置空客户端，多级缓存会以 L1-only 模式启动并在后台探测 Redis 恢复
+			accessLogger.Warn("Failed to ping Redis server, cache will start in l1-only mode", zap.Error(err))
 		} else {
 			accessLogger.Info("Redis connected successfully")
+
+			// 预加载 Lua 脚本，之后通过 EVALSHA 执行
+			if err := redis.LoadScripts(ctx, redisClient); err != nil {
+				accessLogger.Warn("Failed to load Redis scripts", zap.Error(err))
+			}
 		}
 	}
 
 	// 创建缓存实例
+	cacheCodec, err := cache.CodecByName(configs.Get().Cache.Codec, configs.Get().Cache.CompressThreshold)
+	if err != nil {
+		accessLogger.Fatal("Invalid cache codec", zap.Error(err))
+	}
+
 	var appCache cache.Cache
 	if redisClient != nil {
-		// 使用多级缓存（本地+Redis）
-		appCache = cache.NewMultiLevelCache(redisClient)
+		// 使用多级缓存（本地+Redis），通过 Redis 发布订阅在实例间同步 L1 失效
+		multiLevelCache := cache.NewMultiLevelCache(redisClient,
+			cache.WithLogger(accessLogger),
+			cache.WithInvalidationChannel(configs.ProjectName+":"+envName+":"+cache.DefaultInvalidationChannel),
+			cache.WithName("app"),
+			cache.WithCodec(cacheCodec),
+		)
+		shutdown.RegisterHook(func() {
+			if err := multiLevelCache.Close(); err != nil {
+				accessLogger.Error("Failed to close multi-level cache", zap.Error(err))
+			}
+		})
+		appCache = multiLevelCache
 		accessLogger.Info("Using multi-level cache (local + Redis)")
 	} else {
 		// 仅使用本地缓存
-		appCache = cache.NewLocalCache(1000, 5*time.Minute)
+		appCache = cache.NewLocalCache(1000, 5*time.Minute, cache.WithLocalCodec(cacheCodec))
 		accessLogger.Warn("Using local cache only")
 	}
 
+	// 启动时加载上次停机保存的L1快照，停机时重新保存
+	if snapshotConfig := configs.Get().Cache.Snapshot; snapshotConfig.Enable {
+		if snapshotter, ok := appCache.(cache.Snapshotter); ok {
+			if n, err := snapshotter.LoadSnapshot(snapshotConfig.Path, snapshotConfig.MaxAge); err != nil {
+				accessLogger.Warn("Failed to load cache snapshot", zap.Error(err))
+			} else {
+				accessLogger.Info("Cache snapshot loaded", zap.Int("entries", n))
+			}
+
+			shutdown.RegisterHook(func() {
+				if n, err := snapshotter.SaveSnapshot(snapshotConfig.Path); err != nil {
+					accessLogger.Error("Failed to save cache snapshot", zap.Error(err))
+				} else {
+					accessLogger.Info("Cache snapshot saved", zap.Int("entries", n))
+				}
+			})
+		}
+	}
+
+	// 初始化 HTTP 服务
+	accessLogger.Info("Initializing HTTP mux...")
+	httpMux, err := router.NewHTTPMux(accessLogger, dbRepo, appCache, &redisRepo)
+	if err != nil {
+		accessLogger.Fatal("Failed to create HTTP mux", zap.Error(err))
+		os.Exit(1)
+	}
+	accessLogger.Info("HTTP mux initialized successfully")
+
+	// 执行缓存预热，完成后再注册服务，避免冷缓存实例接收流量
+	warmupTimeout := configs.Get().Cache.Warmup.Timeout
+	if warmupTimeout <= 0 {
+		warmupTimeout = 30 * time.Second
+	}
+	warmupCtx, warmupCancel := context.WithTimeout(context.Background(), warmupTimeout)
+	if err := cache.Warmup(warmupCtx, accessLogger); err != nil {
+		accessLogger.Warn("Cache warm-up finished with errors", zap.Error(err))
+	}
+	warmupCancel()
+
 	// 初始化服务注册
 	accessLogger.Info("Initializing service registry...")
 	var serviceRegistry *etcd.Registry
@@ -168,15 +228,6 @@ func main() {
 		})
 	}
 
-	// 初始化 HTTP 服务
-	accessLogger.Info("Initializing HTTP mux...")
-	httpMux, err := router.NewHTTPMux(accessLogger, dbRepo, appCache, &redisRepo)
-	if err != nil {
-		accessLogger.Fatal("Failed to create HTTP mux", zap.Error(err))
-		os.Exit(1)
-	}
-	accessLogger.Info("HTTP mux initialized successfully")
-
 	server := &http.Server{
 		Addr:    configs.ProjectPort,
 		Handler: httpMux,
@@ -208,7 +259,6 @@ func main() {
 	}
 
 	// 执行停机钩子
-	shutdown.Close(func() {
-		accessLogger.Info("Server shutdown completed")
-	})
+	shutdown.RunHooks()
+	accessLogger.Info("Server shutdown completed")
 }